@@ -1,14 +1,26 @@
 package main
 
 import (
+	"bytes"
+	"config-service/auth"
 	"config-service/db"
 	"config-service/db/mongo"
+	"config-service/jobs"
 	"config-service/types"
+	"config-service/utils"
 	"config-service/utils/consts"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"sort"
+	"strings"
+	"time"
 
 	_ "embed"
 
@@ -86,6 +98,9 @@ func (suite *MainTestSuite) TestAdminAndUsers() {
 		populateUser(userGUID)
 		verifyUserData(userGUID)
 	}
+	//keep the session of user2 to verify it is revoked with the user data
+	suite.login(user2)
+	user2Cookie := suite.authCookie
 	//login as admin
 	suite.loginAsAdmin("a-admin-guid")
 	//delete users2 and users3 data
@@ -93,15 +108,13 @@ func (suite *MainTestSuite) TestAdminAndUsers() {
 	type deletedResponse struct {
 		Deleted int64 `json:"deleted"`
 	}
-	w := suite.doRequest(http.MethodDelete, deleteUsersUrls, nil)
-	suite.Equal(http.StatusOK, w.Code)
-	response, err := decodeResponse[*deletedResponse](w)
-	if err != nil {
-		suite.FailNow(err.Error())
-	}
+	deleted := deleteCustomersWithJob(suite, deleteUsersUrls)
 	//expect 2 customers doc and all what they have
 	deletedCount := 2 * (1 + len(clusters) + len(frameworks) + len(posturePolices) + len(vulnerabilityPolicies) + len(repositories) + len(registryCronJobs))
-	suite.Equal(int64(deletedCount), response.Deleted)
+	suite.Equal(int64(deletedCount), deleted)
+	//verify the session of user2 is revoked
+	suite.authCookie = user2Cookie
+	testBadRequest(suite, http.MethodGet, consts.ClusterPath, errorUnauthorized, nil, http.StatusUnauthorized)
 	//verify user1 data is still there
 	verifyUserData(user1)
 	//verify user2 and user3 data is gone
@@ -118,9 +131,9 @@ func (suite *MainTestSuite) TestAdminAndUsers() {
 	populateUser(user2)
 	verifyUserData(user2)
 	//test customer delete they own data with  DELETE /customer api
-	w = suite.doRequest(http.MethodDelete, consts.CustomerPath, nil)
+	w := suite.doRequest(http.MethodDelete, consts.CustomerPath, nil)
 	suite.Equal(http.StatusOK, w.Code)
-	response, err = decodeResponse[*deletedResponse](w)
+	response, err := decodeResponse[*deletedResponse](w)
 	if err != nil {
 		suite.FailNow(err.Error())
 	}
@@ -136,14 +149,7 @@ func (suite *MainTestSuite) TestAdminAndUsers() {
 	suite.login(admin)
 	//delete user1 data
 	deleteUsersUrls = fmt.Sprintf("%s/customers?%s=%s", consts.AdminPath, consts.CustomersParam, user1)
-	w = suite.doRequest(http.MethodDelete, deleteUsersUrls, nil)
-	suite.Equal(http.StatusOK, w.Code)
-	response, err = decodeResponse[*deletedResponse](w)
-	if err != nil {
-		suite.FailNow(err.Error())
-	}
-
-	suite.Equal(int64(deletedCount), response.Deleted)
+	suite.Equal(int64(deletedCount), deleteCustomersWithJob(suite, deleteUsersUrls))
 	//verify user1 data is gone
 	verifyUserDataDeleted(user1)
 
@@ -151,7 +157,90 @@ func (suite *MainTestSuite) TestAdminAndUsers() {
 	suite.loginAsAdmin("other-admin-guid")
 	deleteUsersUrls = fmt.Sprintf("%s/customers", consts.AdminPath)
 	testBadRequest(suite, http.MethodDelete, deleteUsersUrls, errorMissingQueryParams(consts.CustomersParam), nil, http.StatusBadRequest)
+	//test get not existing job
+	testBadRequest(suite, http.MethodGet, fmt.Sprintf("%s/jobs/%s", consts.AdminPath, "not-existing-job"), errorDocumentNotFound, nil, http.StatusNotFound)
+
+}
 
+// deleteCustomersWithJob submits a delete customers job, waits for it to complete and returns the number of deleted documents
+func deleteCustomersWithJob(suite *MainTestSuite, deleteUsersUrl string) int64 {
+	w := suite.doRequest(http.MethodDelete, deleteUsersUrl, nil)
+	suite.Equal(http.StatusAccepted, w.Code)
+	job, err := decodeResponse[*jobs.Job](w)
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	suite.Equal(jobs.StatusPending, job.Status)
+	jobUrl := w.Header().Get("Location")
+	suite.Equal(fmt.Sprintf("%s/jobs/%s", consts.AdminPath, job.ID), jobUrl)
+	err = retry(100, time.Millisecond*100, func() error {
+		w := suite.doRequest(http.MethodGet, jobUrl, nil)
+		if w.Code != http.StatusOK {
+			return fmt.Errorf("failed to get job status code %d", w.Code)
+		}
+		if job, err = decodeResponse[*jobs.Job](w); err != nil {
+			return err
+		}
+		if job.Status != jobs.StatusCompleted {
+			return fmt.Errorf("job status is %s", job.Status)
+		}
+		return nil
+	})
+	if err != nil {
+		suite.FailNow("delete customers job did not complete", err.Error())
+	}
+	suite.Equal(1, job.Attempts)
+	suite.True(job.Progress[consts.CustomersCollection].Done)
+	suite.True(job.Progress[consts.SessionsCollection].Done)
+	//revoked sessions are not deleted documents
+	return job.TotalCount() - job.Progress[consts.SessionsCollection].Count
+}
+
+func (suite *MainTestSuite) TestJobRetry() {
+	//the job fails in the second step of the first attempt
+	jobs.RegisterHandler("testFailOnce", func(ctx context.Context, job *jobs.Job, checkpoint jobs.Checkpointer) error {
+		if !job.Progress["first"].Done {
+			if err := checkpoint("first", jobs.Progress{Done: true, Count: job.Progress["first"].Count + 1}); err != nil {
+				return err
+			}
+		}
+		if job.Attempts == 1 {
+			return errors.New("second step failed")
+		}
+		return checkpoint("second", jobs.Progress{Done: true, Count: 1})
+	})
+	jobs.RegisterHandler("testAlwaysFail", func(ctx context.Context, job *jobs.Job, checkpoint jobs.Checkpointer) error {
+		return fmt.Errorf("attempt %d failed", job.Attempts)
+	})
+	waitForJob := func(jobType string, status jobs.Status) *jobs.Job {
+		job, err := jobs.Submit(context.Background(), jobType, nil, "admin-guid")
+		suite.NoError(err)
+		err = retry(100, time.Millisecond*100, func() error {
+			if job, err = jobs.Get(context.Background(), job.ID); err != nil {
+				return err
+			}
+			if job.Status != status {
+				return fmt.Errorf("job status is %s", job.Status)
+			}
+			return nil
+		})
+		if err != nil {
+			suite.FailNow("job did not finish", err.Error())
+		}
+		return job
+	}
+
+	//a failed attempt is retried from the last checkpoint
+	job := waitForJob("testFailOnce", jobs.StatusCompleted)
+	suite.Equal(2, job.Attempts)
+	suite.Equal(int64(1), job.Progress["first"].Count, "done steps are not repeated")
+	suite.True(job.Progress["second"].Done)
+	suite.Empty(job.Error)
+
+	//the job fails when the last attempt fails
+	job = waitForJob("testAlwaysFail", jobs.StatusFailed)
+	suite.Equal(2, job.Attempts, "the test configuration max attempts")
+	suite.Equal("attempt 2 failed", job.Error)
 }
 
 //go:embed test_data/active_users/users.json
@@ -245,3 +334,372 @@ func (suite *MainTestSuite) TestAdminActiveUsers() {
 	badParamTypeUrl = fmt.Sprintf("%s/activeCustomers?%s=%s&%s=%s&%s=%s", consts.AdminPath, consts.FromDateParam, "2024-01-01T20:00:00Z", consts.ToDateParam, "2024-01-01T20:00:00Z", consts.SkipParam, "some-bad-limit")
 	testBadRequest(suite, http.MethodGet, badParamTypeUrl, errorParamType(consts.SkipParam, "number"), nil, http.StatusBadRequest)
 }
+
+func (suite *MainTestSuite) TestAdminCloneCustomer() {
+	const (
+		source   = "clone-source-guid"
+		target   = "8d2f3a1c-6b7e-4c55-9a0e-1f2b3c4d5e6f"
+		scrubbed = "5e0c9b7a-2d4f-4e1b-8c3a-7f6e5d4c3b2a"
+	)
+	clusters, clustersNames := loadJson[*types.Cluster](clustersJson)
+	posturePolices, policiesNames := loadJson[*types.PostureExceptionPolicy](posturePoliciesJson)
+
+	//populate source tenant
+	suite.login(source)
+	sourceClusters := testBulkPostDocs(suite, consts.ClusterPath, clusters, newClusterCompareFilter)
+	testBulkPostDocs(suite, consts.PostureExceptionPolicyPath, posturePolices, commonCmpFilter)
+	customer := &types.Customer{
+		PortalBase: armotypes.PortalBase{
+			Name: source,
+			GUID: source,
+		},
+	}
+	testPostDoc(suite, consts.TenantPath, customer, customerCompareFilter)
+
+	type cloneResponse struct {
+		CustomerGUID string           `json:"customerGUID"`
+		Copied       map[string]int64 `json:"copied"`
+	}
+	cloneUrl := fmt.Sprintf("%s/customers/%s/clone", consts.AdminPath, source)
+
+	//regular user can't clone
+	testBadRequest(suite, http.MethodPost, cloneUrl, errorNotAdminUser, nil, http.StatusUnauthorized)
+
+	//clone as is
+	suite.loginAsAdmin("admin-guid")
+	w := suite.doRequest(http.MethodPost, cloneUrl, map[string]interface{}{"guid": target})
+	suite.Equal(http.StatusCreated, w.Code)
+	response, err := decodeResponse[*cloneResponse](w)
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	suite.Equal(target, response.CustomerGUID)
+	suite.Equal(int64(len(clusters)), response.Copied[consts.ClustersCollection])
+	suite.Equal(int64(len(posturePolices)), response.Copied[consts.PostureExceptionPolicyCollection])
+
+	suite.login(target)
+	testGetNameList(suite, consts.ClusterPath, clustersNames)
+	testGetNameList(suite, consts.PostureExceptionPolicyPath, policiesNames)
+	targetClusters := testGetDocs(suite, consts.ClusterPath, clusters, newClusterCompareFilter)
+	for i := range targetClusters {
+		suite.NotEqual(sourceClusters[i].GetGUID(), targetClusters[i].GetGUID(), "cloned cluster should have a new guid")
+		suite.NotEmpty(targetClusters[i].Attributes[consts.ShortNameAttribute], "cloned cluster should have a short name")
+	}
+	customer.GUID = target
+	testGetDoc(suite, consts.CustomerPath, customer, customerCompareFilter)
+
+	//clone with scrubbing
+	suite.loginAsAdmin("admin-guid")
+	w = suite.doRequest(http.MethodPost, cloneUrl, map[string]interface{}{"guid": scrubbed, "scrub": true})
+	suite.Equal(http.StatusCreated, w.Code)
+	suite.login(scrubbed)
+	w = suite.doRequest(http.MethodGet, consts.ClusterPath+"?list", nil)
+	suite.Equal(http.StatusOK, w.Code)
+	scrubbedNames := decodeArray[string](suite, w.Body.Bytes())
+	suite.Equal(len(clustersNames), len(scrubbedNames))
+	for _, name := range scrubbedNames {
+		suite.True(strings.HasPrefix(name, "anon-"), "cluster name should be scrubbed")
+	}
+
+	//clone of not existing customer and clone to an existing customer should fail
+	suite.loginAsAdmin("admin-guid")
+	notExistingUrl := fmt.Sprintf("%s/customers/%s/clone", consts.AdminPath, "not-existing-guid")
+	testBadRequest(suite, http.MethodPost, notExistingUrl, errorDocumentNotFound, nil, http.StatusNotFound)
+	testBadRequest(suite, http.MethodPost, cloneUrl, `{"error":"guid `+target+` already exists"}`, map[string]interface{}{"guid": target}, http.StatusConflict)
+	testBadRequest(suite, http.MethodPost, cloneUrl, `{"error":"guid `+target+` already exists"}`, map[string]interface{}{"guid": strings.ToUpper(target)}, http.StatusConflict)
+	//the clone guid must be a UUID
+	testBadRequest(suite, http.MethodPost, cloneUrl, `{"error":"guid not-a-uuid is not a valid UUID"}`, map[string]interface{}{"guid": "not-a-uuid"}, http.StatusBadRequest)
+}
+
+func (suite *MainTestSuite) TestSessions() {
+	const (
+		user  = "session-user-guid"
+		admin = "admin-user-guid"
+	)
+	//a tampered session cookie is rejected
+	suite.login(user)
+	validCookie, validCSRFToken := suite.authCookie, suite.csrfToken
+	suite.True(strings.HasPrefix(validCookie, consts.SessionCookie+"="), "login should set a session cookie")
+	suite.authCookie = strings.Replace(validCookie, consts.SessionCookie+"=", consts.SessionCookie+"=x", 1)
+	testBadRequest(suite, http.MethodGet, consts.ClusterPath, errorUnauthorized, nil, http.StatusUnauthorized)
+	//the unsigned cookie is rejected without development cookie authentication, signed sessions are independent of it
+	authConfig := utils.GetConfig().Auth
+	authConfig.AllowCookieAuth = false
+	suite.NoError(auth.Init(authConfig))
+	suite.authCookie = consts.CustomerGUID + "=" + user + ";" + consts.AdminAccess
+	testBadRequest(suite, http.MethodGet, consts.ClusterPath, errorUnauthorized, nil, http.StatusUnauthorized)
+	suite.authCookie = validCookie
+	suite.Equal(http.StatusOK, suite.doRequest(http.MethodGet, consts.ClusterPath, nil).Code)
+	suite.Equal(http.StatusOK, suite.doRequest(http.MethodPost, "/login", map[string]interface{}{"customerGUID": user}).Code)
+	//login sessions are not issued and not accepted when disabled
+	authConfig.Session.Enabled = false
+	suite.NoError(auth.Init(authConfig))
+	testBadRequest(suite, http.MethodPost, "/login", errorLoginDisabled, map[string]interface{}{"customerGUID": user}, http.StatusForbidden)
+	testBadRequest(suite, http.MethodGet, consts.ClusterPath, errorUnauthorized, nil, http.StatusUnauthorized)
+	suite.NoError(auth.Init(utils.GetConfig().Auth))
+	//admin access is not granted by the login request
+	w := suite.doRequest(http.MethodPost, "/login", map[string]interface{}{"customerGUID": user, "attributes": map[string]interface{}{"admin": true}})
+	suite.Equal(http.StatusOK, w.Code)
+	suite.setSessionCookies(w)
+	testBadRequest(suite, http.MethodDelete, consts.AdminPath+"/customers/"+user+"/sessions", errorNotAdminUser, nil, http.StatusUnauthorized)
+	//a non admin session cannot be escalated
+	suite.authCookie, suite.csrfToken = validCookie, validCSRFToken
+	testBadRequest(suite, http.MethodDelete, consts.AdminPath+"/customers/"+user+"/sessions", errorNotAdminUser, nil, http.StatusUnauthorized)
+
+	//logout requires authentication and a CSRF token
+	suite.Equal(http.StatusOK, suite.doRequest(http.MethodGet, consts.ClusterPath, nil).Code)
+	suite.csrfToken = ""
+	testBadRequest(suite, http.MethodPost, "/logout", errorInvalidCSRF, nil, http.StatusForbidden)
+	suite.authCookie = ""
+	testBadRequest(suite, http.MethodPost, "/logout", errorUnauthorized, nil, http.StatusUnauthorized)
+	//logout revokes the session
+	suite.authCookie, suite.csrfToken = validCookie, validCSRFToken
+	w = suite.doRequest(http.MethodPost, "/logout", nil)
+	suite.Equal(http.StatusOK, w.Code)
+	testBadRequest(suite, http.MethodGet, consts.ClusterPath, errorUnauthorized, nil, http.StatusUnauthorized)
+
+	//admin revokes all the sessions of the user
+	suite.login(user)
+	firstSession := suite.authCookie
+	suite.login(user)
+	secondSession := suite.authCookie
+	suite.Equal(http.StatusOK, suite.doRequest(http.MethodGet, consts.ClusterPath, nil).Code)
+	suite.loginAsAdmin(admin)
+	w = suite.doRequest(http.MethodDelete, consts.AdminPath+"/customers/"+user+"/sessions", nil)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal(`{"revoked":2}`, w.Body.String())
+	for _, cookie := range []string{firstSession, secondSession} {
+		suite.authCookie = cookie
+		testBadRequest(suite, http.MethodGet, consts.ClusterPath, errorUnauthorized, nil, http.StatusUnauthorized)
+	}
+	//admin session is still valid
+	suite.loginAsAdmin(admin)
+	w = suite.doRequest(http.MethodDelete, consts.AdminPath+"/customers/"+user+"/sessions", nil)
+	suite.Equal(`{"revoked":0}`, w.Body.String())
+}
+
+func (suite *MainTestSuite) TestLoginDisabledWithJWT() {
+	const user = "jwt-session-user-guid"
+	suite.login(user)
+	suite.Equal(http.StatusOK, suite.doRequest(http.MethodGet, consts.ClusterPath, nil).Code)
+
+	authConfig := utils.GetConfig().Auth
+	authConfig.JWT.Key = "jwt-test-secret"
+	suite.NoError(auth.Init(authConfig))
+	defer auth.Init(utils.GetConfig().Auth)
+	//login does not issue sessions and existing login sessions are rejected
+	testBadRequest(suite, http.MethodPost, "/login", errorLoginDisabled, map[string]interface{}{"customerGUID": user}, http.StatusForbidden)
+	testBadRequest(suite, http.MethodGet, consts.ClusterPath, errorUnauthorized, nil, http.StatusUnauthorized)
+}
+
+func (suite *MainTestSuite) TestCSRFProtection() {
+	//CSRF protection is enabled by default
+	const user = "csrf-user-guid"
+	w := suite.doRequest(http.MethodPost, "/login", map[string]interface{}{"customerGUID": user, "userId": defaultUserID})
+	suite.Equal(http.StatusOK, w.Code)
+	var sessionCookie, csrfCookie *http.Cookie
+	for _, cookie := range w.Result().Cookies() {
+		switch cookie.Name {
+		case consts.SessionCookie:
+			sessionCookie = cookie
+		case consts.CSRFCookie:
+			csrfCookie = cookie
+		}
+	}
+	suite.Require().NotNil(sessionCookie)
+	suite.Require().NotNil(csrfCookie, "login should set a CSRF token cookie")
+	suite.True(sessionCookie.HttpOnly)
+	suite.False(csrfCookie.HttpOnly, "CSRF token cookie should be readable by the client")
+	suite.authCookie = sessionCookie.Name + "=" + sessionCookie.Value + "; " + csrfCookie.Name + "=" + csrfCookie.Value
+
+	clusters, _ := loadJson[*types.Cluster](clustersJson)
+	//read requests do not require a token
+	suite.csrfToken = ""
+	w = suite.doRequest(http.MethodGet, consts.ClusterPath, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	//missing or wrong token
+	testBadRequest(suite, http.MethodPost, consts.ClusterPath, errorInvalidCSRF, clusters[0], http.StatusForbidden)
+	suite.csrfToken = "wrong-token"
+	testBadRequest(suite, http.MethodPost, consts.ClusterPath, errorInvalidCSRF, clusters[0], http.StatusForbidden)
+	//matching cookie and header that do not belong to the session
+	suite.authCookie = sessionCookie.Name + "=" + sessionCookie.Value + "; " + csrfCookie.Name + "=wrong-token"
+	testBadRequest(suite, http.MethodPost, consts.ClusterPath, errorInvalidCSRF, clusters[0], http.StatusForbidden)
+	//valid token
+	suite.authCookie = sessionCookie.Name + "=" + sessionCookie.Value + "; " + csrfCookie.Name + "=" + csrfCookie.Value
+	suite.csrfToken = csrfCookie.Value
+	cluster := testPostDoc(suite, consts.ClusterPath, clusters[0], newClusterCompareFilter)
+
+	//no token is required when the protection is disabled
+	authConfig := utils.GetConfig().Auth
+	authConfig.DisableCSRFProtection = true
+	suite.NoError(auth.Init(authConfig))
+	suite.csrfToken = ""
+	testPostDoc(suite, consts.ClusterPath, clusters[1], newClusterCompareFilter)
+	suite.NoError(auth.Init(utils.GetConfig().Auth))
+	suite.csrfToken = csrfCookie.Value
+
+	//API key clients are exempt
+	w = suite.doRequest(http.MethodPost, consts.APIKeysPath, map[string]interface{}{"name": "csrf", "scopes": []string{"cluster:write"}})
+	suite.Equal(http.StatusCreated, w.Code)
+	key, _ := decode[map[string]interface{}](suite, w.Body.Bytes())["key"].(string)
+	suite.authCookie = ""
+	suite.csrfToken = ""
+	suite.apiKey = key
+	w = suite.doRequest(http.MethodDelete, consts.ClusterPath+"/"+cluster.GUID, nil)
+	suite.Equal(http.StatusOK, w.Code)
+}
+
+func (suite *MainTestSuite) TestServiceIdentity() {
+	const customerGUID = "service-customer-guid"
+	clusters, _ := loadJson[*types.Cluster](clustersJson)
+	posturePolicies, _ := loadJson[*types.PostureExceptionPolicy](posturePoliciesJson)
+	//a trusted service with a verified client certificate acts for the customer in the header
+	serviceRequest := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		bodyBytes, err := json.Marshal(body)
+		suite.NoError(err)
+		req := httptest.NewRequest(method, path, bytes.NewReader(bodyBytes))
+		req.Header.Set(consts.CustomerGUIDHeader, customerGUID)
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "internal-service"}}}}}
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+	//the service role is independent of the default role of users
+	authConfig := utils.GetConfig().Auth
+	authConfig.DefaultRole = ""
+	suite.NoError(auth.Init(authConfig))
+	defer func() { suite.NoError(auth.Init(utils.GetConfig().Auth)) }()
+
+	//the default service role is editor
+	w := serviceRequest(http.MethodPost, consts.ClusterPath, clusters[0])
+	suite.Equal(http.StatusCreated, w.Code)
+	w = serviceRequest(http.MethodPost, consts.PostureExceptionPolicyPath, posturePolicies[0])
+	suite.Equal(http.StatusForbidden, w.Code)
+	suite.Contains(w.Body.String(), "missing permission exceptions:write")
+	//configured service role
+	authConfig.ServiceRole = string(auth.RoleOwner)
+	suite.NoError(auth.Init(authConfig))
+	w = serviceRequest(http.MethodPost, consts.PostureExceptionPolicyPath, posturePolicies[0])
+	suite.Equal(http.StatusCreated, w.Code)
+
+	//the documents are created in the customer of the header
+	suite.login(customerGUID)
+	testGetNameList(suite, consts.ClusterPath, []string{clusters[0].Name})
+	testGetNameList(suite, consts.PostureExceptionPolicyPath, []string{posturePolicies[0].Name})
+}
+
+func (suite *MainTestSuite) TestUserRoles() {
+	const (
+		customerGUID = "roles-customer-guid"
+		owner        = "owner-user"
+		viewer       = "viewer-user"
+		approver     = "approver-user"
+	)
+	//users without a stored role get the default role, owner in the test configuration
+	suite.loginAsUser(customerGUID, owner)
+	w := suite.doRequest(http.MethodGet, consts.UserRolesPath+"/"+owner, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal(`{"role":"owner","userId":"owner-user"}`, w.Body.String())
+	testBadRequest(suite, http.MethodPut, consts.UserRolesPath+"/"+viewer, `{"error":"invalid role admin"}`, map[string]string{"role": "admin"}, http.StatusBadRequest)
+	for user, role := range map[string]string{viewer: "viewer", approver: "security-approver"} {
+		w = suite.doRequest(http.MethodPut, consts.UserRolesPath+"/"+user, map[string]string{"role": role})
+		suite.Equal(http.StatusOK, w.Code)
+	}
+	w = suite.doRequest(http.MethodGet, consts.UserRolesPath, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Len(decodeArray[map[string]interface{}](suite, w.Body.Bytes()), 2)
+
+	posturePolicies, _ := loadJson[*types.PostureExceptionPolicy](posturePoliciesJson)
+	policy := testPostDoc(suite, consts.PostureExceptionPolicyPath, posturePolicies[0], commonCmpFilter)
+
+	//viewer can read but not write
+	suite.loginAsUser(customerGUID, viewer)
+	testGetDoc(suite, consts.PostureExceptionPolicyPath+"/"+policy.GUID, policy, commonCmpFilter)
+	testBadRequest(suite, http.MethodDelete, consts.PostureExceptionPolicyPath+"/"+policy.GUID, `{"error":"missing permission exceptions:write"}`, nil, http.StatusForbidden)
+	testBadRequest(suite, http.MethodPut, consts.CustomerConfigPath, `{"error":"missing permission config:write"}`, map[string]string{"name": "CustomerConfig"}, http.StatusForbidden)
+	testBadRequest(suite, http.MethodGet, consts.UserRolesPath, `{"error":"missing permission users:manage"}`, nil, http.StatusForbidden)
+
+	//security approver can write exceptions but not other configuration
+	suite.loginAsUser(customerGUID, approver)
+	testBadRequest(suite, http.MethodPost, consts.ClusterPath, `{"error":"missing permission config:write"}`, map[string]string{"name": "cluster"}, http.StatusForbidden)
+	w = suite.doRequest(http.MethodDelete, consts.PostureExceptionPolicyPath+"/"+policy.GUID, nil)
+	suite.Equal(http.StatusOK, w.Code)
+
+	//deleted role falls back to the default role
+	suite.loginAsUser(customerGUID, owner)
+	w = suite.doRequest(http.MethodDelete, consts.UserRolesPath+"/"+viewer, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	testBadRequest(suite, http.MethodDelete, consts.UserRolesPath+"/"+viewer, errorDocumentNotFound, nil, http.StatusNotFound)
+	w = suite.doRequest(http.MethodGet, consts.UserRolesPath+"/"+viewer, nil)
+	suite.Equal(`{"role":"owner","userId":"viewer-user"}`, w.Body.String())
+	policy = testPostDoc(suite, consts.PostureExceptionPolicyPath, posturePolicies[1], commonCmpFilter)
+
+	//with the default viewer role users without a stored role and callers without a user id can read but not write
+	authConfig := utils.GetConfig().Auth
+	authConfig.DefaultRole = ""
+	suite.NoError(auth.Init(authConfig))
+	defer func() { suite.NoError(auth.Init(utils.GetConfig().Auth)) }()
+	for _, userID := range []string{owner, ""} {
+		w = suite.doRequest(http.MethodPost, "/login", map[string]interface{}{"customerGUID": customerGUID, "userId": userID})
+		suite.Equal(http.StatusOK, w.Code)
+		suite.setSessionCookies(w)
+		testGetDoc(suite, consts.PostureExceptionPolicyPath+"/"+policy.GUID, policy, commonCmpFilter)
+		testBadRequest(suite, http.MethodPut, consts.CustomerConfigPath, `{"error":"missing permission config:write"}`, map[string]string{"name": "CustomerConfig"}, http.StatusForbidden)
+		testBadRequest(suite, http.MethodDelete, consts.PostureExceptionPolicyPath+"/"+policy.GUID, `{"error":"missing permission exceptions:write"}`, nil, http.StatusForbidden)
+	}
+	//the development cookie has no user id
+	suite.authCookie, suite.csrfToken = consts.CustomerGUID+"="+customerGUID, ""
+	testGetDoc(suite, consts.PostureExceptionPolicyPath+"/"+policy.GUID, policy, commonCmpFilter)
+}
+
+func (suite *MainTestSuite) TestAdminImpersonation() {
+	const (
+		target = "impersonated-customer-guid"
+		admin  = "admin-user-guid"
+	)
+	clusters, _ := loadJson[*types.Cluster](clustersJson)
+	suite.login(target)
+	cluster := testPostDoc(suite, consts.ClusterPath, clusters[0], newClusterCompareFilter)
+
+	impersonateUrl := consts.AdminPath + "/impersonate/" + target
+	//not admin
+	testBadRequest(suite, http.MethodPost, impersonateUrl, errorNotAdminUser, nil, http.StatusUnauthorized)
+
+	//read only impersonation
+	suite.loginAsAdmin(admin)
+	w := suite.doRequest(http.MethodPost, impersonateUrl, map[string]interface{}{"reason": "support ticket"})
+	suite.Equal(http.StatusCreated, w.Code)
+	session := decode[map[string]interface{}](suite, w.Body.Bytes())
+	suite.Equal(target, session["customerGUID"])
+	suite.Equal(true, session["readOnly"])
+	suite.Equal(map[string]interface{}{"customerGUID": admin}, session["impersonator"])
+	suite.Equal("support ticket", session["reason"])
+	expiration, err := time.Parse(time.RFC3339, session["expirationTime"].(string))
+	suite.NoError(err)
+	suite.True(time.Until(expiration) <= time.Hour, "impersonation session should be time-boxed")
+	suite.setSessionCookies(w)
+	w = suite.doRequest(http.MethodGet, consts.ClusterPath, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Len(decodeArray[*types.Cluster](suite, w.Body.Bytes()), 1)
+	testBadRequest(suite, http.MethodDelete, consts.ClusterPath+"/"+cluster.GUID, `{"error":"read only session"}`, nil, http.StatusForbidden)
+	//impersonation session has no admin access
+	testBadRequest(suite, http.MethodPost, impersonateUrl, errorNotAdminUser, nil, http.StatusUnauthorized)
+
+	//impersonation with write access
+	suite.loginAsAdmin(admin)
+	w = suite.doRequest(http.MethodPost, impersonateUrl, map[string]interface{}{"allowWrite": true})
+	suite.Equal(http.StatusCreated, w.Code)
+	suite.setSessionCookies(w)
+	w = suite.doRequest(http.MethodDelete, consts.ClusterPath+"/"+cluster.GUID, nil)
+	suite.Equal(http.StatusOK, w.Code)
+
+	//impersonation of a customer of the admins list has no admin access
+	suite.loginAsAdmin(admin)
+	w = suite.doRequest(http.MethodPost, consts.AdminPath+"/impersonate/admin-guid", map[string]interface{}{"allowWrite": true})
+	suite.Equal(http.StatusCreated, w.Code)
+	suite.setSessionCookies(w)
+	testBadRequest(suite, http.MethodPost, impersonateUrl, errorNotAdminUser, nil, http.StatusUnauthorized)
+	//a customer of the admins list has admin access also without the admin flag of its identity
+	suite.authCookie, suite.csrfToken = consts.CustomerGUID+"=admin-guid", ""
+	testBadRequest(suite, http.MethodGet, consts.AdminPath+"/jobs/not-existing-job", errorDocumentNotFound, nil, http.StatusNotFound)
+}
@@ -0,0 +1,4 @@
+// Package configservicev1 - protobuf messages and gRPC services of the config service gRPC API
+package configservicev1
+
+//go:generate protoc --proto_path=../../.. --go_out=../../.. --go_opt=paths=source_relative --go-grpc_out=../../.. --go-grpc_opt=paths=source_relative api/configservice/v1/config_service.proto
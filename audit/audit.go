@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"config-service/db/mongo"
+	"config-service/utils/consts"
+	"config-service/utils/log"
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+// audit log of changes that are made by the customers users
+// entries are only added, they are kept in the audit_log collection with the identity of the request
+
+// Entry - audit log document in db
+type Entry struct {
+	ID           string    `json:"id" bson:"_id"`
+	CustomerGUID string    `json:"customerGUID" bson:"customerGUID"`
+	UserID       string    `json:"userId,omitempty" bson:"userId,omitempty"`
+	AuthMethod   string    `json:"authMethod,omitempty" bson:"authMethod,omitempty"`
+	Impersonator string    `json:"impersonator,omitempty" bson:"impersonator,omitempty"`
+	Method       string    `json:"method" bson:"method"`
+	Path         string    `json:"path" bson:"path"`
+	Status       int       `json:"status" bson:"status"`
+	BatchID      string    `json:"batchId,omitempty" bson:"batchId,omitempty"`
+	Operation    int       `json:"operation" bson:"operation"` //index of the operation in the batch
+	Committed    bool      `json:"committed" bson:"committed"` //false when the change was rolled back or not made
+	Time         time.Time `json:"time" bson:"time"`
+}
+
+// NewEntry returns an entry of the request with the identity of the gin context
+func NewEntry(c *gin.Context, method, path string, status int) Entry {
+	return Entry{
+		ID:           uuid.NewV4().String(),
+		CustomerGUID: c.GetString(consts.CustomerGUID),
+		UserID:       c.GetString(consts.UserID),
+		AuthMethod:   c.GetString(consts.AuthMethod),
+		Impersonator: c.GetString(consts.Impersonator),
+		Method:       method,
+		Path:         path,
+		Status:       status,
+		Time:         time.Now().UTC(),
+	}
+}
+
+// Record adds the entries to the audit log
+func Record(c context.Context, entries ...Entry) error {
+	defer log.LogNTraceEnterExit("audit.Record", c)()
+	if len(entries) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		docs = append(docs, entry)
+	}
+	_, err := mongo.GetWriteCollection(consts.AuditLogCollection).InsertMany(c, docs)
+	return err
+}
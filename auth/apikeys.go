@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"config-service/db/mongo"
+	"config-service/utils/consts"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	rndStr "github.com/dchest/uniuri"
+	uuid "github.com/satori/go.uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	mongoDB "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// API keys are random secrets that are returned once on creation, only the key hash is stored in db
+
+const (
+	apiKeyPrefix    = "csk_"
+	apiKeyLength    = 40
+	apiKeyHintChars = 4
+)
+
+// APIKey - API key document in db
+type APIKey struct {
+	GUID           string     `json:"guid" bson:"_id"`
+	Name           string     `json:"name" bson:"name"`
+	Customers      []string   `json:"-" bson:"customers"`
+	KeyHash        string     `json:"-" bson:"keyHash"`
+	KeyHint        string     `json:"keyHint" bson:"keyHint"` //last characters of the key to identify it
+	Scopes         []string   `json:"scopes" bson:"scopes"`
+	ExpirationTime *time.Time `json:"expirationTime,omitempty" bson:"expirationTime,omitempty"`
+	LastUsedTime   *time.Time `json:"lastUsedTime,omitempty" bson:"lastUsedTime,omitempty"`
+	CreatedBy      string     `json:"createdBy,omitempty" bson:"createdBy,omitempty"`
+	CreationTime   time.Time  `json:"creationTime" bson:"creationTime"`
+}
+
+// NewAPIKey stores a new API key of the customer and returns the key value, the value cannot be retrieved later
+func NewAPIKey(c context.Context, customerGUID, name string, scopes []string, expirationTime *time.Time, createdBy string) (string, *APIKey, error) {
+	key := apiKeyPrefix + rndStr.NewLen(apiKeyLength)
+	apiKey := &APIKey{
+		GUID:           uuid.NewV4().String(),
+		Name:           name,
+		Customers:      []string{customerGUID},
+		KeyHash:        hashAPIKey(key),
+		KeyHint:        key[len(key)-apiKeyHintChars:],
+		Scopes:         scopes,
+		ExpirationTime: expirationTime,
+		CreatedBy:      createdBy,
+		CreationTime:   time.Now().UTC(),
+	}
+	if _, err := mongo.GetWriteCollection(consts.APIKeysCollection).InsertOne(c, apiKey); err != nil {
+		return "", nil, err
+	}
+	return key, apiKey, nil
+}
+
+// ListAPIKeys returns the API keys of the customer
+func ListAPIKeys(c context.Context, customerGUID string) ([]APIKey, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "creationTime", Value: 1}})
+	cur, err := mongo.GetReadCollection(consts.APIKeysCollection).Find(c, bson.D{{Key: consts.CustomersField, Value: customerGUID}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	apiKeys := []APIKey{}
+	if err := cur.All(c, &apiKeys); err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+// RevokeAPIKey deletes the API key of the customer, returns false if the key does not exist
+func RevokeAPIKey(c context.Context, customerGUID, guid string) (bool, error) {
+	res, err := mongo.GetWriteCollection(consts.APIKeysCollection).DeleteOne(c,
+		bson.D{{Key: consts.IdField, Value: guid}, {Key: consts.CustomersField, Value: customerGUID}})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+// ValidateAPIKey returns the identity of a valid API key and updates the key last used time
+func ValidateAPIKey(c context.Context, key string) (*Identity, error) {
+	now := time.Now().UTC()
+	filter := bson.D{
+		{Key: "keyHash", Value: hashAPIKey(key)},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "expirationTime", Value: bson.D{{Key: "$exists", Value: false}}}},
+			bson.D{{Key: "expirationTime", Value: bson.D{{Key: "$gt", Value: now}}}},
+		}},
+	}
+	var apiKey APIKey
+	if err := mongo.GetWriteCollection(consts.APIKeysCollection).FindOneAndUpdate(c, filter,
+		bson.D{{Key: "$set", Value: bson.D{{Key: "lastUsedTime", Value: now}}}}).Decode(&apiKey); err != nil {
+		if err == mongoDB.ErrNoDocuments {
+			return nil, errors.New("invalid or expired API key")
+		}
+		return nil, err
+	}
+	if len(apiKey.Customers) == 0 {
+		return nil, errors.New("API key has no customer")
+	}
+	return &Identity{
+		CustomerGUID: apiKey.Customers[0],
+		UserID:       apiKey.GUID,
+		Method:       MethodAPIKey,
+		Scopes:       apiKey.Scopes,
+	}, nil
+}
+
+func hashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"config-service/utils"
+	"errors"
+	"fmt"
+)
+
+// Identity - the authenticated caller of a request
+type Identity struct {
+	CustomerGUID string
+	UserID       string
+	Admin        bool
+	Method       string        //authentication method
+	Scopes       []string      //API key scopes, nil for other authentication methods
+	Impersonator *Impersonator //the admin of an impersonation session
+	ReadOnly     bool          //only read requests are allowed
+}
+
+// authentication methods
+const (
+	MethodCookie     = "cookie"
+	MethodJWT        = "jwt"
+	MethodSession    = "session"
+	MethodAPIKey     = "apiKey"
+	MethodClientCert = "clientCert"
+)
+
+var ErrUnauthorized = errors.New("unauthorized")
+
+var config utils.AuthConfig
+var jwtValidator *JWTValidator
+
+// Init initializes the authentication methods from the configuration
+func Init(authConfig utils.AuthConfig) error {
+	config = authConfig
+	jwtValidator = nil
+	if authConfig.DefaultRole != "" && !IsValidRole(Role(authConfig.DefaultRole)) {
+		return fmt.Errorf("invalid default role %s", authConfig.DefaultRole)
+	}
+	if authConfig.ServiceRole != "" && !IsValidRole(Role(authConfig.ServiceRole)) {
+		return fmt.Errorf("invalid service role %s", authConfig.ServiceRole)
+	}
+	initSessions()
+	if authConfig.JWT.JWKSFile != "" || authConfig.JWT.Key != "" {
+		validator, err := NewJWTValidator(authConfig.JWT)
+		if err != nil {
+			return err
+		}
+		jwtValidator = validator
+	}
+	return nil
+}
+
+// CookieAuthAllowed returns true if the unsigned development cookie authentication is allowed
+func CookieAuthAllowed() bool {
+	return config.AllowCookieAuth
+}
+
+// LoginSessionsAllowed returns true if the login route issues signed sessions and the sessions are accepted,
+// login sessions are enabled by the session configuration and are not used when JWT authentication is configured
+func LoginSessionsAllowed() bool {
+	return config.Session.Enabled && jwtValidator == nil
+}
+
+// ValidateBearerToken validates a bearer JWT and returns the caller identity
+func ValidateBearerToken(token string) (*Identity, error) {
+	if jwtValidator == nil {
+		return nil, errors.New("bearer authentication is not configured")
+	}
+	return jwtValidator.Validate(token)
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"config-service/utils/consts"
+	"crypto/hmac"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// double submit CSRF protection of cookie authenticated requests
+// the CSRF token is a HMAC of the session id, it is set in a cookie that the browser client reads and sends back in the X-CSRF-Token header
+
+// CSRFProtectionEnabled returns true if state changing requests authenticated by cookies require a CSRF token, it is enabled unless disabled by the configuration
+func CSRFProtectionEnabled() bool {
+	return !config.DisableCSRFProtection
+}
+
+// CSRFToken returns the CSRF token of a session token
+func CSRFToken(sessionToken string) string {
+	sessionID, _, err := parseSessionToken(sessionToken, time.Now())
+	if err != nil {
+		return ""
+	}
+	return sessionSignature("csrf." + sessionID)
+}
+
+// ValidCSRFToken returns true if the token is the CSRF token of the session
+func ValidCSRFToken(sessionToken, csrfToken string) bool {
+	expected := CSRFToken(sessionToken)
+	return expected != "" && hmac.Equal([]byte(expected), []byte(csrfToken))
+}
+
+// SetSessionCookies sets the session cookie and its CSRF token cookie, an empty token with negative max age removes the cookies
+func SetSessionCookies(c *gin.Context, sessionToken string, maxAge int) {
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(consts.SessionCookie, sessionToken, maxAge, "/", "", SecureSessionCookie(), true)
+	csrfToken := ""
+	if sessionToken != "" {
+		csrfToken = CSRFToken(sessionToken)
+	}
+	//not http only so the client can read it
+	c.SetCookie(consts.CSRFCookie, csrfToken, maxAge, "/", "", SecureSessionCookie(), false)
+}
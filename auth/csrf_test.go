@@ -0,0 +1,21 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSRFToken(t *testing.T) {
+	sessionSecret = []byte("test-session-secret")
+	sessionToken := signSessionToken("session1", time.Now().Add(time.Hour))
+	csrfToken := CSRFToken(sessionToken)
+	assert.NotEmpty(t, csrfToken)
+	assert.True(t, ValidCSRFToken(sessionToken, csrfToken))
+
+	assert.False(t, ValidCSRFToken(sessionToken, ""))
+	assert.False(t, ValidCSRFToken(sessionToken, CSRFToken(signSessionToken("session2", time.Now().Add(time.Hour)))), "token of another session")
+	assert.False(t, ValidCSRFToken("invalid", csrfToken))
+	assert.Empty(t, CSRFToken(signSessionToken("session1", time.Now().Add(-time.Minute))), "expired session has no token")
+}
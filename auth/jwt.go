@@ -0,0 +1,232 @@
+package auth
+
+import (
+	"config-service/utils"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	defaultCustomerGUIDClaim = "customerGUID"
+	defaultUserIDClaim       = "sub"
+)
+
+var supportedAlgorithms = []string{"RS256", "ES256", "HS256"}
+
+// JWTValidator validates bearer tokens signed with one of the configured keys
+type JWTValidator struct {
+	config utils.JWTConfig
+	keys   map[string]interface{} //keys by key id
+	parser *jwt.Parser
+}
+
+func NewJWTValidator(config utils.JWTConfig) (*JWTValidator, error) {
+	if config.CustomerGUIDClaim == "" {
+		config.CustomerGUIDClaim = defaultCustomerGUIDClaim
+	}
+	if config.UserIDClaim == "" {
+		config.UserIDClaim = defaultUserIDClaim
+	}
+	v := &JWTValidator{
+		config: config,
+		keys:   map[string]interface{}{},
+		parser: jwt.NewParser(jwt.WithValidMethods(supportedAlgorithms), jwt.WithoutClaimsValidation()),
+	}
+	if config.JWKSFile != "" {
+		jwksBytes, err := os.ReadFile(config.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read jwks file: %w", err)
+		}
+		if v.keys, err = parseJWKS(jwksBytes); err != nil {
+			return nil, err
+		}
+	} else if config.Key != "" {
+		key, err := parseKey(config.Key)
+		if err != nil {
+			return nil, err
+		}
+		v.keys[""] = key
+	}
+	if len(v.keys) == 0 {
+		return nil, fmt.Errorf("no jwt validation keys configured")
+	}
+	return v, nil
+}
+
+// Validate verifies the token signature, expiry, issuer and audience and maps the claims to the caller identity
+func (v *JWTValidator) Validate(tokenString string) (*Identity, error) {
+	claims := jwt.MapClaims{}
+	if _, err := v.parser.ParseWithClaims(tokenString, claims, v.keyFunc); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	leeway := time.Duration(v.config.LeewaySeconds) * time.Second
+	if !claims.VerifyExpiresAt(now.Add(-leeway).Unix(), true) {
+		return nil, fmt.Errorf("token is expired or has no expiry")
+	}
+	if !claims.VerifyNotBefore(now.Add(leeway).Unix(), false) {
+		return nil, fmt.Errorf("token is not valid yet")
+	}
+	if v.config.Issuer != "" && !claims.VerifyIssuer(v.config.Issuer, true) {
+		return nil, fmt.Errorf("invalid token issuer")
+	}
+	if v.config.Audience != "" && !claims.VerifyAudience(v.config.Audience, true) {
+		return nil, fmt.Errorf("invalid token audience")
+	}
+	customerGUID, _ := claims[v.config.CustomerGUIDClaim].(string)
+	if customerGUID == "" {
+		return nil, fmt.Errorf("token has no %s claim", v.config.CustomerGUIDClaim)
+	}
+	userID, _ := claims[v.config.UserIDClaim].(string)
+	return &Identity{
+		CustomerGUID: customerGUID,
+		UserID:       userID,
+		Admin:        v.isAdmin(claims),
+		Method:       MethodJWT,
+	}, nil
+}
+
+func (v *JWTValidator) isAdmin(claims jwt.MapClaims) bool {
+	if v.config.AdminClaim == "" {
+		return false
+	}
+	switch claim := claims[v.config.AdminClaim].(type) {
+	case bool:
+		return claim && v.config.AdminClaimValue == ""
+	case string:
+		return v.config.AdminClaimValue != "" && claim == v.config.AdminClaimValue
+	case []interface{}:
+		for _, item := range claim {
+			if s, ok := item.(string); ok && v.config.AdminClaimValue != "" && s == v.config.AdminClaimValue {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// keyFunc returns the key by the token key id and checks that the key type matches the token algorithm
+func (v *JWTValidator) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := v.keys[kid]
+	if !ok {
+		if len(v.keys) != 1 || kid != "" {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		for _, k := range v.keys {
+			key = k
+		}
+	}
+	switch key.(type) {
+	case *rsa.PublicKey:
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); ok {
+			return key, nil
+		}
+	case *ecdsa.PublicKey:
+		if _, ok := token.Method.(*jwt.SigningMethodECDSA); ok {
+			return key, nil
+		}
+	case []byte:
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("signing method %s does not match the key type", token.Method.Alg())
+}
+
+// parseKey parses a PEM encoded public key or returns the value as HMAC secret
+func parseKey(key string) (interface{}, error) {
+	if !strings.HasPrefix(strings.TrimSpace(key), "-----BEGIN") {
+		return []byte(key), nil
+	}
+	if rsaKey, err := jwt.ParseRSAPublicKeyFromPEM([]byte(key)); err == nil {
+		return rsaKey, nil
+	}
+	if ecKey, err := jwt.ParseECPublicKeyFromPEM([]byte(key)); err == nil {
+		return ecKey, nil
+	}
+	return nil, fmt.Errorf("failed to parse jwt public key")
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// parseJWKS parses RSA, EC (P-256) and symmetric keys from a JWKS document
+func parseJWKS(jwksBytes []byte) (map[string]interface{}, error) {
+	var jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(jwksBytes, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to parse jwks: %w", err)
+	}
+	keys := map[string]interface{}{}
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse jwk %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(k.K)
+	}
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"config-service/utils"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTValidator(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	hmacSecret := []byte("test-hmac-secret")
+
+	//write jwks file with all keys
+	b64 := func(i *big.Int) string { return base64.RawURLEncoding.EncodeToString(i.Bytes()) }
+	jwks := map[string]interface{}{
+		"keys": []map[string]string{
+			{"kid": "rsa", "kty": "RSA", "use": "sig", "n": b64(rsaKey.N), "e": b64(big.NewInt(int64(rsaKey.E)))},
+			{"kid": "ec", "kty": "EC", "crv": "P-256", "x": b64(ecKey.X), "y": b64(ecKey.Y)},
+			{"kid": "hmac", "kty": "oct", "k": base64.RawURLEncoding.EncodeToString(hmacSecret)},
+		},
+	}
+	jwksBytes, err := json.Marshal(jwks)
+	require.NoError(t, err)
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksFile, jwksBytes, 0600))
+
+	validator, err := NewJWTValidator(utils.JWTConfig{
+		JWKSFile:        jwksFile,
+		Issuer:          "test-issuer",
+		Audience:        "config-service",
+		AdminClaim:      "roles",
+		AdminClaimValue: "admin",
+	})
+	require.NoError(t, err)
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":          "test-issuer",
+			"aud":          "config-service",
+			"sub":          "user1",
+			"exp":          time.Now().Add(time.Hour).Unix(),
+			"customerGUID": "customer1",
+		}
+	}
+	sign := func(method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+		return signed
+	}
+	withClaim := func(key string, value interface{}) jwt.MapClaims {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name      string
+		token     string
+		wantErr   bool
+		wantAdmin bool
+	}{
+		{name: "RS256", token: sign(jwt.SigningMethodRS256, "rsa", rsaKey, validClaims())},
+		{name: "ES256", token: sign(jwt.SigningMethodES256, "ec", ecKey, validClaims())},
+		{name: "HS256", token: sign(jwt.SigningMethodHS256, "hmac", hmacSecret, validClaims())},
+		{name: "admin role", token: sign(jwt.SigningMethodRS256, "rsa", rsaKey, withClaim("roles", []string{"viewer", "admin"})), wantAdmin: true},
+		{name: "not admin role", token: sign(jwt.SigningMethodRS256, "rsa", rsaKey, withClaim("roles", []string{"viewer"}))},
+		{name: "expired", token: sign(jwt.SigningMethodRS256, "rsa", rsaKey, withClaim("exp", time.Now().Add(-time.Minute).Unix())), wantErr: true},
+		{name: "no expiry", token: sign(jwt.SigningMethodRS256, "rsa", rsaKey, withClaim("exp", nil)), wantErr: true},
+		{name: "wrong issuer", token: sign(jwt.SigningMethodRS256, "rsa", rsaKey, withClaim("iss", "other")), wantErr: true},
+		{name: "wrong audience", token: sign(jwt.SigningMethodRS256, "rsa", rsaKey, withClaim("aud", "other")), wantErr: true},
+		{name: "no customer", token: sign(jwt.SigningMethodRS256, "rsa", rsaKey, withClaim("customerGUID", nil)), wantErr: true},
+		{name: "unknown kid", token: sign(jwt.SigningMethodRS256, "other", rsaKey, validClaims()), wantErr: true},
+		{name: "algorithm does not match key", token: sign(jwt.SigningMethodHS256, "rsa", hmacSecret, validClaims()), wantErr: true},
+		{name: "wrong signature", token: sign(jwt.SigningMethodHS256, "hmac", []byte("other-secret"), validClaims()), wantErr: true},
+		{name: "garbage", token: "not-a-token", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := validator.Validate(tt.token)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "customer1", identity.CustomerGUID)
+			assert.Equal(t, "user1", identity.UserID)
+			assert.Equal(t, MethodJWT, identity.Method)
+			assert.Equal(t, tt.wantAdmin, identity.Admin)
+		})
+	}
+}
+
+func TestJWTValidatorWithKey(t *testing.T) {
+	validator, err := NewJWTValidator(utils.JWTConfig{Key: "secret", CustomerGUIDClaim: "tenant", AdminClaim: "admin"})
+	require.NoError(t, err)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"tenant": "customer2",
+		"admin":  true,
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+	identity, err := validator.Validate(token)
+	require.NoError(t, err)
+	assert.Equal(t, "customer2", identity.CustomerGUID)
+	assert.True(t, identity.Admin)
+
+	_, err = NewJWTValidator(utils.JWTConfig{})
+	assert.Error(t, err, "validator without keys should fail")
+}
@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"config-service/db/mongo"
+	"config-service/utils/consts"
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	mongoDB "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/exp/slices"
+)
+
+// role based access control for tenant users
+// each route requires a permission by its base path and method (GET and HEAD are read, all other methods are write)
+// the user role is stored per customer and user id, users without a role get the configured default role
+// trusted services act for the customer with the configured service role
+
+type Role string
+
+const (
+	RoleViewer           Role = "viewer"
+	RoleEditor           Role = "editor"
+	RoleSecurityApprover Role = "security-approver"
+	RoleOwner            Role = "owner"
+)
+
+type Permission string
+
+const (
+	PermissionConfigRead      Permission = "config:read"
+	PermissionConfigWrite     Permission = "config:write"
+	PermissionExceptionsWrite Permission = "exceptions:write"
+	PermissionCustomerWrite   Permission = "customer:write"
+	PermissionAPIKeysManage   Permission = "apiKeys:manage"
+	PermissionUsersManage     Permission = "users:manage"
+	PermissionWebhooksManage  Permission = "webhooks:manage"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleViewer:           {PermissionConfigRead},
+	RoleEditor:           {PermissionConfigRead, PermissionConfigWrite},
+	RoleSecurityApprover: {PermissionConfigRead, PermissionExceptionsWrite},
+	RoleOwner: {PermissionConfigRead, PermissionConfigWrite, PermissionExceptionsWrite,
+		PermissionCustomerWrite, PermissionAPIKeysManage, PermissionUsersManage, PermissionWebhooksManage},
+}
+
+type routePolicy struct {
+	read  Permission
+	write Permission
+}
+
+// default policy of routes that are not in the policy map
+var defaultRoutePolicy = routePolicy{read: PermissionConfigRead, write: PermissionConfigWrite}
+
+// route base path to required permissions
+var policy = map[string]routePolicy{
+	strings.TrimPrefix(consts.PostureExceptionPolicyPath, "/"):       {read: PermissionConfigRead, write: PermissionExceptionsWrite},
+	strings.TrimPrefix(consts.VulnerabilityExceptionPolicyPath, "/"): {read: PermissionConfigRead, write: PermissionExceptionsWrite},
+	strings.TrimPrefix(consts.CustomerPath, "/"):                     {read: PermissionConfigRead, write: PermissionCustomerWrite},
+	strings.TrimPrefix(consts.APIKeysPath, "/"):                      {read: PermissionAPIKeysManage, write: PermissionAPIKeysManage},
+	strings.TrimPrefix(consts.UserRolesPath, "/"):                    {read: PermissionUsersManage, write: PermissionUsersManage},
+	strings.TrimPrefix(consts.WebhookPath, "/"):                      {read: PermissionWebhooksManage, write: PermissionWebhooksManage},
+	//each operation of a batch is checked by its route
+	strings.TrimPrefix(consts.BatchPath, "/"): {read: PermissionConfigRead, write: PermissionConfigRead},
+	//GraphQL queries are read only, each queried document type is checked by its path
+	strings.TrimPrefix(consts.GraphQLPath, "/"): {read: PermissionConfigRead, write: PermissionConfigRead},
+}
+
+// IsValidRole returns true if the role is one of the defined roles
+func IsValidRole(role Role) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// DefaultRole returns the role of users without a stored role
+func DefaultRole() Role {
+	if config.DefaultRole != "" {
+		return Role(config.DefaultRole)
+	}
+	return RoleViewer
+}
+
+// ServiceRole returns the role of the trusted services that are authenticated by client certificates
+func ServiceRole() Role {
+	if config.ServiceRole != "" {
+		return Role(config.ServiceRole)
+	}
+	return RoleEditor
+}
+
+// RequiredPermission returns the permission required for the request method and route full path
+func RequiredPermission(method, fullPath string) Permission {
+	basePath, _, _ := strings.Cut(strings.TrimPrefix(fullPath, "/"), "/")
+	routePolicy, ok := policy[basePath]
+	if !ok {
+		routePolicy = defaultRoutePolicy
+	}
+	if method == http.MethodGet || method == http.MethodHead {
+		return routePolicy.read
+	}
+	return routePolicy.write
+}
+
+// HasPermission returns true if the role grants the permission
+func HasPermission(role Role, permission Permission) bool {
+	return slices.Contains(rolePermissions[role], permission)
+}
+
+// UserRole - user role document in db
+type UserRole struct {
+	GUID        string    `json:"guid" bson:"_id"`
+	Customers   []string  `json:"-" bson:"customers"`
+	UserID      string    `json:"userId" bson:"userId"`
+	Role        Role      `json:"role" bson:"role"`
+	UpdatedBy   string    `json:"updatedBy,omitempty" bson:"updatedBy,omitempty"`
+	UpdatedTime time.Time `json:"updatedTime" bson:"updatedTime"`
+}
+
+// GetUserRole returns the stored role of the user or the default role if the user has no role,
+// callers without a user id (e.g. a JWT without the user claim or a login without a user) get the default role
+func GetUserRole(c context.Context, customerGUID, userID string) (Role, error) {
+	if userID == "" {
+		return DefaultRole(), nil
+	}
+	var userRole UserRole
+	if err := mongo.GetReadCollection(consts.UserRolesCollection).FindOne(c, userRoleFilter(customerGUID, userID)).Decode(&userRole); err != nil {
+		if err == mongoDB.ErrNoDocuments {
+			return DefaultRole(), nil
+		}
+		return "", err
+	}
+	return userRole.Role, nil
+}
+
+// ListUserRoles returns the stored roles of the customer users
+func ListUserRoles(c context.Context, customerGUID string) ([]UserRole, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "userId", Value: 1}})
+	cur, err := mongo.GetReadCollection(consts.UserRolesCollection).Find(c, bson.D{{Key: consts.CustomersField, Value: customerGUID}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	userRoles := []UserRole{}
+	if err := cur.All(c, &userRoles); err != nil {
+		return nil, err
+	}
+	return userRoles, nil
+}
+
+// SetUserRole sets the role of the user and returns the updated user role
+func SetUserRole(c context.Context, customerGUID, userID string, role Role, updatedBy string) (*UserRole, error) {
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "role", Value: role},
+			{Key: "updatedBy", Value: updatedBy},
+			{Key: consts.UpdatedTimeField, Value: time.Now().UTC()},
+		}},
+		{Key: "$setOnInsert", Value: bson.D{{Key: consts.IdField, Value: uuid.NewV4().String()}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var userRole UserRole
+	if err := mongo.GetWriteCollection(consts.UserRolesCollection).FindOneAndUpdate(c, userRoleFilter(customerGUID, userID), update, opts).Decode(&userRole); err != nil {
+		return nil, err
+	}
+	return &userRole, nil
+}
+
+// DeleteUserRole deletes the stored role of the user, returns false if the user has no stored role
+func DeleteUserRole(c context.Context, customerGUID, userID string) (bool, error) {
+	res, err := mongo.GetWriteCollection(consts.UserRolesCollection).DeleteOne(c, userRoleFilter(customerGUID, userID))
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+// userRoleFilter matches the exact customers array so an upsert inserts it as an array
+func userRoleFilter(customerGUID, userID string) bson.D {
+	return bson.D{{Key: consts.CustomersField, Value: bson.A{customerGUID}}, {Key: "userId", Value: userID}}
+}
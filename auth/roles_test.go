@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"config-service/utils"
+	"config-service/utils/consts"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRolePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    Role
+		method  string
+		path    string
+		allowed bool
+	}{
+		{name: "viewer get customer config", role: RoleViewer, method: http.MethodGet, path: consts.CustomerConfigPath, allowed: true},
+		{name: "viewer put customer config", role: RoleViewer, method: http.MethodPut, path: consts.CustomerConfigPath},
+		{name: "viewer delete exception", role: RoleViewer, method: http.MethodDelete, path: consts.PostureExceptionPolicyPath + "/:guid"},
+		{name: "viewer get exception", role: RoleViewer, method: http.MethodGet, path: consts.VulnerabilityExceptionPolicyPath + "/:guid", allowed: true},
+		{name: "editor put cluster", role: RoleEditor, method: http.MethodPut, path: consts.ClusterPath + "/:guid", allowed: true},
+		{name: "editor post exception", role: RoleEditor, method: http.MethodPost, path: consts.PostureExceptionPolicyPath},
+		{name: "approver post exception", role: RoleSecurityApprover, method: http.MethodPost, path: consts.VulnerabilityExceptionPolicyPath, allowed: true},
+		{name: "approver put cluster", role: RoleSecurityApprover, method: http.MethodPut, path: consts.ClusterPath},
+		{name: "editor manage api keys", role: RoleEditor, method: http.MethodGet, path: consts.APIKeysPath},
+		{name: "editor put customer", role: RoleEditor, method: http.MethodPut, path: consts.CustomerPath},
+		{name: "owner manage users", role: RoleOwner, method: http.MethodPut, path: consts.UserRolesPath + "/:userId", allowed: true},
+		{name: "owner delete exception", role: RoleOwner, method: http.MethodDelete, path: consts.PostureExceptionPolicyPath, allowed: true},
+		{name: "viewer get webhooks", role: RoleViewer, method: http.MethodGet, path: consts.WebhookPath + "/deliveries"},
+		{name: "owner post webhook", role: RoleOwner, method: http.MethodPost, path: consts.WebhookPath, allowed: true},
+		{name: "unknown role", role: "other", method: http.MethodGet, path: consts.ClusterPath},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.allowed, HasPermission(tt.role, RequiredPermission(tt.method, tt.path)))
+		})
+	}
+}
+
+func TestDefaultRole(t *testing.T) {
+	assert.NoError(t, Init(utils.AuthConfig{}))
+	assert.Equal(t, RoleViewer, DefaultRole())
+	//callers without a user id get the default role
+	role, err := GetUserRole(context.Background(), "customer1", "")
+	assert.NoError(t, err)
+	assert.Equal(t, RoleViewer, role)
+
+	assert.NoError(t, Init(utils.AuthConfig{DefaultRole: string(RoleEditor)}))
+	assert.Equal(t, RoleEditor, DefaultRole())
+	assert.Error(t, Init(utils.AuthConfig{DefaultRole: "admin"}))
+}
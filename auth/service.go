@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+
+	"golang.org/x/exp/slices"
+)
+
+// ServiceIdentity returns the identity of a trusted internal service that acts for the customer,
+// the service is identified by the common name or a DNS name of its verified client certificate
+func ServiceIdentity(state *tls.ConnectionState, customerGUID string) (*Identity, error) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil, errors.New("no verified client certificate")
+	}
+	if customerGUID == "" {
+		return nil, errors.New("no customer GUID")
+	}
+	cert := state.VerifiedChains[0][0]
+	names := append([]string{cert.Subject.CommonName}, cert.DNSNames...)
+	for _, name := range names {
+		if name != "" && slices.Contains(config.TrustedServices, name) {
+			return &Identity{
+				CustomerGUID: customerGUID,
+				UserID:       "service:" + name,
+				Method:       MethodClientCert,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("client certificate %s is not a trusted service", cert.Subject.CommonName)
+}
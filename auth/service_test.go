@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"config-service/utils"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceIdentity(t *testing.T) {
+	require.NoError(t, Init(utils.AuthConfig{TrustedServices: []string{"internal-service", "svc.internal"}}))
+	defer Init(utils.AuthConfig{})
+	connState := func(cn string, dnsNames ...string) *tls.ConnectionState {
+		return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: cn}, DNSNames: dnsNames}}}}
+	}
+
+	identity, err := ServiceIdentity(connState("internal-service"), "customer1")
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{CustomerGUID: "customer1", UserID: "service:internal-service", Method: MethodClientCert}, identity)
+
+	identity, err = ServiceIdentity(connState("other", "svc.internal"), "customer1")
+	require.NoError(t, err)
+	assert.Equal(t, "service:svc.internal", identity.UserID)
+
+	_, err = ServiceIdentity(connState("untrusted"), "customer1")
+	assert.Error(t, err)
+	_, err = ServiceIdentity(connState("internal-service"), "")
+	assert.Error(t, err, "customer GUID is required")
+	_, err = ServiceIdentity(&tls.ConnectionState{}, "customer1")
+	assert.Error(t, err, "client certificate must be verified")
+}
+
+func TestServiceRole(t *testing.T) {
+	require.NoError(t, Init(utils.AuthConfig{}))
+	assert.Equal(t, RoleEditor, ServiceRole())
+	require.NoError(t, Init(utils.AuthConfig{ServiceRole: string(RoleOwner)}))
+	defer Init(utils.AuthConfig{})
+	assert.Equal(t, RoleOwner, ServiceRole())
+	assert.Error(t, Init(utils.AuthConfig{ServiceRole: "admin"}))
+}
@@ -0,0 +1,217 @@
+package auth
+
+import (
+	"config-service/db/mongo"
+	"config-service/utils/consts"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	rndStr "github.com/dchest/uniuri"
+	uuid "github.com/satori/go.uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	mongoDB "go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// session tokens are "<session id>.<expiry unix time>.<signature>", the signature is an HMAC-SHA256 of the id and expiry
+// the session document in db is the source of the customer and admin access and can be revoked
+
+const (
+	defaultSessionTTL       = 2 * 24 * time.Hour
+	defaultImpersonationTTL = time.Hour
+)
+
+// Session - session document in db
+type Session struct {
+	ID             string        `json:"id" bson:"_id"`
+	CustomerGUID   string        `json:"customerGUID" bson:"customerGUID"`
+	UserID         string        `json:"userId,omitempty" bson:"userId,omitempty"`
+	Admin          bool          `json:"admin" bson:"admin"`
+	Impersonator   *Impersonator `json:"impersonator,omitempty" bson:"impersonator,omitempty"`
+	ReadOnly       bool          `json:"readOnly,omitempty" bson:"readOnly,omitempty"`
+	Reason         string        `json:"reason,omitempty" bson:"reason,omitempty"`
+	CreationTime   time.Time     `json:"creationTime" bson:"creationTime"`
+	ExpirationTime time.Time     `json:"expirationTime" bson:"expirationTime"`
+	RevocationTime *time.Time    `json:"revocationTime,omitempty" bson:"revocationTime,omitempty"`
+}
+
+// Impersonator - the admin that impersonates a customer in an impersonation session
+type Impersonator struct {
+	CustomerGUID string `json:"customerGUID" bson:"customerGUID"`
+	UserID       string `json:"userId,omitempty" bson:"userId,omitempty"`
+}
+
+var sessionSecret []byte
+
+func initSessions() {
+	sessionSecret = []byte(config.Session.Secret)
+	if len(sessionSecret) == 0 {
+		zap.L().Warn("auth.session.secret is not configured, using a random secret - sessions are valid only in this replica until it restarts")
+		sessionSecret = []byte(rndStr.NewLen(32))
+	}
+}
+
+// SessionTTL returns the configured session expiry duration
+func SessionTTL() time.Duration {
+	if config.Session.TTLSeconds > 0 {
+		return time.Duration(config.Session.TTLSeconds) * time.Second
+	}
+	return defaultSessionTTL
+}
+
+// ImpersonationTTL returns the configured impersonation session expiry duration
+func ImpersonationTTL() time.Duration {
+	if config.Session.ImpersonationTTLSeconds > 0 {
+		return time.Duration(config.Session.ImpersonationTTLSeconds) * time.Second
+	}
+	return defaultImpersonationTTL
+}
+
+// SecureSessionCookie returns true if the session cookie should be sent only over https
+func SecureSessionCookie() bool {
+	return config.Session.Secure
+}
+
+// NewSession stores a new session in db and returns its signed token
+func NewSession(c context.Context, customerGUID, userID string, admin bool) (string, *Session, error) {
+	now := time.Now().UTC()
+	return newSession(c, &Session{
+		ID:             uuid.NewV4().String(),
+		CustomerGUID:   customerGUID,
+		UserID:         userID,
+		Admin:          admin,
+		CreationTime:   now,
+		ExpirationTime: now.Add(SessionTTL()),
+	})
+}
+
+// NewImpersonationSession stores a time-boxed session of an admin in the target customer, the session is read only unless allowWrite is set
+func NewImpersonationSession(c context.Context, targetGUID string, impersonator Impersonator, allowWrite bool, reason string) (string, *Session, error) {
+	now := time.Now().UTC()
+	return newSession(c, &Session{
+		ID:             uuid.NewV4().String(),
+		CustomerGUID:   targetGUID,
+		Impersonator:   &impersonator,
+		ReadOnly:       !allowWrite,
+		Reason:         reason,
+		CreationTime:   now,
+		ExpirationTime: now.Add(ImpersonationTTL()),
+	})
+}
+
+func newSession(c context.Context, session *Session) (string, *Session, error) {
+	if _, err := mongo.GetWriteCollection(consts.SessionsCollection).InsertOne(c, session); err != nil {
+		return "", nil, err
+	}
+	return signSessionToken(session.ID, session.ExpirationTime), session, nil
+}
+
+// ValidateSessionToken verifies the token signature and the session status and returns the session identity
+func ValidateSessionToken(c context.Context, token string) (*Identity, error) {
+	session, err := getValidSession(c, token)
+	if err != nil {
+		return nil, err
+	}
+	//login sessions issued before login was disabled are rejected, impersonation sessions are issued to authenticated admins
+	if session.Impersonator == nil && !LoginSessionsAllowed() {
+		return nil, errors.New("login sessions are disabled")
+	}
+	return &Identity{
+		CustomerGUID: session.CustomerGUID,
+		UserID:       session.UserID,
+		Admin:        session.Admin,
+		Method:       MethodSession,
+		Impersonator: session.Impersonator,
+		ReadOnly:     session.ReadOnly,
+	}, nil
+}
+
+// RevokeSession revokes the session of the token, invalid or unknown tokens are ignored
+func RevokeSession(c context.Context, token string) error {
+	sessionID, _, err := parseSessionToken(token, time.Now())
+	if err != nil {
+		return nil
+	}
+	now := time.Now().UTC()
+	_, err = mongo.GetWriteCollection(consts.SessionsCollection).UpdateOne(c,
+		bson.D{{Key: consts.IdField, Value: sessionID}, {Key: "revocationTime", Value: bson.D{{Key: "$exists", Value: false}}}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "revocationTime", Value: now}}}})
+	return err
+}
+
+// RevokeCustomerSessions revokes all the active sessions of a customer and returns the number of revoked sessions
+func RevokeCustomerSessions(c context.Context, customerGUID string) (int64, error) {
+	now := time.Now().UTC()
+	res, err := mongo.GetWriteCollection(consts.SessionsCollection).UpdateMany(c,
+		bson.D{
+			{Key: "customerGUID", Value: customerGUID},
+			{Key: "revocationTime", Value: bson.D{{Key: "$exists", Value: false}}},
+			{Key: "expirationTime", Value: bson.D{{Key: "$gt", Value: now}}},
+		},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "revocationTime", Value: now}}}})
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+func getValidSession(c context.Context, token string) (*Session, error) {
+	now := time.Now().UTC()
+	sessionID, _, err := parseSessionToken(token, now)
+	if err != nil {
+		return nil, err
+	}
+	var session Session
+	if err := mongo.GetReadCollection(consts.SessionsCollection).FindOne(c, bson.D{{Key: consts.IdField, Value: sessionID}}).Decode(&session); err != nil {
+		if err == mongoDB.ErrNoDocuments {
+			return nil, errors.New("session not found")
+		}
+		return nil, err
+	}
+	if session.RevocationTime != nil {
+		return nil, errors.New("session is revoked")
+	}
+	if !session.ExpirationTime.After(now) {
+		return nil, errors.New("session is expired")
+	}
+	return &session, nil
+}
+
+func signSessionToken(sessionID string, expiration time.Time) string {
+	payload := sessionID + "." + strconv.FormatInt(expiration.Unix(), 10)
+	return payload + "." + sessionSignature(payload)
+}
+
+// parseSessionToken verifies the token signature and expiry and returns the session id
+func parseSessionToken(token string, now time.Time) (sessionID string, expiration time.Time, err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", time.Time{}, errors.New("malformed session token")
+	}
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(sessionSignature(payload))) {
+		return "", time.Time{}, errors.New("invalid session token signature")
+	}
+	expiry, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid session token expiry: %w", err)
+	}
+	expiration = time.Unix(expiry, 0)
+	if !expiration.After(now) {
+		return "", time.Time{}, errors.New("session token is expired")
+	}
+	return parts[0], expiration, nil
+}
+
+func sessionSignature(payload string) string {
+	mac := hmac.New(sha256.New, sessionSecret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
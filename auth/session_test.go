@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionToken(t *testing.T) {
+	sessionSecret = []byte("test-session-secret")
+	now := time.Now()
+	expiration := now.Add(time.Hour)
+	token := signSessionToken("session1", expiration)
+
+	sessionID, parsedExpiration, err := parseSessionToken(token, now)
+	require.NoError(t, err)
+	assert.Equal(t, "session1", sessionID)
+	assert.Equal(t, expiration.Unix(), parsedExpiration.Unix())
+
+	parts := strings.Split(token, ".")
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "malformed", token: "session1"},
+		{name: "changed session id", token: "session2." + parts[1] + "." + parts[2]},
+		{name: "extended expiry", token: parts[0] + "." + "9999999999" + "." + parts[2]},
+		{name: "expired", token: signSessionToken("session1", now.Add(-time.Minute))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := parseSessionToken(tt.token, now)
+			assert.Error(t, err)
+		})
+	}
+
+	//token signed with another secret
+	sessionSecret = []byte("other-secret")
+	_, _, err = parseSessionToken(token, now)
+	assert.Error(t, err)
+}
@@ -0,0 +1,307 @@
+package client
+
+import (
+	"bytes"
+	"config-service/utils/consts"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Go client of the config service routes, the documents are the types of the types package.
+// the client hides the quirks of the v1 routes (single vs array responses, [old,new] responses of PUT, 404 of empty lists),
+// authenticates with an API key, a bearer token or the session cookies and retries failed requests
+
+const (
+	defaultTimeout    = 30 * time.Second
+	defaultMinBackoff = 200 * time.Millisecond
+	defaultMaxBackoff = 10 * time.Second
+)
+
+// Client - client of the config service
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	headers    http.Header
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	cookiesLock sync.RWMutex
+	cookies     map[string]*http.Cookie
+}
+
+// Option - option of the client
+type Option func(*Client)
+
+// WithHTTPClient sets the http client of the requests, the default client has a 30 seconds timeout
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAPIKey authenticates the requests with an API key
+func WithAPIKey(apiKey string) Option {
+	return WithHeader(consts.APIKeyHeader, apiKey)
+}
+
+// WithBearerToken authenticates the requests with a bearer token
+func WithBearerToken(token string) Option {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithCookies authenticates the requests with cookies, e.g. the session and CSRF cookies of a login
+func WithCookies(cookies ...*http.Cookie) Option {
+	return func(c *Client) {
+		c.setCookies(cookies)
+	}
+}
+
+// WithHeader adds a header to all the requests
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.headers.Set(key, value)
+	}
+}
+
+// WithRetries retries requests that failed with network errors or with 429, 502, 503 and 504 responses up to maxRetries times,
+// the backoff starts with minBackoff and is doubled up to maxBackoff, Retry-After headers of the responses are honored.
+// POST requests are retried with the same Idempotency-Key
+func WithRetries(maxRetries int, minBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.minBackoff = minBackoff
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// New returns a client of the service at the base URL (e.g. https://config.example.com)
+func New(baseURL string, options ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %s: scheme must be http or https", baseURL)
+	}
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		headers:    http.Header{},
+		minBackoff: defaultMinBackoff,
+		maxBackoff: defaultMaxBackoff,
+		cookies:    map[string]*http.Cookie{},
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c, nil
+}
+
+// Login starts a session of the customer (and the user when not empty) and authenticates the next requests with the session cookies,
+// the server issues login sessions when they are enabled in its configuration
+func (c *Client) Login(ctx context.Context, customerGUID, userID string) error {
+	body := map[string]string{"customerGUID": customerGUID}
+	if userID != "" {
+		body["userId"] = userID
+	}
+	res, _, err := c.do(ctx, http.MethodPost, "/login", nil, body)
+	if err != nil {
+		return err
+	}
+	c.setCookies(res.Cookies())
+	return nil
+}
+
+// Logout revokes the session of the client
+func (c *Client) Logout(ctx context.Context) error {
+	if _, _, err := c.do(ctx, http.MethodPost, "/logout", nil, nil); err != nil {
+		return err
+	}
+	c.cookiesLock.Lock()
+	c.cookies = map[string]*http.Cookie{}
+	c.cookiesLock.Unlock()
+	return nil
+}
+
+func (c *Client) setCookies(cookies []*http.Cookie) {
+	c.cookiesLock.Lock()
+	defer c.cookiesLock.Unlock()
+	for _, cookie := range cookies {
+		if cookie.MaxAge < 0 || cookie.Value == "" {
+			delete(c.cookies, cookie.Name)
+		} else {
+			c.cookies[cookie.Name] = cookie
+		}
+	}
+}
+
+func (c *Client) addCookies(req *http.Request) {
+	c.cookiesLock.RLock()
+	defer c.cookiesLock.RUnlock()
+	for _, cookie := range c.cookies {
+		req.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+	}
+	//double submit of the CSRF token in state changing requests
+	if csrf, ok := c.cookies[consts.CSRFCookie]; ok && !isSafeMethod(req.Method) {
+		req.Header.Set(consts.CSRFHeader, csrf.Value)
+	}
+}
+
+// Error - error response of the service (RFC 7807 problem details)
+type Error struct {
+	StatusCode int         `json:"status"`
+	Code       string      `json:"code"`
+	Title      string      `json:"title"`
+	Detail     string      `json:"detail"`
+	Details    interface{} `json:"details,omitempty"`
+	TraceID    string      `json:"traceId,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("config service responded %d %s: %s", e.StatusCode, e.Code, e.Detail)
+	}
+	return fmt.Sprintf("config service responded %d: %s", e.StatusCode, e.Detail)
+}
+
+// IsNotFound returns true if the error is a not found response
+func IsNotFound(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.StatusCode == http.StatusNotFound
+}
+
+// IsConflict returns true if the error is a conflict response, e.g. a duplicate name
+func IsConflict(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.StatusCode == http.StatusConflict
+}
+
+func newError(res *http.Response, body []byte) *Error {
+	e := &Error{}
+	if err := json.Unmarshal(body, e); err != nil || (e.Detail == "" && e.Code == "") {
+		e = &Error{Detail: strings.TrimSpace(string(body))}
+		//former {"error":"<message>"} responses
+		var former struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &former) == nil && former.Error != "" {
+			e.Detail = former.Error
+		}
+	}
+	e.StatusCode = res.StatusCode
+	return e
+}
+
+// do sends the request with retries and returns the response and its body, error responses are returned as *Error
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Response, []byte, error) {
+	var bodyBytes []byte
+	if body != nil {
+		var err error
+		if bodyBytes, err = json.Marshal(body); err != nil {
+			return nil, nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+	}
+	u := *c.baseURL
+	u.Path += path
+	u.RawQuery = query.Encode()
+	idempotencyKey := ""
+	if method == http.MethodPost && c.maxRetries > 0 {
+		idempotencyKey = uuid.NewV4().String()
+	}
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(bodyBytes))
+		if err != nil {
+			return nil, nil, err
+		}
+		for key, values := range c.headers {
+			req.Header[key] = values
+		}
+		req.Header.Set("Accept", "application/json")
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if idempotencyKey != "" {
+			req.Header.Set(consts.IdempotencyKeyHeader, idempotencyKey)
+		}
+		c.addCookies(req)
+
+		res, resBody, err := c.send(req)
+		if attempt >= c.maxRetries || !retryable(res, err) {
+			if err != nil {
+				return nil, nil, err
+			}
+			if res.StatusCode >= http.StatusBadRequest {
+				return res, resBody, newError(res, resBody)
+			}
+			return res, resBody, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(c.backoff(attempt+1, res)):
+		}
+	}
+}
+
+func (c *Client) send(req *http.Request) (*http.Response, []byte, error) {
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	return res, body, nil
+}
+
+// retryable returns true for network errors and for responses of overload, unavailability and concurrent requests with the same idempotency key
+func retryable(res *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	case http.StatusConflict:
+		return res.Header.Get("Retry-After") != ""
+	}
+	return false
+}
+
+// backoff returns the wait before the retry, the Retry-After of the response when set
+func (c *Client) backoff(retry int, res *http.Response) time.Duration {
+	if res != nil {
+		if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			if wait := time.Duration(seconds) * time.Second; wait < c.maxBackoff {
+				return wait
+			}
+			return c.maxBackoff
+		}
+	}
+	wait := c.minBackoff
+	for i := 1; i < retry && wait < c.maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > c.maxBackoff {
+		return c.maxBackoff
+	}
+	return wait
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
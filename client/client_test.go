@@ -0,0 +1,303 @@
+package client
+
+import (
+	"config-service/types"
+	"config-service/utils/consts"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stub server of recorded requests
+type stub struct {
+	sync.Mutex
+	requests []*http.Request
+	bodies   []string
+	handler  http.HandlerFunc
+}
+
+func newStub(t *testing.T, handler http.HandlerFunc) (*stub, *httptest.Server) {
+	s := &stub{handler: handler}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.Lock()
+		s.requests = append(s.requests, r)
+		s.bodies = append(s.bodies, string(body))
+		s.Unlock()
+		s.handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+func respond(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(body))
+}
+
+func cluster(guid, name string) *types.Cluster {
+	return &types.Cluster{PortalBase: armotypes.PortalBase{GUID: guid, Name: name}}
+}
+
+func TestDocumentsResponses(t *testing.T) {
+	ctx := context.Background()
+	var status int
+	var body string
+	s, server := newStub(t, func(w http.ResponseWriter, r *http.Request) {
+		respond(w, status, body)
+	})
+	c, err := New(server.URL)
+	require.NoError(t, err)
+	clusters := c.Clusters()
+
+	//single and array POST responses
+	status, body = http.StatusCreated, `{"guid":"1","name":"c1"}`
+	created, err := clusters.Create(ctx, cluster("", "c1"))
+	require.NoError(t, err)
+	require.Len(t, created, 1)
+	assert.Equal(t, "1", created[0].GUID)
+	assert.JSONEq(t, `{"name":"c1"}`, mustJSONFields(t, s.bodies[0], "name"), "one document is posted as an object")
+	status, body = http.StatusCreated, `[{"guid":"1","name":"c1"},{"guid":"2","name":"c2"}]`
+	created, err = clusters.Create(ctx, cluster("", "c1"), cluster("", "c2"))
+	require.NoError(t, err)
+	require.Len(t, created, 2)
+	assert.Equal(t, "c2", created[1].Name)
+
+	//PUT responds with [old,new]
+	status, body = http.StatusOK, `[{"guid":"1","name":"c1"},{"guid":"1","name":"c1-new"}]`
+	updated, err := clusters.Update(ctx, cluster("1", "c1-new"))
+	require.NoError(t, err)
+	assert.Equal(t, "c1-new", updated.Name)
+	assert.Equal(t, consts.ClusterPath+"/1", s.requests[2].URL.Path)
+	assert.Equal(t, http.MethodPut, s.requests[2].Method)
+
+	//empty v1 lists respond with not found
+	status, body = http.StatusNotFound, `{"status":404,"code":"not_found","detail":"document not found"}`
+	docs, err := clusters.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, docs)
+	_, err = clusters.Get(ctx, "missing")
+	assert.True(t, IsNotFound(err), "not found of a document is an error")
+
+	status, body = http.StatusOK, `null`
+	names, err := clusters.Names(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, names)
+	assert.Equal(t, consts.ListParam+"=", s.requests[len(s.requests)-1].URL.RawQuery)
+
+	//delete by name
+	frameworks := c.Frameworks()
+	status, body = http.StatusOK, `{"guid":"1","name":"f1"}`
+	deleted, err := frameworks.DeleteByName(ctx, "f1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	status, body = http.StatusOK, `{"deletedCount":2}`
+	deleted, err = frameworks.DeleteByName(ctx, "f1", "f2", "f3")
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+	assert.Equal(t, []string{"f1", "f2", "f3"}, s.requests[len(s.requests)-1].URL.Query()[consts.FrameworkNameParam])
+	_, err = clusters.DeleteByName(ctx, "c1")
+	assert.Error(t, err, "clusters are not deleted by name")
+}
+
+func TestError(t *testing.T) {
+	var body string
+	_, server := newStub(t, func(w http.ResponseWriter, r *http.Request) {
+		respond(w, http.StatusConflict, body)
+	})
+	c, err := New(server.URL)
+	require.NoError(t, err)
+
+	body = `{"type":"urn:config-service:problem:duplicate_key","title":"Conflict","status":409,"detail":"name already exists","code":"duplicate_key","details":{"name":"c1"},"traceId":"trace1"}`
+	_, err = c.Clusters().Create(context.Background(), cluster("", "c1"))
+	require.Error(t, err)
+	assert.True(t, IsConflict(err))
+	var e *Error
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, &Error{StatusCode: http.StatusConflict, Code: "duplicate_key", Title: "Conflict", Detail: "name already exists",
+		Details: map[string]interface{}{"name": "c1"}, TraceID: "trace1"}, e)
+
+	body = `{"error":"former error"}`
+	_, err = c.Clusters().Get(context.Background(), "1")
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, "former error", e.Detail)
+	assert.Equal(t, "config service responded 409: former error", e.Error())
+}
+
+func TestRetries(t *testing.T) {
+	attempts := 0
+	s, server := newStub(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.Header().Set("Retry-After", "0")
+			respond(w, http.StatusServiceUnavailable, `{"status":503,"detail":"unavailable"}`)
+			return
+		}
+		respond(w, http.StatusCreated, `{"guid":"1","name":"c1"}`)
+	})
+	c, err := New(server.URL, WithRetries(2, time.Millisecond, 10*time.Millisecond))
+	require.NoError(t, err)
+	created, err := c.Clusters().Create(context.Background(), cluster("", "c1"))
+	require.NoError(t, err)
+	assert.Equal(t, "1", created[0].GUID)
+	require.Len(t, s.requests, 3)
+	key := s.requests[0].Header.Get(consts.IdempotencyKeyHeader)
+	assert.NotEmpty(t, key, "retried POST has an idempotency key")
+	assert.Equal(t, key, s.requests[2].Header.Get(consts.IdempotencyKeyHeader))
+	assert.Equal(t, s.bodies[0], s.bodies[2], "body is resent")
+
+	//retries are exhausted
+	attempts = -10
+	_, err = c.Clusters().Get(context.Background(), "1")
+	var e *Error
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, http.StatusServiceUnavailable, e.StatusCode)
+	assert.Len(t, s.requests, 6)
+
+	//client errors are not retried
+	s.handler = func(w http.ResponseWriter, r *http.Request) {
+		respond(w, http.StatusBadRequest, `{"status":400,"detail":"bad"}`)
+	}
+	_, err = c.Clusters().Get(context.Background(), "1")
+	assert.Error(t, err)
+	assert.Len(t, s.requests, 7)
+	c, _ = New(server.URL)
+	c.Clusters().Create(context.Background(), cluster("", "c1"))
+	assert.Empty(t, s.requests[7].Header.Get(consts.IdempotencyKeyHeader), "no idempotency key without retries")
+}
+
+func TestBackoff(t *testing.T) {
+	c, err := New("http://localhost:8080", WithRetries(5, time.Second, 5*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, c.backoff(1, nil))
+	assert.Equal(t, 2*time.Second, c.backoff(2, nil))
+	assert.Equal(t, 4*time.Second, c.backoff(3, nil))
+	assert.Equal(t, 5*time.Second, c.backoff(4, nil))
+	res := &http.Response{Header: http.Header{"Retry-After": {"3"}}}
+	assert.Equal(t, 3*time.Second, c.backoff(1, res))
+	res.Header.Set("Retry-After", "60")
+	assert.Equal(t, 5*time.Second, c.backoff(1, res), "retry after is capped")
+}
+
+func TestAuth(t *testing.T) {
+	s, server := newStub(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: consts.SessionCookie, Value: "session-token"})
+			http.SetCookie(w, &http.Cookie{Name: consts.CSRFCookie, Value: "csrf-token"})
+		}
+		respond(w, http.StatusOK, `[]`)
+	})
+	ctx := context.Background()
+	c, err := New(server.URL, WithAPIKey("csk_key"))
+	require.NoError(t, err)
+	c.Clusters().List(ctx)
+	assert.Equal(t, "csk_key", s.requests[0].Header.Get(consts.APIKeyHeader))
+
+	c, _ = New(server.URL, WithBearerToken("token"))
+	c.Clusters().List(ctx)
+	assert.Equal(t, "Bearer token", s.requests[1].Header.Get("Authorization"))
+
+	c, _ = New(server.URL)
+	require.NoError(t, c.Login(ctx, "customer-guid", "user1"))
+	assert.JSONEq(t, `{"customerGUID":"customer-guid","userId":"user1"}`, s.bodies[2])
+	c.Clusters().List(ctx)
+	cookie, err := s.requests[3].Cookie(consts.SessionCookie)
+	require.NoError(t, err)
+	assert.Equal(t, "session-token", cookie.Value)
+	assert.Empty(t, s.requests[3].Header.Get(consts.CSRFHeader), "no CSRF token in safe requests")
+	c.Clusters().Delete(ctx, "1")
+	assert.Equal(t, "csrf-token", s.requests[4].Header.Get(consts.CSRFHeader))
+
+	c, _ = New(server.URL, WithCookies(&http.Cookie{Name: consts.CustomerGUID, Value: "customer-guid"}))
+	c.Clusters().List(ctx)
+	cookie, err = s.requests[5].Cookie(consts.CustomerGUID)
+	require.NoError(t, err)
+	assert.Equal(t, "customer-guid", cookie.Value)
+
+	_, err = New("localhost:8080")
+	assert.Error(t, err, "base URL without scheme")
+}
+
+func TestQuery(t *testing.T) {
+	query := NewQuery().
+		Scope("cluster", "c1", "c2").
+		Field("posturePolicies", "frameworkName", "NSA").
+		Attribute("alias", "prod").
+		Key("name", "n1")
+	assert.Equal(t, "attributes.alias=prod&name=n1&posturePolicies.frameworkName=NSA&scope.cluster=c1&scope.cluster=c2", query.Values().Encode())
+	assert.Equal(t, "", (*Query)(nil).Values().Encode())
+
+	s, server := newStub(t, func(w http.ResponseWriter, r *http.Request) {
+		respond(w, http.StatusOK, `[{"guid":"1","name":"p1"}]`)
+	})
+	c, _ := New(server.URL + "/")
+	policies, err := c.PostureExceptionPolicies().Query(context.Background(), NewQuery().Scope("cluster", "c1"))
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	assert.Equal(t, "p1", policies[0].Name)
+	assert.Equal(t, consts.PostureExceptionPolicyPath, s.requests[0].URL.Path)
+	assert.Equal(t, "scope.cluster=c1", s.requests[0].URL.RawQuery)
+}
+
+func TestCustomerConfig(t *testing.T) {
+	s, server := newStub(t, func(w http.ResponseWriter, r *http.Request) {
+		respond(w, http.StatusOK, `{"guid":"1","name":"c1","settings":{"vulnerabilityScansScheduleTime":"1h"}}`)
+	})
+	ctx := context.Background()
+	c, _ := New(server.URL)
+	config, err := c.ClusterConfig(ctx, "c1")
+	require.NoError(t, err)
+	assert.Equal(t, "c1", config.Name)
+	assert.Equal(t, consts.ClusterNameParam+"=c1", s.requests[0].URL.RawQuery)
+	c.CustomerConfig(ctx)
+	assert.Equal(t, consts.ScopeParam+"="+consts.CustomerScope, s.requests[1].URL.RawQuery)
+	c.DefaultConfig(ctx)
+	assert.Equal(t, consts.ScopeParam+"="+consts.DefaultScope, s.requests[2].URL.RawQuery)
+	c.UnmergedConfig(ctx, "c1")
+	assert.Equal(t, consts.ConfigNameParam+"=c1&unmerged=true", s.requests[3].URL.RawQuery)
+	c.DeleteConfig(ctx, "c1")
+	assert.Equal(t, http.MethodDelete, s.requests[4].Method)
+	assert.Equal(t, consts.ConfigNameParam+"=c1", s.requests[4].URL.RawQuery)
+}
+
+func TestSync(t *testing.T) {
+	s, server := newStub(t, func(w http.ResponseWriter, r *http.Request) {
+		respond(w, http.StatusOK, `{"dryRun":true,"key":"name","managedBy":"gitops","summary":{"created":1},"changes":[{"action":"create","key":"c1"}]}`)
+	})
+	ctx := context.Background()
+	c, _ := New(server.URL)
+	result, err := c.Clusters().Sync(ctx, []*types.Cluster{cluster("", "c1")}, SyncOptions{DryRun: true, ManagedBy: "gitops"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Summary.Created)
+	assert.Equal(t, []SyncChange{{Action: "create", Key: "c1"}}, result.Changes)
+	assert.Equal(t, http.MethodPut, s.requests[0].Method)
+	assert.Equal(t, consts.ClusterPath+"/sync", s.requests[0].URL.Path)
+	assert.Equal(t, consts.DryRunParam+"=true&"+consts.ManagedByParam+"=gitops", s.requests[0].URL.RawQuery)
+	//an empty desired set is an empty array
+	_, err = c.Clusters().Sync(ctx, nil, SyncOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "[]", s.bodies[1])
+}
+
+// mustJSONFields returns the JSON of the fields of a JSON object
+func mustJSONFields(t *testing.T, body string, fields ...string) string {
+	obj := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(body), &obj))
+	res := map[string]interface{}{}
+	for _, field := range fields {
+		res[field] = obj[field]
+	}
+	data, err := json.Marshal(res)
+	require.NoError(t, err)
+	return string(data)
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"config-service/types"
+	"config-service/utils/consts"
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// DefaultConfig returns the default configuration of all the customers
+func (c *Client) DefaultConfig(ctx context.Context) (*types.CustomerConfig, error) {
+	return c.getConfig(ctx, url.Values{consts.ScopeParam: {consts.DefaultScope}})
+}
+
+// CustomerConfig returns the configuration of the customer merged over the default configuration
+func (c *Client) CustomerConfig(ctx context.Context) (*types.CustomerConfig, error) {
+	return c.getConfig(ctx, url.Values{consts.ScopeParam: {consts.CustomerScope}})
+}
+
+// ClusterConfig returns the configuration of the cluster merged over the customer and the default configurations,
+// it returns the merged customer configuration when the cluster has no configuration
+func (c *Client) ClusterConfig(ctx context.Context, clusterName string) (*types.CustomerConfig, error) {
+	return c.getConfig(ctx, url.Values{consts.ClusterNameParam: {clusterName}})
+}
+
+// UnmergedConfig returns the configuration with the name as it is stored, without merging the customer and the default configurations
+func (c *Client) UnmergedConfig(ctx context.Context, configName string) (*types.CustomerConfig, error) {
+	return c.getConfig(ctx, url.Values{consts.ConfigNameParam: {configName}, "unmerged": {"true"}})
+}
+
+// UpdateConfig updates the configuration with the name of the config and returns the updated configuration
+func (c *Client) UpdateConfig(ctx context.Context, config *types.CustomerConfig) (*types.CustomerConfig, error) {
+	_, body, err := c.do(ctx, http.MethodPut, consts.CustomerConfigPath, url.Values{consts.ConfigNameParam: {config.Name}}, config)
+	if err != nil {
+		return nil, err
+	}
+	return decodeUpdated[*types.CustomerConfig](body)
+}
+
+// DeleteConfig deletes the configuration with the name and returns the deleted configuration
+func (c *Client) DeleteConfig(ctx context.Context, configName string) (*types.CustomerConfig, error) {
+	_, body, err := c.do(ctx, http.MethodDelete, consts.CustomerConfigPath, url.Values{consts.ConfigNameParam: {configName}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeDoc[*types.CustomerConfig](body)
+}
+
+func (c *Client) getConfig(ctx context.Context, query url.Values) (*types.CustomerConfig, error) {
+	_, body, err := c.do(ctx, http.MethodGet, consts.CustomerConfigPath, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeDoc[*types.CustomerConfig](body)
+}
+
+// Customer returns the customer of the client
+func (c *Client) Customer(ctx context.Context) (*types.Customer, error) {
+	_, body, err := c.do(ctx, http.MethodGet, consts.CustomerPath, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeDoc[*types.Customer](body)
+}
+
+// UpdateCustomer updates the fields of the customer of the client and returns the updated customer
+func (c *Client) UpdateCustomer(ctx context.Context, customer *types.Customer) (*types.Customer, error) {
+	_, body, err := c.do(ctx, http.MethodPut, consts.CustomerPath, nil, customer)
+	if err != nil {
+		return nil, err
+	}
+	return decodeUpdated[*types.Customer](body)
+}
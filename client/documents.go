@@ -0,0 +1,219 @@
+package client
+
+import (
+	"config-service/types"
+	"config-service/utils/consts"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Documents - typed CRUD of the documents of a v1 route
+type Documents[T types.DocContent] struct {
+	client    *Client
+	path      string
+	nameParam string
+}
+
+// NewDocuments returns the CRUD of documents of type T served at the path, nameParam is the query param of the get and delete by name (empty when not supported)
+func NewDocuments[T types.DocContent](c *Client, path, nameParam string) *Documents[T] {
+	return &Documents[T]{client: c, path: path, nameParam: nameParam}
+}
+
+func (c *Client) Clusters() *Documents[*types.Cluster] {
+	return NewDocuments[*types.Cluster](c, consts.ClusterPath, "")
+}
+
+func (c *Client) PostureExceptionPolicies() *Documents[*types.PostureExceptionPolicy] {
+	return NewDocuments[*types.PostureExceptionPolicy](c, consts.PostureExceptionPolicyPath, consts.PolicyNameParam)
+}
+
+func (c *Client) VulnerabilityExceptionPolicies() *Documents[*types.VulnerabilityExceptionPolicy] {
+	return NewDocuments[*types.VulnerabilityExceptionPolicy](c, consts.VulnerabilityExceptionPolicyPath, consts.PolicyNameParam)
+}
+
+func (c *Client) Frameworks() *Documents[*types.Framework] {
+	return NewDocuments[*types.Framework](c, consts.FrameworkPath, consts.FrameworkNameParam)
+}
+
+func (c *Client) Repositories() *Documents[*types.Repository] {
+	return NewDocuments[*types.Repository](c, consts.RepositoryPath, "")
+}
+
+func (c *Client) RegistryCronJobs() *Documents[*types.RegistryCronJob] {
+	return NewDocuments[*types.RegistryCronJob](c, consts.RegistryCronJobPath, consts.NameField)
+}
+
+func (c *Client) WebhookSubscriptions() *Documents[*types.WebhookSubscription] {
+	return NewDocuments[*types.WebhookSubscription](c, consts.WebhookPath, "")
+}
+
+// CustomerConfigs - the customer configurations documents, GetByName returns the merged configuration (see ClusterConfig)
+func (c *Client) CustomerConfigs() *Documents[*types.CustomerConfig] {
+	return NewDocuments[*types.CustomerConfig](c, consts.CustomerConfigPath, consts.ConfigNameParam)
+}
+
+// List returns all the documents of the customer
+func (d *Documents[T]) List(ctx context.Context) ([]T, error) {
+	return d.list(ctx, nil)
+}
+
+// Query returns the documents matching the scope query
+func (d *Documents[T]) Query(ctx context.Context, query *Query) ([]T, error) {
+	return d.list(ctx, query.Values())
+}
+
+func (d *Documents[T]) list(ctx context.Context, query url.Values) ([]T, error) {
+	_, body, err := d.client.do(ctx, http.MethodGet, d.path, query, nil)
+	if err != nil {
+		//v1 lists respond with not found when there are no documents
+		if IsNotFound(err) {
+			return []T{}, nil
+		}
+		return nil, err
+	}
+	docs := []T{}
+	if err := json.Unmarshal(body, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode documents: %w", err)
+	}
+	return docs, nil
+}
+
+// Names returns the names of the documents of the customer
+func (d *Documents[T]) Names(ctx context.Context) ([]string, error) {
+	_, body, err := d.client.do(ctx, http.MethodGet, d.path, url.Values{consts.ListParam: {""}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	if err := json.Unmarshal(body, &names); err != nil {
+		return nil, fmt.Errorf("failed to decode names: %w", err)
+	}
+	if names == nil {
+		names = []string{}
+	}
+	return names, nil
+}
+
+// Get returns the document with the GUID
+func (d *Documents[T]) Get(ctx context.Context, guid string) (T, error) {
+	_, body, err := d.client.do(ctx, http.MethodGet, d.path+"/"+url.PathEscape(guid), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeDoc[T](body)
+}
+
+// GetByName returns the document with the name
+func (d *Documents[T]) GetByName(ctx context.Context, name string) (T, error) {
+	if d.nameParam == "" {
+		return nil, fmt.Errorf("%s does not support get by name", d.path)
+	}
+	_, body, err := d.client.do(ctx, http.MethodGet, d.path, url.Values{d.nameParam: {name}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeDoc[T](body)
+}
+
+// Create creates the documents and returns the created documents
+func (d *Documents[T]) Create(ctx context.Context, docs ...T) ([]T, error) {
+	if len(docs) == 0 {
+		return []T{}, nil
+	}
+	var reqBody interface{} = docs
+	if len(docs) == 1 {
+		reqBody = docs[0]
+	}
+	_, body, err := d.client.do(ctx, http.MethodPost, d.path, nil, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	//v1 POST responds with the document when one document is created
+	return decodeList[T](body)
+}
+
+// Update updates the fields of the document with the GUID of the document and returns the updated document
+func (d *Documents[T]) Update(ctx context.Context, doc T) (T, error) {
+	path := d.path
+	if guid := doc.GetGUID(); guid != "" {
+		path += "/" + url.PathEscape(guid)
+	}
+	_, body, err := d.client.do(ctx, http.MethodPut, path, nil, doc)
+	if err != nil {
+		return nil, err
+	}
+	return decodeUpdated[T](body)
+}
+
+// Delete deletes the document with the GUID and returns the deleted document
+func (d *Documents[T]) Delete(ctx context.Context, guid string) (T, error) {
+	_, body, err := d.client.do(ctx, http.MethodDelete, d.path+"/"+url.PathEscape(guid), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeDoc[T](body)
+}
+
+// DeleteByName deletes the documents with the names and returns the number of deleted documents
+func (d *Documents[T]) DeleteByName(ctx context.Context, names ...string) (int, error) {
+	if d.nameParam == "" {
+		return 0, fmt.Errorf("%s does not support delete by name", d.path)
+	}
+	if len(names) == 0 {
+		return 0, nil
+	}
+	_, body, err := d.client.do(ctx, http.MethodDelete, d.path, url.Values{d.nameParam: names}, nil)
+	if err != nil {
+		return 0, err
+	}
+	//v1 delete of one name responds with the deleted document
+	if len(names) == 1 {
+		return 1, nil
+	}
+	var res struct {
+		DeletedCount int `json:"deletedCount"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return 0, fmt.Errorf("failed to decode delete response: %w", err)
+	}
+	return res.DeletedCount, nil
+}
+
+func decodeDoc[T types.DocContent](body []byte) (T, error) {
+	var doc T
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode document: %w", err)
+	}
+	return doc, nil
+}
+
+// decodeList decodes v1 responses of one or more documents
+func decodeList[T any](body []byte) ([]T, error) {
+	docs := []T{}
+	if len(body) > 0 && body[0] == '{' {
+		var doc T
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return nil, fmt.Errorf("failed to decode document: %w", err)
+		}
+		return append(docs, doc), nil
+	}
+	if err := json.Unmarshal(body, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode documents: %w", err)
+	}
+	return docs, nil
+}
+
+// decodeUpdated decodes the updated document from v1 PUT [old,new] responses
+func decodeUpdated[T types.DocContent](body []byte) (T, error) {
+	docs := []T{}
+	if err := json.Unmarshal(body, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode documents: %w", err)
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("update responded with no documents")
+	}
+	return docs[len(docs)-1], nil
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"net/url"
+)
+
+// Query - scope query of documents, mirrors the QueryParamsConfig of the routes:
+// params are <field>.<key>=<value>, values of the same param are ORed and different params are ANDed.
+// a key without a field is in the default field of the route (attributes in most routes, designators in vulnerability exception policies)
+type Query struct {
+	params url.Values
+}
+
+// NewQuery returns an empty query
+func NewQuery() *Query {
+	return &Query{params: url.Values{}}
+}
+
+// Field adds values of the key in the field, e.g. Field("posturePolicies", "frameworkName", "NSA")
+func (q *Query) Field(field, key string, values ...string) *Query {
+	if field == "" {
+		return q.Key(key, values...)
+	}
+	return q.Key(field+"."+key, values...)
+}
+
+// Key adds values of a key in the default field of the route, or of a top level field in routes with flat queries (e.g. registry cron jobs)
+func (q *Query) Key(key string, values ...string) *Query {
+	for _, value := range values {
+		q.params.Add(key, value)
+	}
+	return q
+}
+
+// Attribute adds values of the attribute, e.g. Attribute("alias", "prod")
+func (q *Query) Attribute(key string, values ...string) *Query {
+	return q.Field("attributes", key, values...)
+}
+
+// Scope adds values of the scope attribute, the resources of posture exception policies and the designators of vulnerability exception policies,
+// e.g. Scope("cluster", "prod-cluster")
+func (q *Query) Scope(key string, values ...string) *Query {
+	return q.Field("scope", key, values...)
+}
+
+// Values returns the query params
+func (q *Query) Values() url.Values {
+	values := url.Values{}
+	if q == nil {
+		return values
+	}
+	for key, vals := range q.params {
+		values[key] = append([]string{}, vals...)
+	}
+	return values
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"config-service/utils/consts"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// SyncOptions - options of a desired state sync
+type SyncOptions struct {
+	DryRun    bool   //return the plan without applying it
+	ManagedBy string //the manager of the synced documents, the service default is "sync"
+	Adopt     bool   //update and mark matched documents of other managers or created in the UI
+}
+
+// SyncChange - a change of the plan of a sync
+type SyncChange struct {
+	Action string   `json:"action"` //create, update, delete or skip
+	Key    string   `json:"key"`
+	GUID   string   `json:"guid,omitempty"`
+	Fields []string `json:"fields,omitempty"`
+	Reason string   `json:"reason,omitempty"`
+}
+
+// SyncResult - the plan of a sync, applied unless it is a dry run
+type SyncResult struct {
+	DryRun    bool   `json:"dryRun"`
+	Atomic    bool   `json:"atomic"`
+	Key       string `json:"key"`
+	ManagedBy string `json:"managedBy"`
+	Summary   struct {
+		Created   int `json:"created"`
+		Updated   int `json:"updated"`
+		Deleted   int `json:"deleted"`
+		Unchanged int `json:"unchanged"`
+		Skipped   int `json:"skipped"`
+	} `json:"summary"`
+	Changes []SyncChange `json:"changes"`
+}
+
+// Sync makes the documents of the customer as the desired documents: documents are matched by name, unmatched documents
+// are created, changed documents are updated and documents of the manager that are not desired are deleted
+func (d *Documents[T]) Sync(ctx context.Context, desired []T, opts SyncOptions) (*SyncResult, error) {
+	if desired == nil {
+		desired = []T{}
+	}
+	query := url.Values{}
+	if opts.DryRun {
+		query.Set(consts.DryRunParam, "true")
+	}
+	if opts.ManagedBy != "" {
+		query.Set(consts.ManagedByParam, opts.ManagedBy)
+	}
+	if opts.Adopt {
+		query.Set(consts.AdoptParam, "true")
+	}
+	_, body, err := d.client.do(ctx, http.MethodPut, d.path+"/sync", query, desired)
+	if err != nil {
+		return nil, err
+	}
+	result := &SyncResult{}
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil, fmt.Errorf("failed to decode sync response: %w", err)
+	}
+	return result, nil
+}
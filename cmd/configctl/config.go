@@ -0,0 +1,222 @@
+package main
+
+import (
+	"config-service/client"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"sigs.k8s.io/yaml"
+)
+
+const configEnv = "CONFIGCTL_CONFIG"
+
+// Config - the contexts of the local config file, a context selects the endpoint and the credentials
+type Config struct {
+	CurrentContext string     `json:"currentContext,omitempty"`
+	Contexts       []*Context `json:"contexts"`
+}
+
+// Context - endpoint and credentials of the service
+type Context struct {
+	Name         string `json:"name"`
+	Server       string `json:"server"`
+	APIKey       string `json:"apiKey,omitempty"`
+	BearerToken  string `json:"bearerToken,omitempty"`
+	CustomerGUID string `json:"customerGUID,omitempty"` //login with a session of the customer, for development
+	UserID       string `json:"userId,omitempty"`
+}
+
+// defaultConfigPath returns the config file path of the CONFIGCTL_CONFIG env or ~/.config/configctl/config.yaml
+func defaultConfigPath() string {
+	if path := os.Getenv(configEnv); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "configctl", "config.yaml")
+}
+
+// loadConfig reads the config file, a missing file is an empty config
+func loadConfig(path string) (*Config, error) {
+	config := &Config{Contexts: []*Context{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// save writes the config file, it is readable only by the user since it has credentials
+func (config *Config) save(path string) error {
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+func (config *Config) context(name string) *Context {
+	for _, ctx := range config.Contexts {
+		if ctx.Name == name {
+			return ctx
+		}
+	}
+	return nil
+}
+
+// newClient returns a client of the named context, or of the current context when the name is empty
+func (config *Config) newClient(ctx context.Context, name string, httpClient *http.Client) (*client.Client, error) {
+	if name == "" {
+		name = config.CurrentContext
+	}
+	if name == "" {
+		return nil, fmt.Errorf("no current context, add one with: configctl context set <name> --server <url>")
+	}
+	configCtx := config.context(name)
+	if configCtx == nil {
+		return nil, fmt.Errorf("context %s not found", name)
+	}
+	options := []client.Option{client.WithRetries(3, defaultMinBackoff, defaultMaxBackoff)}
+	if httpClient != nil {
+		options = append(options, client.WithHTTPClient(httpClient))
+	}
+	switch {
+	case configCtx.APIKey != "":
+		options = append(options, client.WithAPIKey(configCtx.APIKey))
+	case configCtx.BearerToken != "":
+		options = append(options, client.WithBearerToken(configCtx.BearerToken))
+	}
+	c, err := client.New(configCtx.Server, options...)
+	if err != nil {
+		return nil, err
+	}
+	if configCtx.APIKey == "" && configCtx.BearerToken == "" && configCtx.CustomerGUID != "" {
+		if err := c.Login(ctx, configCtx.CustomerGUID, configCtx.UserID); err != nil {
+			return nil, fmt.Errorf("failed to login: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// runContext runs the context sub commands: list, use, set and delete
+func (cli *cli) runContext(args []string) error {
+	if len(args) == 0 {
+		return usageError("context list|use|set|delete")
+	}
+	config, err := loadConfig(cli.configPath)
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "list":
+		sort.Slice(config.Contexts, func(i, j int) bool { return config.Contexts[i].Name < config.Contexts[j].Name })
+		w := tabwriter.NewWriter(cli.stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "CURRENT\tNAME\tSERVER\tAUTH")
+		for _, ctx := range config.Contexts {
+			current := ""
+			if ctx.Name == config.CurrentContext {
+				current = "*"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", current, ctx.Name, ctx.Server, ctx.auth())
+		}
+		return w.Flush()
+	case "use":
+		if len(args) != 2 {
+			return usageError("context use <name>")
+		}
+		if config.context(args[1]) == nil {
+			return fmt.Errorf("context %s not found", args[1])
+		}
+		config.CurrentContext = args[1]
+	case "set":
+		flags := newFlagSet("context set", cli.stderr)
+		configCtx := &Context{}
+		flags.StringVar(&configCtx.Server, "server", "", "base URL of the service")
+		flags.StringVar(&configCtx.APIKey, "api-key", "", "API key")
+		flags.StringVar(&configCtx.BearerToken, "bearer-token", "", "bearer token")
+		flags.StringVar(&configCtx.CustomerGUID, "customer-guid", "", "customer of a login session (development)")
+		flags.StringVar(&configCtx.UserID, "user-id", "", "user of a login session (development)")
+		positional, err := parseFlags(flags, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(positional) != 1 {
+			return usageError("context set <name> --server <url> [--api-key <key>|--bearer-token <token>|--customer-guid <guid>]")
+		}
+		configCtx.Name = positional[0]
+		if existing := config.context(configCtx.Name); existing != nil {
+			//only the given flags are changed
+			flags.Visit(func(f *flag.Flag) {
+				switch f.Name {
+				case "server":
+					existing.Server = configCtx.Server
+				case "api-key":
+					existing.APIKey = configCtx.APIKey
+				case "bearer-token":
+					existing.BearerToken = configCtx.BearerToken
+				case "customer-guid":
+					existing.CustomerGUID = configCtx.CustomerGUID
+				case "user-id":
+					existing.UserID = configCtx.UserID
+				}
+			})
+		} else {
+			if configCtx.Server == "" {
+				return usageError("context set <name> --server <url>")
+			}
+			config.Contexts = append(config.Contexts, configCtx)
+		}
+		if config.CurrentContext == "" {
+			config.CurrentContext = configCtx.Name
+		}
+	case "delete":
+		if len(args) != 2 {
+			return usageError("context delete <name>")
+		}
+		contexts := []*Context{}
+		for _, ctx := range config.Contexts {
+			if ctx.Name != args[1] {
+				contexts = append(contexts, ctx)
+			}
+		}
+		if len(contexts) == len(config.Contexts) {
+			return fmt.Errorf("context %s not found", args[1])
+		}
+		config.Contexts = contexts
+		if config.CurrentContext == args[1] {
+			config.CurrentContext = ""
+		}
+	default:
+		return usageError("context list|use|set|delete")
+	}
+	return config.save(cli.configPath)
+}
+
+func (ctx *Context) auth() string {
+	switch {
+	case ctx.APIKey != "":
+		return "api-key"
+	case ctx.BearerToken != "":
+		return "bearer-token"
+	case ctx.CustomerGUID != "":
+		return "login " + ctx.CustomerGUID
+	}
+	return "none"
+}
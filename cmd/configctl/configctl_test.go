@@ -0,0 +1,334 @@
+package main
+
+import (
+	"bytes"
+	"config-service/utils/consts"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestReadManifests(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "policies.yaml"), `
+kind: pep
+items:
+  - name: policy1
+    actions: [alertOnly]
+---
+kind: clusters
+items:
+  - name: cluster1
+    attributes:
+      alias: CLUSTER1
+`)
+	writeFile(t, filepath.Join(dir, "nested", "clusters.json"), `{"kind":"cluster","items":[{"name":"cluster2","attributes":{"port":8080}}]}`)
+	writeFile(t, filepath.Join(dir, "README.md"), "not a manifest")
+
+	manifests, isDir, err := readManifests(dir, nil)
+	require.NoError(t, err)
+	assert.True(t, isDir)
+	require.Len(t, manifests, 2)
+	assert.Equal(t, "clusters", manifests[0].resource.name, "in the order of the resources")
+	assert.ElementsMatch(t, []string{"cluster1", "cluster2"}, []string{manifests[0].docs[0].name(), manifests[0].docs[1].name()})
+	assert.Equal(t, "postureExceptionPolicies", manifests[1].resource.name)
+	assert.Equal(t, []interface{}{"alertOnly"}, manifests[1].docs[0]["actions"])
+	for _, doc := range manifests[0].docs {
+		if doc.name() == "cluster2" {
+			assert.Equal(t, float64(8080), doc["attributes"].(map[string]interface{})["port"], "numbers are decoded as json numbers")
+		}
+	}
+
+	manifests, isDir, err = readManifests("-", strings.NewReader("kind: frameworks\nitems: [{name: framework1}]"))
+	require.NoError(t, err)
+	assert.False(t, isDir)
+	assert.Equal(t, "framework1", manifests[0].docs[0].name())
+
+	_, _, err = readManifests("-", strings.NewReader("kind: nodes\nitems: [{name: node1}]"))
+	assert.ErrorContains(t, err, "unknown resource nodes")
+	_, _, err = readManifests("-", strings.NewReader("items: [{name: node1}]"))
+	assert.ErrorContains(t, err, "manifest without kind")
+	_, _, err = readManifests("-", strings.NewReader("kind: clusters\nitems: [{attributes: {}}]"))
+	assert.ErrorContains(t, err, "clusters document 0 has no name")
+}
+
+func TestPlanChanges(t *testing.T) {
+	clusters, _ := findResource("clusters")
+	local := []document{
+		{"name": "new"},
+		{"name": "same", "attributes": map[string]interface{}{"alias": "SAME"}},
+		{"name": "changed", "attributes": map[string]interface{}{"alias": "NEW"}, "guid": "ignored-guid-of-other-tenant"},
+		{"name": "renamed", "guid": "guid4", "attributes": map[string]interface{}{"alias": "RENAMED"}},
+	}
+	remote := []document{
+		{"name": "same", "guid": "guid1", "creationTime": "2023-01-01", "attributes": map[string]interface{}{"alias": "SAME"}},
+		{"name": "changed", "guid": "guid2", "attributes": map[string]interface{}{"alias": "OLD"}},
+		{"name": "removed", "guid": "guid3"},
+		{"name": "old-name", "guid": "guid4", "attributes": map[string]interface{}{"alias": "RENAMED"}},
+	}
+	changes, err := planChanges(clusters, local, remote, true)
+	require.NoError(t, err)
+	summary := []string{}
+	for _, ch := range changes {
+		summary = append(summary, fmt.Sprintf("%s %s %v", ch.action, ch.name(), ch.fields))
+	}
+	assert.Equal(t, []string{
+		"create new []",
+		"create changed []", //the guid of the manifest is not in the service
+		"update renamed [name]",
+		"delete changed []",
+		"delete removed []",
+	}, summary)
+
+	local[2] = document{"name": "changed", "attributes": map[string]interface{}{"alias": "NEW"}}
+	changes, err = planChanges(clusters, local, remote, false)
+	require.NoError(t, err)
+	require.Len(t, changes, 3, "no deletes without prune")
+	update := changes[1]
+	assert.Equal(t, actionUpdate, update.action)
+	assert.Equal(t, []string{"attributes"}, update.fields)
+	assert.Equal(t, document{"name": "changed", "guid": "guid2", "attributes": map[string]interface{}{"alias": "NEW"}}, update.updatedDocument())
+	assert.Contains(t, update.diff(), `-`)
+	assert.Contains(t, update.diff(), `"OLD"`)
+	assert.Contains(t, update.diff(), `"NEW"`)
+
+	out := &bytes.Buffer{}
+	printPlan(out, changes, false)
+	assert.Equal(t, "  + create clusters/new\n  ~ update clusters/changed: attributes\n  ~ update clusters/renamed: name\nPlan: 1 to create, 2 to update, 0 to delete.\n", out.String())
+
+	_, err = planChanges(clusters, []document{{"name": "dup"}, {"name": "dup"}}, remote, false)
+	assert.ErrorContains(t, err, "clusters dup is duplicated in the manifests")
+
+	//protected documents and write only fields
+	configs, _ := findResource("customerConfigs")
+	changes, err = planChanges(configs, []document{}, []document{{"name": consts.GlobalConfigName}}, true)
+	require.NoError(t, err)
+	assert.Empty(t, changes, "the default configuration is not deleted")
+	webhooks, _ := findResource("webhook")
+	changes, err = planChanges(webhooks, []document{{"name": "hook", "secret": "secret-of-the-subscription"}}, []document{{"name": "hook", "secret": nil}}, false)
+	require.NoError(t, err)
+	assert.Empty(t, changes, "the secret is not compared")
+}
+
+func TestExportManifest(t *testing.T) {
+	configs, _ := findResource("config")
+	m := exportManifest(configs, []document{
+		{"name": consts.GlobalConfigName, "guid": "guid1"},
+		{"name": "cluster1", "guid": "guid2", "creationTime": "2023-01-01", "updatedTime": "2023-01-02", "settings": map[string]interface{}{}},
+	})
+	assert.Equal(t, &manifest{Kind: "customerConfigs", Items: []document{{"name": "cluster1", "settings": map[string]interface{}{}}}}, m)
+	webhooks, _ := findResource(consts.WebhookPath)
+	m = exportManifest(webhooks, []document{{"name": "hook", "secret": nil}})
+	assert.Equal(t, []document{{"name": "hook"}}, m.Items)
+}
+
+func TestContexts(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "configctl", "config.yaml")
+	run := func(args ...string) (int, string) {
+		out := &bytes.Buffer{}
+		c := &cli{stdin: strings.NewReader(""), stdout: out, stderr: out}
+		code := c.run(context.Background(), append([]string{"--config", configPath}, args...))
+		return code, out.String()
+	}
+	code, out := run("list", "clusters")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, out, "no current context")
+
+	code, _ = run("context", "set", "dev", "--server", "http://localhost:8080", "--customer-guid", "customer1")
+	require.Equal(t, 0, code)
+	code, _ = run("context", "set", "prod", "--server", "https://config.example.com", "--api-key", "csk_key")
+	require.Equal(t, 0, code)
+	code, _ = run("context", "set", "prod", "--server", "https://config2.example.com")
+	require.Equal(t, 0, code)
+	info, err := os.Stat(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "credentials are readable only by the user")
+	config, err := loadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "dev", config.CurrentContext, "the first context is the current context")
+	assert.Equal(t, &Context{Name: "prod", Server: "https://config2.example.com", APIKey: "csk_key"}, config.context("prod"), "only the given flags are changed")
+
+	code, _ = run("context", "use", "prod")
+	require.Equal(t, 0, code)
+	code, out = run("context", "list")
+	require.Equal(t, 0, code)
+	assert.Contains(t, out, "         dev   http://localhost:8080        login customer1")
+	assert.Contains(t, out, "*        prod  https://config2.example.com  api-key")
+	code, out = run("context", "use", "staging")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, out, "context staging not found")
+	code, _ = run("context", "delete", "prod")
+	require.Equal(t, 0, code)
+	config, _ = loadConfig(configPath)
+	assert.Equal(t, "", config.CurrentContext)
+	assert.Len(t, config.Contexts, 1)
+
+	code, out = run("context", "set")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, out, "usage: configctl context set")
+	code, _ = run("-o", "xml", "resources")
+	assert.Equal(t, 1, code)
+}
+
+// clustersStub - in memory v1 cluster routes
+type clustersStub struct {
+	sync.Mutex
+	clusters []document
+	nextGUID int
+}
+
+func (s *clustersStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+	respond := func(status int, body interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}
+	if r.Header.Get(consts.APIKeyHeader) != "csk_key" {
+		respond(http.StatusUnauthorized, map[string]interface{}{"status": 401, "code": "unauthorized", "detail": "Unauthorized"})
+		return
+	}
+	guid := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, consts.ClusterPath), "/")
+	index := -1
+	for i, doc := range s.clusters {
+		if guid != "" && doc.guid() == guid {
+			index = i
+		}
+	}
+	body, _ := io.ReadAll(r.Body)
+	switch {
+	case r.Method == http.MethodGet && guid == "":
+		if len(s.clusters) == 0 {
+			respond(http.StatusNotFound, map[string]interface{}{"status": 404, "code": "not_found", "detail": "document not found"})
+			return
+		}
+		respond(http.StatusOK, s.clusters)
+	case r.Method == http.MethodPost:
+		docs := []document{}
+		if body[0] == '{' {
+			doc := document{}
+			json.Unmarshal(body, &doc)
+			docs = append(docs, doc)
+		} else {
+			json.Unmarshal(body, &docs)
+		}
+		for _, doc := range docs {
+			s.nextGUID++
+			doc["guid"] = fmt.Sprintf("guid%d", s.nextGUID)
+			s.clusters = append(s.clusters, doc)
+		}
+		if len(docs) == 1 {
+			respond(http.StatusCreated, docs[0])
+		} else {
+			respond(http.StatusCreated, docs)
+		}
+	case index == -1:
+		respond(http.StatusNotFound, map[string]interface{}{"status": 404, "code": "not_found", "detail": "document not found"})
+	case r.Method == http.MethodPut:
+		updated := document{}
+		json.Unmarshal(body, &updated)
+		old := s.clusters[index]
+		s.clusters[index] = updated
+		respond(http.StatusOK, []document{old, updated})
+	case r.Method == http.MethodDelete:
+		deleted := s.clusters[index]
+		s.clusters = append(s.clusters[:index], s.clusters[index+1:]...)
+		respond(http.StatusOK, deleted)
+	}
+}
+
+func TestApply(t *testing.T) {
+	stub := &clustersStub{clusters: []document{{"name": "removed", "guid": "guid0"}}}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	run := func(stdin string, args ...string) (int, string) {
+		out := &bytes.Buffer{}
+		c := &cli{stdin: strings.NewReader(stdin), stdout: out, stderr: out}
+		code := c.run(context.Background(), append([]string{"--config", configPath}, args...))
+		return code, out.String()
+	}
+	code, _ := run("", "context", "set", "test", "--server", server.URL, "--api-key", "csk_key")
+	require.Equal(t, 0, code)
+
+	manifests := filepath.Join(dir, "manifests")
+	writeFile(t, filepath.Join(manifests, "clusters.yaml"), `
+kind: clusters
+items:
+  - name: cluster1
+    attributes: {alias: CLUSTER1}
+  - name: cluster2
+`)
+	code, out := run("", "diff", "-f", manifests)
+	assert.Equal(t, 1, code, "diff exits with 1 when there are changes")
+	assert.Contains(t, out, "  + create clusters/cluster1\n")
+	//the indentation of cmp diffs is randomized
+	assert.Contains(t, out, `"attributes": map[string]any{"alias": string("CLUSTER1")},`)
+	assert.Contains(t, out, "  - delete clusters/removed\n")
+
+	code, out = run("n\n", "apply", "-f", manifests)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, out, "Plan: 2 to create, 0 to update, 1 to delete.\nApply the plan? [y/N] Apply canceled.")
+	assert.Len(t, stub.clusters, 1, "nothing is applied")
+
+	code, out = run("y\n", "apply", "-f", manifests)
+	assert.Equal(t, 0, code, out)
+	assert.Contains(t, out, "clusters/cluster1 created\nclusters/cluster2 created\nclusters/removed deleted\n")
+	require.Len(t, stub.clusters, 2)
+
+	code, out = run("", "diff", "-f", manifests)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "No changes.\n", out)
+
+	//update of a file does not delete
+	writeFile(t, filepath.Join(dir, "cluster1.yaml"), "kind: clusters\nitems: [{name: cluster1, attributes: {alias: PROD}}]")
+	code, out = run("", "apply", "-f", filepath.Join(dir, "cluster1.yaml"), "--yes")
+	assert.Equal(t, 0, code, out)
+	assert.Equal(t, "  ~ update clusters/cluster1: attributes\nPlan: 0 to create, 1 to update, 0 to delete.\nclusters/cluster1 updated\n", out)
+	assert.Equal(t, document{"name": "cluster1", "guid": "guid1", "attributes": map[string]interface{}{"alias": "PROD"}}, stub.clusters[0])
+
+	code, out = run("", "-o", "json", "get", "cluster", "cluster1")
+	assert.Equal(t, 0, code, out)
+	assert.Contains(t, out, `"alias": "PROD"`)
+	code, out = run("", "list", "clusters")
+	assert.Equal(t, 0, code, out)
+	assert.Contains(t, out, "NAME      GUID   UPDATED\ncluster1  guid1")
+
+	//export and import
+	exported := filepath.Join(dir, "exported")
+	code, out = run("", "export", "-d", exported, "clusters")
+	assert.Equal(t, 0, code, out)
+	data, err := os.ReadFile(filepath.Join(exported, "clusters.yaml"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "guid")
+	code, out = run("", "delete", "clusters", "cluster2")
+	assert.Equal(t, 0, code, out)
+	code, out = run("", "import", "-f", exported)
+	assert.Equal(t, 0, code, out)
+	assert.Equal(t, "clusters/cluster1 exists, skipped\nclusters/cluster2 created\n", out)
+
+	code, out = run("", "delete", "clusters", "no-such-cluster")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, out, "clusters no-such-cluster not found")
+	code, out = run("", "--context", "test", "list", "nodes")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, out, "unknown resource nodes")
+}
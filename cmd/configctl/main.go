@@ -0,0 +1,454 @@
+// configctl is a command line tool to inspect and change the configuration documents of a customer in the config service
+package main
+
+import (
+	"bufio"
+	"config-service/client"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMinBackoff = 500 * time.Millisecond
+	defaultMaxBackoff = 10 * time.Second
+)
+
+const usage = `configctl - inspect and change the configuration documents of the config service
+
+Usage:
+  configctl [--context <name>] [--config <file>] [-o table|json|yaml] <command> [args]
+
+Commands:
+  list <resource> [-q <field>.<key>=<value>]...   list the documents, optionally by a scope query
+  get <resource> <name|guid>...                   get documents by name or guid
+  delete <resource> <name|guid>...                delete documents by name or guid
+  apply -f <file|dir|-> [--dry-run] [--yes]       create and update the documents of the manifests,
+                                                  a directory also deletes the documents of its resources that are not in it
+  diff -f <file|dir|->                            show the changes apply would make, exits with 1 when there are changes
+  export [-d <dir>] [<resource>...]               write the manifests of the documents, one file per resource in a directory
+  import -f <file|dir|-> [--dry-run]              create the documents of the manifests that do not exist
+  context list|use|set|delete                     manage the contexts of the config file
+  resources                                       list the resources
+
+Contexts in the config file (default ~/.config/configctl/config.yaml or $CONFIGCTL_CONFIG) select the service and the credentials:
+  configctl context set prod --server https://config.example.com --api-key csk_...
+`
+
+// cli - the command line tool with its streams and global flags
+type cli struct {
+	stdin       io.Reader
+	stdout      io.Writer
+	stderr      io.Writer
+	httpClient  *http.Client
+	configPath  string
+	contextName string
+	output      string
+}
+
+// usageError - error of a wrong usage of a command
+type usageError string
+
+func (e usageError) Error() string {
+	return "usage: configctl " + string(e)
+}
+
+// errChanges - diff found changes
+var errChanges = errors.New("changes found")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	c := &cli{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	os.Exit(c.run(ctx, os.Args[1:]))
+}
+
+// run runs the command of the args and returns the exit code, 2 for usage errors
+func (cli *cli) run(ctx context.Context, args []string) int {
+	flags := newFlagSet("configctl", cli.stderr)
+	flags.StringVar(&cli.contextName, "context", "", "context of the config file, the current context when empty")
+	flags.StringVar(&cli.configPath, "config", defaultConfigPath(), "config file of the contexts")
+	flags.StringVar(&cli.output, "o", outputTable, "output format: table, json or yaml")
+	flags.Usage = func() { fmt.Fprint(cli.stderr, usage) }
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprint(cli.stderr, usage)
+		return 2
+	}
+	err := validOutput(cli.output)
+	if err == nil {
+		err = cli.runCommand(ctx, flags.Arg(0), flags.Args()[1:])
+	}
+	var usageErr usageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errChanges):
+		return 1
+	case errors.As(err, &usageErr), errors.Is(err, flag.ErrHelp):
+		fmt.Fprintln(cli.stderr, err)
+		return 2
+	}
+	fmt.Fprintln(cli.stderr, "error:", err)
+	return 1
+}
+
+func (cli *cli) runCommand(ctx context.Context, command string, args []string) error {
+	switch command {
+	case "list":
+		return cli.runList(ctx, args)
+	case "get":
+		return cli.runGet(ctx, args)
+	case "delete":
+		return cli.runDelete(ctx, args)
+	case "apply":
+		return cli.runApply(ctx, args, false)
+	case "diff":
+		return cli.runApply(ctx, args, true)
+	case "export":
+		return cli.runExport(ctx, args)
+	case "import":
+		return cli.runImport(ctx, args)
+	case "context":
+		return cli.runContext(args)
+	case "resources":
+		for _, res := range resources {
+			fmt.Fprintf(cli.stdout, "%s\t%s\t%s\n", res.name, res.path, strings.Join(res.aliases, ","))
+		}
+		return nil
+	case "help":
+		fmt.Fprint(cli.stdout, usage)
+		return nil
+	}
+	return usageError(fmt.Sprintf("unknown command %s, run configctl help", command))
+}
+
+// client returns the client of the context
+func (cli *cli) client(ctx context.Context) (*client.Client, error) {
+	config, err := loadConfig(cli.configPath)
+	if err != nil {
+		return nil, err
+	}
+	return config.newClient(ctx, cli.contextName, cli.httpClient)
+}
+
+func (cli *cli) runList(ctx context.Context, args []string) error {
+	flags := newFlagSet("list", cli.stderr)
+	queries := stringsFlag{}
+	flags.Var(&queries, "q", "scope query param <field>.<key>=<value>, values of the same param are ORed")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError("list <resource> [-q <field>.<key>=<value>]...")
+	}
+	res, err := findResource(positional[0])
+	if err != nil {
+		return err
+	}
+	var query *client.Query
+	for _, q := range queries {
+		key, value, ok := strings.Cut(q, "=")
+		if !ok {
+			return usageError("list <resource> [-q <field>.<key>=<value>]...")
+		}
+		if query == nil {
+			query = client.NewQuery()
+		}
+		query.Key(key, value)
+	}
+	c, err := cli.client(ctx)
+	if err != nil {
+		return err
+	}
+	docs, err := res.documents(c).list(ctx, query)
+	if err != nil {
+		return err
+	}
+	return printDocuments(cli.stdout, cli.output, docs)
+}
+
+func (cli *cli) runGet(ctx context.Context, args []string) error {
+	if len(args) < 2 {
+		return usageError("get <resource> <name|guid>...")
+	}
+	c, err := cli.client(ctx)
+	if err != nil {
+		return err
+	}
+	_, docs, err := findDocuments(ctx, c, args[0], args[1:])
+	if err != nil {
+		return err
+	}
+	if len(docs) == 1 && cli.output != outputTable {
+		return printValue(cli.stdout, cli.output, docs[0])
+	}
+	return printDocuments(cli.stdout, cli.output, docs)
+}
+
+func (cli *cli) runDelete(ctx context.Context, args []string) error {
+	if len(args) < 2 {
+		return usageError("delete <resource> <name|guid>...")
+	}
+	c, err := cli.client(ctx)
+	if err != nil {
+		return err
+	}
+	res, docs, err := findDocuments(ctx, c, args[0], args[1:])
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		if err := res.documents(c).delete(ctx, doc); err != nil {
+			return fmt.Errorf("failed to delete %s/%s: %w", res.name, doc.name(), err)
+		}
+		fmt.Fprintf(cli.stdout, "%s/%s deleted\n", res.name, doc.name())
+	}
+	return nil
+}
+
+// findDocuments returns the documents of the resource with the names or guids, it fails when one of them is not found
+func findDocuments(ctx context.Context, c *client.Client, resourceName string, namesOrGUIDs []string) (*resource, []document, error) {
+	res, err := findResource(resourceName)
+	if err != nil {
+		return nil, nil, err
+	}
+	all, err := res.documents(c).list(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	found := []document{}
+	for _, nameOrGUID := range namesOrGUIDs {
+		var match document
+		for _, doc := range all {
+			if doc.guid() == nameOrGUID || doc.name() == nameOrGUID {
+				match = doc
+				break
+			}
+		}
+		if match == nil {
+			return nil, nil, fmt.Errorf("%s %s not found", res.name, nameOrGUID)
+		}
+		found = append(found, match)
+	}
+	return res, found, nil
+}
+
+// runApply shows the plan of the manifests and applies it after a confirmation, or shows the plan with the differences when diffOnly is true
+func (cli *cli) runApply(ctx context.Context, args []string, diffOnly bool) error {
+	flags := newFlagSet("apply", cli.stderr)
+	path := flags.String("f", "", "manifests file or directory, - for the stdin")
+	dryRun, yes := false, false
+	if !diffOnly {
+		flags.BoolVar(&dryRun, "dry-run", false, "show the plan without applying it")
+		flags.BoolVar(&yes, "yes", false, "apply the plan without a confirmation")
+	}
+	if positional, err := parseFlags(flags, args); err != nil {
+		return err
+	} else if *path == "" || len(positional) > 0 {
+		if diffOnly {
+			return usageError("diff -f <file|dir|->")
+		}
+		return usageError("apply -f <file|dir|-> [--dry-run] [--yes]")
+	}
+	manifests, isDir, err := readManifests(*path, cli.stdin)
+	if err != nil {
+		return err
+	}
+	c, err := cli.client(ctx)
+	if err != nil {
+		return err
+	}
+	changes := []*change{}
+	for _, m := range manifests {
+		remote, err := m.resource.documents(c).list(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", m.resource.name, err)
+		}
+		//a directory has all the documents of its resources
+		resourceChanges, err := planChanges(m.resource, m.docs, remote, isDir)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, resourceChanges...)
+	}
+	printPlan(cli.stdout, changes, diffOnly)
+	if diffOnly {
+		if len(changes) > 0 {
+			return errChanges
+		}
+		return nil
+	}
+	if len(changes) == 0 || dryRun {
+		return nil
+	}
+	if !yes {
+		if *path == "-" {
+			return fmt.Errorf("manifests from the stdin are applied with --yes")
+		}
+		fmt.Fprint(cli.stdout, "Apply the plan? [y/N] ")
+		answer, _ := bufio.NewReader(cli.stdin).ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			fmt.Fprintln(cli.stdout, "Apply canceled.")
+			return nil
+		}
+	}
+	return executePlan(ctx, cli.stdout, func(res *resource) documents { return res.documents(c) }, changes)
+}
+
+func (cli *cli) runExport(ctx context.Context, args []string) error {
+	flags := newFlagSet("export", cli.stderr)
+	dir := flags.String("d", "", "directory of the manifests files, the stdout when empty")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	exported := resources
+	if len(positional) > 0 {
+		exported = []*resource{}
+		for _, name := range positional {
+			res, err := findResource(name)
+			if err != nil {
+				return err
+			}
+			exported = append(exported, res)
+		}
+	}
+	output := cli.output
+	if output == outputTable {
+		output = outputYAML
+	}
+	c, err := cli.client(ctx)
+	if err != nil {
+		return err
+	}
+	if *dir != "" {
+		if err := os.MkdirAll(*dir, 0755); err != nil {
+			return err
+		}
+	}
+	for i, res := range exported {
+		docs, err := res.documents(c).list(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", res.name, err)
+		}
+		m := exportManifest(res, docs)
+		if *dir == "" {
+			if i > 0 && output == outputYAML {
+				fmt.Fprintln(cli.stdout, "---")
+			}
+			if err := printValue(cli.stdout, output, m); err != nil {
+				return err
+			}
+			continue
+		}
+		file, err := os.Create(filepath.Join(*dir, res.name+"."+output))
+		if err != nil {
+			return err
+		}
+		err = printValue(file, output, m)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cli.stderr, "%d %s exported to %s\n", len(m.Items), res.name, file.Name())
+	}
+	return nil
+}
+
+// runImport creates the documents of the manifests that do not exist, existing documents are not changed
+func (cli *cli) runImport(ctx context.Context, args []string) error {
+	flags := newFlagSet("import", cli.stderr)
+	path := flags.String("f", "", "manifests file or directory, - for the stdin")
+	dryRun := flags.Bool("dry-run", false, "show the documents to create without creating them")
+	if positional, err := parseFlags(flags, args); err != nil {
+		return err
+	} else if *path == "" || len(positional) > 0 {
+		return usageError("import -f <file|dir|-> [--dry-run]")
+	}
+	manifests, _, err := readManifests(*path, cli.stdin)
+	if err != nil {
+		return err
+	}
+	c, err := cli.client(ctx)
+	if err != nil {
+		return err
+	}
+	changes := []*change{}
+	for _, m := range manifests {
+		remote, err := m.resource.documents(c).list(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", m.resource.name, err)
+		}
+		resourceChanges, err := planChanges(m.resource, m.docs, remote, false)
+		if err != nil {
+			return err
+		}
+		created := map[string]bool{}
+		for _, ch := range resourceChanges {
+			if ch.action == actionCreate {
+				changes = append(changes, ch)
+				created[ch.name()] = true
+			}
+		}
+		for _, doc := range m.docs {
+			if !created[doc.name()] {
+				fmt.Fprintf(cli.stderr, "%s/%s exists, skipped\n", m.resource.name, doc.name())
+			}
+		}
+	}
+	if *dryRun {
+		printPlan(cli.stdout, changes, false)
+		return nil
+	}
+	return executePlan(ctx, cli.stdout, func(res *resource) documents { return res.documents(c) }, changes)
+}
+
+func newFlagSet(name string, output io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(output)
+	return flags
+}
+
+// parseFlags parses the flags that are before and after the positional args
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// stringsFlag - flag that can be repeated
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// manifest - documents of a resource in a file, files have one or more manifests (YAML documents separated by ---)
+//
+//	kind: postureExceptionPolicies
+//	items:
+//	  - name: exception1
+//	    ...
+type manifest struct {
+	Kind  string     `json:"kind"`
+	Items []document `json:"items"`
+}
+
+// resourceDocuments - documents of a resource read from manifests
+type resourceDocuments struct {
+	resource *resource
+	docs     []document
+}
+
+// fields that are set by the service, they are not exported and not compared
+var serverFields = []string{"guid", "creationTime", "updatedTime"}
+
+func isServerField(field string) bool {
+	for _, serverField := range serverFields {
+		if field == serverField {
+			return true
+		}
+	}
+	return false
+}
+
+// readManifests reads the manifests of a file, of the .yaml, .yml and .json files in a directory or of the stdin when the path is -,
+// the documents are returned in the order of the resources table
+func readManifests(path string, stdin io.Reader) ([]*resourceDocuments, bool, error) {
+	files := map[string][]byte{}
+	isDir := false
+	if path == "-" {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, false, err
+		}
+		files[path] = data
+	} else if info, err := os.Stat(path); err != nil {
+		return nil, false, err
+	} else if info.IsDir() {
+		isDir = true
+		err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return err
+			}
+			switch strings.ToLower(filepath.Ext(file)) {
+			case ".yaml", ".yml", ".json":
+				data, err := os.ReadFile(file)
+				if err != nil {
+					return err
+				}
+				files[file] = data
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, false, err
+		}
+	} else {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, false, err
+		}
+		files[path] = data
+	}
+
+	docsByResource := map[*resource][]document{}
+	for file, data := range files {
+		manifests, err := decodeManifests(data)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %w", file, err)
+		}
+		for _, m := range manifests {
+			res, err := findResource(m.Kind)
+			if err != nil {
+				return nil, false, fmt.Errorf("%s: %w", file, err)
+			}
+			docsByResource[res] = append(docsByResource[res], m.Items...)
+		}
+	}
+	result := []*resourceDocuments{}
+	for _, res := range resources {
+		if docs, ok := docsByResource[res]; ok {
+			result = append(result, &resourceDocuments{resource: res, docs: docs})
+		}
+	}
+	return result, isDir, nil
+}
+
+// decodeManifests decodes the YAML (or JSON) manifests of a file
+func decodeManifests(data []byte) ([]*manifest, error) {
+	manifests := []*manifest{}
+	decoder := yamlv3.NewDecoder(bytes.NewReader(data))
+	for {
+		var value interface{}
+		if err := decoder.Decode(&value); errors.Is(err, io.EOF) {
+			return manifests, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			continue
+		}
+		//the documents are decoded as the service decodes json values
+		jsonData, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		m := &manifest{}
+		if err := json.Unmarshal(jsonData, m); err != nil {
+			return nil, fmt.Errorf("invalid manifest: %w", err)
+		}
+		if m.Kind == "" {
+			return nil, fmt.Errorf("manifest without kind")
+		}
+		for i, doc := range m.Items {
+			if doc.name() == "" {
+				return nil, fmt.Errorf("%s document %d has no name", m.Kind, i)
+			}
+		}
+		manifests = append(manifests, m)
+	}
+}
+
+// exportManifest returns the manifest of the documents of the resource without the fields set by the service, the write only fields and the protected documents
+func exportManifest(res *resource, docs []document) *manifest {
+	m := &manifest{Kind: res.name, Items: []document{}}
+	for _, doc := range docs {
+		if res.isProtected(doc) {
+			continue
+		}
+		exported := document{}
+		for key, value := range doc {
+			if !isServerField(key) && !res.isWriteOnly(key) {
+				exported[key] = value
+			}
+		}
+		m.Items = append(m.Items, exported)
+	}
+	return m
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+func validOutput(output string) error {
+	switch output {
+	case outputTable, outputJSON, outputYAML:
+		return nil
+	}
+	return fmt.Errorf("unknown output %s, the outputs are table, json and yaml", output)
+}
+
+// printDocuments prints the documents as a table of names, guids and update times, or as json or yaml values
+func printDocuments(w io.Writer, output string, docs []document) error {
+	switch output {
+	case outputJSON, outputYAML:
+		return printValue(w, output, docs)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tGUID\tUPDATED")
+	for _, doc := range docs {
+		updated, _ := doc["updatedTime"].(string)
+		if updated == "" {
+			updated, _ = doc["creationTime"].(string)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", doc.name(), doc.guid(), updated)
+	}
+	return tw.Flush()
+}
+
+// printValue prints the value as indented json or as yaml
+func printValue(w io.Writer, output string, value interface{}) error {
+	var data []byte
+	var err error
+	if output == outputJSON {
+		if data, err = json.MarshalIndent(value, "", "  "); err == nil {
+			data = append(data, '\n')
+		}
+	} else {
+		data, err = yaml.Marshal(value)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
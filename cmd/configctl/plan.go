@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type action string
+
+const (
+	actionCreate action = "create"
+	actionUpdate action = "update"
+	actionDelete action = "delete"
+)
+
+// change - a change of the plan of apply
+type change struct {
+	action   action
+	resource *resource
+	local    document //the document in the manifests, nil in deletes
+	remote   document //the document in the service, nil in creates
+	fields   []string //the changed fields of updates
+}
+
+func (ch *change) name() string {
+	if ch.local != nil {
+		return ch.local.name()
+	}
+	return ch.remote.name()
+}
+
+// planChanges returns the changes that make the documents of the resource in the service as in the manifests,
+// documents are matched by guid when the manifest has one and by name otherwise, unmatched documents of the service are deleted when prune is true
+func planChanges(res *resource, local, remote []document, prune bool) ([]*change, error) {
+	changes := []*change{}
+	matched := map[int]bool{}
+	names := map[string]bool{}
+	for _, doc := range local {
+		if names[doc.name()] {
+			return nil, fmt.Errorf("%s %s is duplicated in the manifests", res.name, doc.name())
+		}
+		names[doc.name()] = true
+		index := -1
+		for i, remoteDoc := range remote {
+			if (doc.guid() != "" && doc.guid() == remoteDoc.guid()) || (doc.guid() == "" && doc.name() == remoteDoc.name()) {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			changes = append(changes, &change{action: actionCreate, resource: res, local: doc})
+			continue
+		}
+		matched[index] = true
+		if fields := changedFields(res, doc, remote[index]); len(fields) > 0 {
+			changes = append(changes, &change{action: actionUpdate, resource: res, local: doc, remote: remote[index], fields: fields})
+		}
+	}
+	if prune {
+		for i, doc := range remote {
+			if !matched[i] && !res.isProtected(doc) {
+				changes = append(changes, &change{action: actionDelete, resource: res, remote: doc})
+			}
+		}
+	}
+	return changes, nil
+}
+
+// changedFields returns the fields of the manifest document that are different in the service document
+func changedFields(res *resource, local, remote document) []string {
+	fields := []string{}
+	for key, value := range local {
+		if isServerField(key) || res.isWriteOnly(key) {
+			continue
+		}
+		if !reflect.DeepEqual(value, remote[key]) {
+			fields = append(fields, key)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// updatedDocument returns the service document with the fields of the manifest document
+func (ch *change) updatedDocument() document {
+	updated := document{}
+	for key, value := range ch.remote {
+		updated[key] = value
+	}
+	for key, value := range ch.local {
+		if !isServerField(key) {
+			updated[key] = value
+		}
+	}
+	return updated
+}
+
+// diff returns the differences of the change, - for the service document and + for the manifest document
+func (ch *change) diff() string {
+	from, to := document{}, document{}
+	switch ch.action {
+	case actionCreate:
+		to = exportManifest(ch.resource, []document{ch.local}).Items[0]
+	case actionDelete:
+		from = exportManifest(ch.resource, []document{ch.remote}).Items[0]
+	case actionUpdate:
+		for _, field := range ch.fields {
+			from[field] = ch.remote[field]
+			to[field] = ch.local[field]
+		}
+	}
+	return cmp.Diff(from, to)
+}
+
+// printPlan prints the changes and a summary, with the differences of each change when withDiff is true
+func printPlan(w io.Writer, changes []*change, withDiff bool) {
+	counts := map[action]int{}
+	for _, ch := range changes {
+		counts[ch.action]++
+		switch ch.action {
+		case actionCreate:
+			fmt.Fprintf(w, "  + create %s/%s\n", ch.resource.name, ch.name())
+		case actionUpdate:
+			fmt.Fprintf(w, "  ~ update %s/%s: %s\n", ch.resource.name, ch.name(), strings.Join(ch.fields, ", "))
+		case actionDelete:
+			fmt.Fprintf(w, "  - delete %s/%s\n", ch.resource.name, ch.name())
+		}
+		if withDiff {
+			for _, line := range strings.Split(strings.TrimRight(ch.diff(), "\n"), "\n") {
+				fmt.Fprintf(w, "      %s\n", line)
+			}
+		}
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "No changes.")
+		return
+	}
+	fmt.Fprintf(w, "Plan: %d to create, %d to update, %d to delete.\n", counts[actionCreate], counts[actionUpdate], counts[actionDelete])
+}
+
+// executePlan applies the changes, the creates of a resource are sent in one request
+func executePlan(ctx context.Context, w io.Writer, docsOf func(*resource) documents, changes []*change) error {
+	creates := map[*resource][]document{}
+	for _, ch := range changes {
+		if ch.action == actionCreate {
+			creates[ch.resource] = append(creates[ch.resource], ch.local)
+		}
+	}
+	for _, res := range resources {
+		if docs, ok := creates[res]; ok {
+			if _, err := docsOf(res).create(ctx, docs); err != nil {
+				return fmt.Errorf("failed to create %s: %w", res.name, err)
+			}
+			for _, doc := range docs {
+				fmt.Fprintf(w, "%s/%s created\n", res.name, doc.name())
+			}
+		}
+	}
+	for _, ch := range changes {
+		switch ch.action {
+		case actionUpdate:
+			if _, err := docsOf(ch.resource).update(ctx, ch.updatedDocument()); err != nil {
+				return fmt.Errorf("failed to update %s/%s: %w", ch.resource.name, ch.name(), err)
+			}
+			fmt.Fprintf(w, "%s/%s updated\n", ch.resource.name, ch.name())
+		case actionDelete:
+			if err := docsOf(ch.resource).delete(ctx, ch.remote); err != nil {
+				return fmt.Errorf("failed to delete %s/%s: %w", ch.resource.name, ch.name(), err)
+			}
+			fmt.Fprintf(w, "%s/%s deleted\n", ch.resource.name, ch.name())
+		}
+	}
+	return nil
+}
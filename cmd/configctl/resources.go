@@ -0,0 +1,244 @@
+package main
+
+import (
+	"config-service/client"
+	"config-service/types"
+	"config-service/utils/consts"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// document - json values of a document of any resource
+type document map[string]interface{}
+
+func (doc document) name() string {
+	name, _ := doc[consts.NameField].(string)
+	return name
+}
+
+func (doc document) guid() string {
+	guid, _ := doc[consts.GUIDField].(string)
+	return guid
+}
+
+// documents - CRUD of the documents of a resource
+type documents interface {
+	list(ctx context.Context, query *client.Query) ([]document, error)
+	create(ctx context.Context, docs []document) ([]document, error)
+	update(ctx context.Context, doc document) (document, error)
+	delete(ctx context.Context, doc document) error
+}
+
+// resource - a resource path of the service
+type resource struct {
+	name      string   //name of the resource in commands and in manifests
+	aliases   []string //other names of the resource in commands, matched case insensitive
+	path      string
+	protected []string //names of documents that are not exported and not deleted, e.g. global documents
+	writeOnly []string //fields that are not returned by the service and are not compared
+	documents func(c *client.Client) documents
+}
+
+// resources - the resource paths of the document types the service registers
+var resources = []*resource{
+	{
+		name:      "clusters",
+		aliases:   []string{"cluster"},
+		path:      consts.ClusterPath,
+		documents: typed(func(c *client.Client) *client.Documents[*types.Cluster] { return c.Clusters() }),
+	},
+	{
+		name:    "postureExceptionPolicies",
+		aliases: []string{"postureExceptionPolicy", "pep"},
+		path:    consts.PostureExceptionPolicyPath,
+		documents: typed(func(c *client.Client) *client.Documents[*types.PostureExceptionPolicy] {
+			return c.PostureExceptionPolicies()
+		}),
+	},
+	{
+		name:    "vulnerabilityExceptionPolicies",
+		aliases: []string{"vulnerabilityExceptionPolicy", "vep"},
+		path:    consts.VulnerabilityExceptionPolicyPath,
+		documents: typed(func(c *client.Client) *client.Documents[*types.VulnerabilityExceptionPolicy] {
+			return c.VulnerabilityExceptionPolicies()
+		}),
+	},
+	{
+		name:      "customerConfigs",
+		aliases:   []string{"customerConfig", "config", "configs"},
+		path:      consts.CustomerConfigPath,
+		protected: []string{consts.GlobalConfigName},
+		documents: func(c *client.Client) documents {
+			//customer configurations are deleted by name
+			return &typedDocuments[*types.CustomerConfig]{docs: c.CustomerConfigs(), deleteFunc: func(ctx context.Context, doc document) error {
+				_, err := c.DeleteConfig(ctx, doc.name())
+				return err
+			}}
+		},
+	},
+	{
+		name:      "frameworks",
+		aliases:   []string{"framework"},
+		path:      consts.FrameworkPath,
+		documents: typed(func(c *client.Client) *client.Documents[*types.Framework] { return c.Frameworks() }),
+	},
+	{
+		name:      "repositories",
+		aliases:   []string{"repository", "repo", "repos"},
+		path:      consts.RepositoryPath,
+		documents: typed(func(c *client.Client) *client.Documents[*types.Repository] { return c.Repositories() }),
+	},
+	{
+		name:      "registryCronJobs",
+		aliases:   []string{"registryCronJob"},
+		path:      consts.RegistryCronJobPath,
+		documents: typed(func(c *client.Client) *client.Documents[*types.RegistryCronJob] { return c.RegistryCronJobs() }),
+	},
+	{
+		name:      "webhooks",
+		aliases:   []string{"webhook", "webhookSubscriptions"},
+		path:      consts.WebhookPath,
+		writeOnly: []string{"secret"},
+		documents: typed(func(c *client.Client) *client.Documents[*types.WebhookSubscription] { return c.WebhookSubscriptions() }),
+	},
+}
+
+// findResource returns the resource with the name, alias or path
+func findResource(name string) (*resource, error) {
+	for _, res := range resources {
+		if strings.EqualFold(res.name, name) || res.path == name || res.path == "/"+name {
+			return res, nil
+		}
+		for _, alias := range res.aliases {
+			if strings.EqualFold(alias, name) {
+				return res, nil
+			}
+		}
+	}
+	names := []string{}
+	for _, res := range resources {
+		names = append(names, res.name)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("unknown resource %s, the resources are %s", name, strings.Join(names, ", "))
+}
+
+func (res *resource) isProtected(doc document) bool {
+	for _, name := range res.protected {
+		if doc.name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (res *resource) isWriteOnly(field string) bool {
+	for _, writeOnly := range res.writeOnly {
+		if field == writeOnly {
+			return true
+		}
+	}
+	return false
+}
+
+// typedDocuments - documents of type T of the client
+type typedDocuments[T types.DocContent] struct {
+	docs       *client.Documents[T]
+	deleteFunc func(ctx context.Context, doc document) error
+}
+
+func typed[T types.DocContent](docs func(c *client.Client) *client.Documents[T]) func(c *client.Client) documents {
+	return func(c *client.Client) documents {
+		return &typedDocuments[T]{docs: docs(c)}
+	}
+}
+
+func (t *typedDocuments[T]) list(ctx context.Context, query *client.Query) ([]document, error) {
+	var docs []T
+	var err error
+	if query != nil {
+		docs, err = t.docs.Query(ctx, query)
+	} else {
+		docs, err = t.docs.List(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toDocuments(docs)
+}
+
+func (t *typedDocuments[T]) create(ctx context.Context, docs []document) ([]document, error) {
+	typedDocs := make([]T, 0, len(docs))
+	for _, doc := range docs {
+		typedDoc, err := fromDocument[T](doc)
+		if err != nil {
+			return nil, err
+		}
+		typedDocs = append(typedDocs, typedDoc)
+	}
+	created, err := t.docs.Create(ctx, typedDocs...)
+	if err != nil {
+		return nil, err
+	}
+	return toDocuments(created)
+}
+
+func (t *typedDocuments[T]) update(ctx context.Context, doc document) (document, error) {
+	typedDoc, err := fromDocument[T](doc)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := t.docs.Update(ctx, typedDoc)
+	if err != nil {
+		return nil, err
+	}
+	return toDocument(updated)
+}
+
+func (t *typedDocuments[T]) delete(ctx context.Context, doc document) error {
+	if t.deleteFunc != nil {
+		return t.deleteFunc(ctx, doc)
+	}
+	_, err := t.docs.Delete(ctx, doc.guid())
+	return err
+}
+
+func toDocuments[T any](docs []T) ([]document, error) {
+	res := make([]document, 0, len(docs))
+	for _, doc := range docs {
+		converted, err := toDocument(doc)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, converted)
+	}
+	return res, nil
+}
+
+// toDocument returns the json values of the value
+func toDocument(v interface{}) (document, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	doc := document{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func fromDocument[T types.DocContent](doc document) (T, error) {
+	var typedDoc T
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return typedDoc, err
+	}
+	if err := json.Unmarshal(data, &typedDoc); err != nil {
+		return typedDoc, fmt.Errorf("invalid document %s: %w", doc.name(), err)
+	}
+	return typedDoc, nil
+}
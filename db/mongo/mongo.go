@@ -3,6 +3,7 @@ package mongo
 import (
 	"config-service/utils"
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 
@@ -17,6 +18,8 @@ import (
 
 var mongoDB, mongoDBprimary *mongo.Database
 
+var ErrTransactionsNotSupported = errors.New("transactions are not supported by the db deployment")
+
 func MustConnect(config utils.MongoConfig) {
 	if err := Connect(config); err != nil {
 		zap.L().Fatal("failed to connect to mongo", zap.Error(err))
@@ -115,6 +118,22 @@ func GetWriteCollection(collectionName string) *mongo.Collection {
 	return mongoDBprimary.Collection(collectionName)
 }
 
+// StartSession starts a session for transactions, transactions are supported when the service reads and writes with
+// the same client (no replicaSet in the configuration) and the db is a replica set or a sharded cluster
+func StartSession(c context.Context) (mongo.Session, error) {
+	if mongoDB == nil || mongoDB != mongoDBprimary {
+		return nil, ErrTransactionsNotSupported
+	}
+	var hello bson.M
+	if err := mongoDB.RunCommand(c, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return nil, err
+	}
+	if _, isReplicaSet := hello["setName"]; !isReplicaSet && hello["msg"] != "isdbgrid" {
+		return nil, ErrTransactionsNotSupported
+	}
+	return mongoDB.Client().StartSession()
+}
+
 func ListCollectionNames(c context.Context) ([]string, error) {
 	return mongoDB.ListCollectionNames(c, bson.D{}, options.ListCollections().SetAuthorizedCollections(true).SetNameOnly(true))
 }
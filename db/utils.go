@@ -28,8 +28,38 @@ func GetAllForCustomer[T any](c context.Context, includeGlobals bool) ([]T, erro
 // GetAllForCustomerWithProjection returns all docs for customer with projection
 func GetAllForCustomerWithProjection[T any](c context.Context, projection bson.D, includeGlobals bool) ([]T, error) {
 	defer log.LogNTraceEnterExit("GetAllForCustomerWithProjection", c)()
-	collection, _, err := ReadContext(c)
 	result := []T{}
+	if cur, err := findAllForCustomer(c, projection, includeGlobals); err != nil {
+		return nil, err
+	} else if err := cur.All(c, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// StreamAllForCustomer calls onDoc for each of the customer's docs as they are read from the cursor without buffering all of them,
+// stops on the first error of onDoc
+func StreamAllForCustomer[T any](c context.Context, projection bson.D, includeGlobals bool, onDoc func(doc T) error) error {
+	defer log.LogNTraceEnterExit("StreamAllForCustomer", c)()
+	cur, err := findAllForCustomer(c, projection, includeGlobals)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(context.Background())
+	for cur.Next(c) {
+		var doc T
+		if err := cur.Decode(&doc); err != nil {
+			return err
+		}
+		if err := onDoc(doc); err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
+func findAllForCustomer(c context.Context, projection bson.D, includeGlobals bool) (*mongoDB.Cursor, error) {
+	collection, _, err := ReadContext(c)
 	if err != nil {
 		return nil, err
 	}
@@ -39,18 +69,11 @@ func GetAllForCustomerWithProjection[T any](c context.Context, projection bson.D
 	} else {
 		fb.WithNotDeleteForCustomer(c)
 	}
-	filter := fb.Get()
 	findOpts := options.Find().SetNoCursorTimeout(true)
 	if projection != nil {
 		findOpts.SetProjection(projection)
 	}
-	if cur, err := mongo.GetReadCollection(collection).
-		Find(c, filter, findOpts); err != nil {
-		return nil, err
-	} else if err := cur.All(c, &result); err != nil {
-		return nil, err
-	}
-	return result, nil
+	return mongo.GetReadCollection(collection).Find(c, fb.Get(), findOpts)
 }
 
 func FindForCustomer[T any](c context.Context, filterBuilder *FilterBuilder, projection bson.D) ([]T, error) {
@@ -289,9 +312,15 @@ func InsertDocuments[T types.DocContent](c context.Context, docs []T) ([]T, erro
 	if err != nil {
 		return nil, err
 	}
+	//client supplied GUIDs are kept when the route allows them
+	clientGUIDs, _ := c.Value(consts.ClientGUIDs).(bool)
 	dbDocs := []interface{}{}
 	for i := range docs {
-		dbDocs = append(dbDocs, types.NewDocument(docs[i], customerGUID))
+		if clientGUIDs {
+			dbDocs = append(dbDocs, types.NewDocumentWithGUID(docs[i], customerGUID, docs[i].GetGUID()))
+		} else {
+			dbDocs = append(dbDocs, types.NewDocument(docs[i], customerGUID))
+		}
 	}
 
 	if len(dbDocs) == 1 {
@@ -394,41 +423,18 @@ func AdminDeleteCustomersDocs(c context.Context, customerGUIDs ...string) (delet
 		}
 	}()
 
-	//delete the customers themselves
+	//delete the customers themselves and all the customers docs in all collections
 	wg := sync.WaitGroup{}
-	wg.Add(1)
-	go func(customerGUIDs []string) {
-		defer wg.Done()
-		idsFilter := NewFilterBuilder().WithIDs(customerGUIDs)
-		res, err := mongo.GetWriteCollection(consts.CustomersCollection).DeleteMany(c, idsFilter.Get())
-		if err != nil {
-			errChanel <- err
-		}
-		if res != nil {
-			atomic.AddInt64(&deletedCount, res.DeletedCount)
-		}
-	}(customerGUIDs)
-
-	//delete all the customers docs in all collections
-	ownersFilter := NewFilterBuilder().WithCustomers(customerGUIDs)
 	for _, collection := range collections {
-		if collection == consts.CustomersCollection {
-			continue
-		}
 		wg.Add(1)
 		go func(collection string, customerGUIDs []string) {
 			defer wg.Done()
-			res, err := mongo.GetWriteCollection(collection).DeleteMany(c, ownersFilter.Get())
+			deleted, err := AdminDeleteCustomersDocsInCollection(c, collection, customerGUIDs...)
 			if err != nil {
-				log.LogNTraceError(fmt.Sprintf("AdminDeleteAllCustomerDocs errors when deleting documents in collection:%s", collection), err, c)
 				errChanel <- err
 			}
-			if res != nil {
-				atomic.AddInt64(&deletedCount, res.DeletedCount)
-				log.LogNTrace(fmt.Sprintf("AdminDeleteAllCustomerDocs deleted %d documents in collection:%s", res.DeletedCount, collection), c)
-			}
+			atomic.AddInt64(&deletedCount, deleted)
 		}(collection, customerGUIDs)
-
 	}
 	wg.Wait()
 	close(errChanel)
@@ -436,6 +442,24 @@ func AdminDeleteCustomersDocs(c context.Context, customerGUIDs ...string) (delet
 	return atomic.LoadInt64(&deletedCount), deletionErrs
 }
 
+// AdminDeleteCustomersDocsInCollection deletes the customers documents in a single collection
+// in the customers collection the customers documents themselves are deleted
+func AdminDeleteCustomersDocsInCollection(c context.Context, collection string, customerGUIDs ...string) (deletedCount int64, err error) {
+	filter := NewFilterBuilder().WithCustomers(customerGUIDs)
+	if collection == consts.CustomersCollection {
+		filter = NewFilterBuilder().WithIDs(customerGUIDs)
+	}
+	res, err := mongo.GetWriteCollection(collection).DeleteMany(c, filter.Get())
+	if err != nil {
+		log.LogNTraceError(fmt.Sprintf("AdminDeleteAllCustomerDocs errors when deleting documents in collection:%s", collection), err, c)
+	}
+	if res != nil {
+		deletedCount = res.DeletedCount
+		log.LogNTrace(fmt.Sprintf("AdminDeleteAllCustomerDocs deleted %d documents in collection:%s", res.DeletedCount, collection), c)
+	}
+	return deletedCount, err
+}
+
 // helpers
 
 // ReadContext reads collection and customerGUID from context
@@ -0,0 +1,192 @@
+package grpcserver
+
+import (
+	"config-service/auth"
+	"config-service/handlers"
+	"config-service/utils/consts"
+	"config-service/utils/log"
+	"context"
+	"crypto/tls"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// calls are authenticated like REST requests, by the metadata keys of the REST headers:
+// "authorization: Bearer <JWT>", "x-api-key: <API key>" or "x-customer-guid: <customer>" of a trusted service with a verified client certificate
+
+// REST paths of the documents of each method, API keys need a scope of the path and users need the role permission to read the path
+var methodPaths = map[string]string{
+	"/configservice.v1.ConfigService/GetClusterConfig":                             consts.CustomerConfigPath,
+	"/configservice.v1.ConfigService/WatchClusterConfig":                           consts.CustomerConfigPath,
+	"/configservice.v1.ExceptionPolicyService/ListPostureExceptionPolicies":        consts.PostureExceptionPolicyPath,
+	"/configservice.v1.ExceptionPolicyService/MatchPostureExceptionPolicies":       consts.PostureExceptionPolicyPath,
+	"/configservice.v1.ExceptionPolicyService/WatchPostureExceptionPolicies":       consts.PostureExceptionPolicyPath,
+	"/configservice.v1.ExceptionPolicyService/ListVulnerabilityExceptionPolicies":  consts.VulnerabilityExceptionPolicyPath,
+	"/configservice.v1.ExceptionPolicyService/MatchVulnerabilityExceptionPolicies": consts.VulnerabilityExceptionPolicyPath,
+	"/configservice.v1.ExceptionPolicyService/WatchVulnerabilityExceptionPolicies": consts.VulnerabilityExceptionPolicyPath,
+	"/configservice.v1.FrameworkService/GetFramework":                              consts.FrameworkPath,
+	"/configservice.v1.FrameworkService/ListFrameworks":                            consts.FrameworkPath,
+}
+
+// reflection describes the services only, it is served without authentication when enabled
+const reflectionServicePrefix = "/grpc.reflection."
+
+// requestContext - context of a call with the values that the db and log packages read by their string keys from the gin context of REST requests
+type requestContext struct {
+	context.Context
+	values map[string]interface{}
+}
+
+func (c *requestContext) Value(key interface{}) interface{} {
+	if keyAsString, ok := key.(string); ok {
+		if value, exists := c.values[keyAsString]; exists {
+			return value
+		}
+	}
+	return c.Context.Value(key)
+}
+
+// withCollection returns a context of the call for the db functions of the collection documents
+func withCollection(ctx context.Context, collection string) context.Context {
+	return &requestContext{Context: ctx, values: map[string]interface{}{consts.Collection: collection}}
+}
+
+func unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer recoverCall(info.FullMethod, &err)
+	if ctx, err = authenticate(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func streamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer recoverCall(info.FullMethod, &err)
+	ctx, err := authenticate(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+}
+
+// serverStream - server stream with the authenticated context
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// recoverCall responds with an internal error to calls that panic
+func recoverCall(fullMethod string, err *error) {
+	if r := recover(); r != nil {
+		zap.L().Error("gRPC call panic", zap.String("method", fullMethod), zap.Any("panic", r), zap.Stack("stack"))
+		*err = status.Error(codes.Internal, "internal error")
+	}
+}
+
+// authenticate returns the context of the call with the identity values or an error when the identity is not allowed to call the method
+func authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	if strings.HasPrefix(fullMethod, reflectionServicePrefix) {
+		return ctx, nil
+	}
+	path, ok := methodPaths[fullMethod]
+	if !ok {
+		return nil, status.Errorf(codes.PermissionDenied, "method %s is not allowed", fullMethod)
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	identity, err := identify(ctx, md)
+	if err != nil {
+		zap.L().Warn("gRPC call authentication failed", zap.String("method", fullMethod), zap.Error(err))
+	}
+	if identity == nil {
+		return nil, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+	values := map[string]interface{}{
+		consts.CustomerGUID: identity.CustomerGUID,
+		consts.AuthMethod:   identity.Method,
+		consts.ReqLogger:    zap.L().With(zap.String("method", fullMethod), zap.String(consts.CustomerGUID, identity.CustomerGUID)),
+	}
+	if identity.UserID != "" {
+		values[consts.UserID] = identity.UserID
+	}
+	if identity.Admin {
+		values[consts.AdminAccess] = true
+	}
+	callCtx := &requestContext{Context: ctx, values: values}
+	if err := authorize(callCtx, identity, path); err != nil {
+		return nil, err
+	}
+	return callCtx, nil
+}
+
+// identify returns the identity of the call metadata or nil when the call has no credentials
+func identify(ctx context.Context, md metadata.MD) (*auth.Identity, error) {
+	if customerGUID := metadataValue(md, consts.CustomerGUIDHeader); customerGUID != "" {
+		if tlsState := peerTLSState(ctx); tlsState != nil {
+			return auth.ServiceIdentity(tlsState, customerGUID)
+		}
+	}
+	if authorization := metadataValue(md, "authorization"); strings.HasPrefix(authorization, "Bearer ") {
+		return auth.ValidateBearerToken(strings.TrimPrefix(authorization, "Bearer "))
+	}
+	if apiKey := metadataValue(md, consts.APIKeyHeader); apiKey != "" {
+		return auth.ValidateAPIKey(ctx, apiKey)
+	}
+	return nil, nil
+}
+
+// authorize checks the API key scopes or the user role permission to read the path, admins are not checked
+func authorize(ctx context.Context, identity *auth.Identity, path string) error {
+	if identity.Admin {
+		return nil
+	}
+	if identity.Method == auth.MethodAPIKey {
+		if requiredScope, ok := handlers.HasScope(identity.Scopes, strings.TrimPrefix(path, "/"), http.MethodGet); !ok {
+			log.LogNTrace("API key is missing scope "+requiredScope, ctx)
+			return status.Error(codes.PermissionDenied, "API key is missing scope "+requiredScope)
+		}
+		return nil
+	}
+	role, err := auth.GetUserRole(ctx, identity.CustomerGUID, identity.UserID)
+	if err != nil {
+		return internalError(ctx, "failed to get user role", err)
+	}
+	if permission := auth.RequiredPermission(http.MethodGet, path); !auth.HasPermission(role, permission) {
+		log.LogNTrace("missing permission "+string(permission), ctx)
+		return status.Errorf(codes.PermissionDenied, "missing permission %s", permission)
+	}
+	return nil
+}
+
+func metadataValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// peerTLSState returns the TLS connection state of the caller or nil when the connection is not a TLS connection
+func peerTLSState(ctx context.Context) *tls.ConnectionState {
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			return &tlsInfo.State
+		}
+	}
+	return nil
+}
+
+// internalError logs the error and returns an internal error status without the error details
+func internalError(ctx context.Context, msg string, err error) error {
+	log.LogNTraceError(msg, err, ctx)
+	return status.Error(codes.Internal, msg)
+}
@@ -0,0 +1,53 @@
+package grpcserver
+
+import (
+	configservicev1 "config-service/api/configservice/v1"
+	"config-service/routes/v1/customer_config"
+	"config-service/utils/consts"
+	"config-service/utils/log"
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type configServer struct {
+	configservicev1.UnimplementedConfigServiceServer
+	watchInterval time.Duration
+}
+
+func (s *configServer) GetClusterConfig(ctx context.Context, req *configservicev1.GetClusterConfigRequest) (*configservicev1.Document, error) {
+	defer log.LogNTraceEnterExit("GetClusterConfig", ctx)()
+	if req.ClusterName == "" {
+		return nil, status.Error(codes.InvalidArgument, "cluster_name is required")
+	}
+	return clusterConfig(ctx, req.ClusterName)
+}
+
+func (s *configServer) WatchClusterConfig(req *configservicev1.GetClusterConfigRequest, stream configservicev1.ConfigService_WatchClusterConfigServer) error {
+	ctx := stream.Context()
+	defer log.LogNTraceEnterExit("WatchClusterConfig", ctx)()
+	if req.ClusterName == "" {
+		return status.Error(codes.InvalidArgument, "cluster_name is required")
+	}
+	return watch(ctx, s.watchInterval, func() (*configservicev1.Document, error) {
+		return clusterConfig(ctx, req.ClusterName)
+	}, stream.Send)
+}
+
+// clusterConfig returns the configuration of the cluster merged like the REST configuration of the cluster name
+func clusterConfig(ctx context.Context, clusterName string) (*configservicev1.Document, error) {
+	config, err := customer_config.ResolveClusterConfig(withCollection(ctx, consts.CustomerConfigCollection), clusterName)
+	if err != nil {
+		return nil, internalError(ctx, "failed to resolve cluster configuration", err)
+	}
+	if config == nil {
+		return nil, status.Error(codes.NotFound, "configuration not found")
+	}
+	document, err := toDocument(config)
+	if err != nil {
+		return nil, internalError(ctx, "failed to encode configuration", err)
+	}
+	return document, nil
+}
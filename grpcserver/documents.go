@@ -0,0 +1,79 @@
+package grpcserver
+
+import (
+	"bytes"
+	configservicev1 "config-service/api/configservice/v1"
+	"config-service/types"
+	"context"
+	"encoding/json"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// toDocument returns the document message of a db document with the json content of the REST API
+func toDocument[T types.DocContent](doc T) (*configservicev1.Document, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	content := &structpb.Struct{}
+	if err := protojson.Unmarshal(data, content); err != nil {
+		return nil, err
+	}
+	document := &configservicev1.Document{
+		Guid:    doc.GetGUID(),
+		Name:    doc.GetName(),
+		Content: content,
+	}
+	if updatedTime := doc.GetUpdatedTime(); updatedTime != nil {
+		document.UpdatedTime = updatedTime.UTC().Format(time.RFC3339)
+	}
+	return document, nil
+}
+
+// toDocuments returns the list message of db documents
+func toDocuments[T types.DocContent](docs []T) (*configservicev1.ListDocumentsResponse, error) {
+	response := &configservicev1.ListDocumentsResponse{Documents: make([]*configservicev1.Document, 0, len(docs))}
+	for _, doc := range docs {
+		document, err := toDocument(doc)
+		if err != nil {
+			return nil, err
+		}
+		response.Documents = append(response.Documents, document)
+	}
+	return response, nil
+}
+
+// watch sends the message returned by get and then polls get every interval and sends the message when it changes,
+// until the call is canceled or get or send fail
+func watch[M proto.Message](ctx context.Context, interval time.Duration, get func() (M, error), send func(M) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var sent []byte
+	first := true
+	for {
+		msg, err := get()
+		if err != nil {
+			return err
+		}
+		//deterministic encoding to compare the messages with maps
+		data, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+		if err != nil {
+			return internalError(ctx, "failed to encode message", err)
+		}
+		if first || !bytes.Equal(data, sent) {
+			if err := send(msg); err != nil {
+				return err
+			}
+			sent, first = data, false
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package grpcserver
+
+import (
+	configservicev1 "config-service/api/configservice/v1"
+	"config-service/db"
+	"config-service/types"
+	"config-service/utils/consts"
+	"config-service/utils/log"
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/opa-utils/exceptions"
+	"golang.org/x/exp/slices"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	postureClusterField       = "resources.attributes." + armotypes.AttributeCluster
+	vulnerabilityClusterField = "designators.attributes." + armotypes.AttributeCluster
+)
+
+type exceptionPolicyServer struct {
+	configservicev1.UnimplementedExceptionPolicyServiceServer
+	watchInterval time.Duration
+}
+
+func (s *exceptionPolicyServer) ListPostureExceptionPolicies(ctx context.Context, req *configservicev1.ListExceptionPoliciesRequest) (*configservicev1.ListDocumentsResponse, error) {
+	defer log.LogNTraceEnterExit("ListPostureExceptionPolicies", ctx)()
+	return listPolicies[*types.PostureExceptionPolicy](ctx, consts.PostureExceptionPolicyCollection, postureClusterField, req)
+}
+
+func (s *exceptionPolicyServer) ListVulnerabilityExceptionPolicies(ctx context.Context, req *configservicev1.ListExceptionPoliciesRequest) (*configservicev1.ListDocumentsResponse, error) {
+	defer log.LogNTraceEnterExit("ListVulnerabilityExceptionPolicies", ctx)()
+	return listPolicies[*types.VulnerabilityExceptionPolicy](ctx, consts.VulnerabilityExceptionPolicyCollection, vulnerabilityClusterField, req)
+}
+
+func (s *exceptionPolicyServer) WatchPostureExceptionPolicies(req *configservicev1.ListExceptionPoliciesRequest, stream configservicev1.ExceptionPolicyService_WatchPostureExceptionPoliciesServer) error {
+	ctx := stream.Context()
+	defer log.LogNTraceEnterExit("WatchPostureExceptionPolicies", ctx)()
+	return watch(ctx, s.watchInterval, func() (*configservicev1.ListDocumentsResponse, error) {
+		return listPolicies[*types.PostureExceptionPolicy](ctx, consts.PostureExceptionPolicyCollection, postureClusterField, req)
+	}, stream.Send)
+}
+
+func (s *exceptionPolicyServer) WatchVulnerabilityExceptionPolicies(req *configservicev1.ListExceptionPoliciesRequest, stream configservicev1.ExceptionPolicyService_WatchVulnerabilityExceptionPoliciesServer) error {
+	ctx := stream.Context()
+	defer log.LogNTraceEnterExit("WatchVulnerabilityExceptionPolicies", ctx)()
+	return watch(ctx, s.watchInterval, func() (*configservicev1.ListDocumentsResponse, error) {
+		return listPolicies[*types.VulnerabilityExceptionPolicy](ctx, consts.VulnerabilityExceptionPolicyCollection, vulnerabilityClusterField, req)
+	}, stream.Send)
+}
+
+func (s *exceptionPolicyServer) MatchPostureExceptionPolicies(ctx context.Context, req *configservicev1.MatchPostureExceptionPoliciesRequest) (*configservicev1.ListDocumentsResponse, error) {
+	defer log.LogNTraceEnterExit("MatchPostureExceptionPolicies", ctx)()
+	if req.Resource == nil {
+		return nil, status.Error(codes.InvalidArgument, "resource is required")
+	}
+	//policies without a cluster designator apply to all the clusters so all the policies are matched
+	policies, err := db.FindForCustomer[*types.PostureExceptionPolicy](withCollection(ctx, consts.PostureExceptionPolicyCollection), db.NewFilterBuilder(), nil)
+	if err != nil {
+		return nil, internalError(ctx, "failed to list posture exception policies", err)
+	}
+	response, err := toDocuments(matchPosturePolicies(policies, req))
+	if err != nil {
+		return nil, internalError(ctx, "failed to encode posture exception policies", err)
+	}
+	return response, nil
+}
+
+func (s *exceptionPolicyServer) MatchVulnerabilityExceptionPolicies(ctx context.Context, req *configservicev1.MatchVulnerabilityExceptionPoliciesRequest) (*configservicev1.ListDocumentsResponse, error) {
+	defer log.LogNTraceEnterExit("MatchVulnerabilityExceptionPolicies", ctx)()
+	policies, err := db.FindForCustomer[*types.VulnerabilityExceptionPolicy](withCollection(ctx, consts.VulnerabilityExceptionPolicyCollection), db.NewFilterBuilder(), nil)
+	if err != nil {
+		return nil, internalError(ctx, "failed to list vulnerability exception policies", err)
+	}
+	response, err := toDocuments(matchVulnerabilityPolicies(policies, req))
+	if err != nil {
+		return nil, internalError(ctx, "failed to encode vulnerability exception policies", err)
+	}
+	return response, nil
+}
+
+// listPolicies returns the policies of the request names and with a designator of the request cluster
+func listPolicies[T types.DocContent](ctx context.Context, collection, clusterField string, req *configservicev1.ListExceptionPoliciesRequest) (*configservicev1.ListDocumentsResponse, error) {
+	filterBuilder := db.NewFilterBuilder()
+	if len(req.Names) > 0 {
+		filterBuilder.WithIn(consts.NameField, req.Names)
+	}
+	if req.ClusterName != "" {
+		filterBuilder.WithValue(clusterField, req.ClusterName)
+	}
+	policies, err := db.FindForCustomer[T](withCollection(ctx, collection), filterBuilder, nil)
+	if err != nil {
+		return nil, internalError(ctx, "failed to list exception policies", err)
+	}
+	response, err := toDocuments(policies)
+	if err != nil {
+		return nil, internalError(ctx, "failed to encode exception policies", err)
+	}
+	return response, nil
+}
+
+// matchPosturePolicies returns the policies of the rule with a resource designator of the resource, matched like kubescape matches them
+func matchPosturePolicies(policies []*types.PostureExceptionPolicy, req *configservicev1.MatchPostureExceptionPoliciesRequest) []*types.PostureExceptionPolicy {
+	armoPolicies := make([]armotypes.PostureExceptionPolicy, 0, len(policies))
+	for _, policy := range policies {
+		armoPolicies = append(armoPolicies, armotypes.PostureExceptionPolicy(*policy))
+	}
+	ruleExceptions := exceptions.ListRuleExceptions(armoPolicies, req.FrameworkName, req.ControlName, req.ControlId, req.RuleName)
+	workload := workloadinterface.NewWorkloadObj(req.Resource.AsMap())
+	matched := []*types.PostureExceptionPolicy{}
+	//a policy is returned once also when several designators match
+	guids := []string{}
+	for _, policy := range exceptions.GetResourceExceptions(ruleExceptions, workload, req.ClusterName) {
+		if !slices.Contains(guids, policy.GUID) {
+			guids = append(guids, policy.GUID)
+			matchedPolicy := types.PostureExceptionPolicy(policy)
+			matched = append(matched, &matchedPolicy)
+		}
+	}
+	return matched
+}
+
+// matchVulnerabilityPolicies returns the policies of the vulnerability with a designator of the workload container,
+// designator attributes are regular expressions like in posture exception policies and empty attributes match all the values
+func matchVulnerabilityPolicies(policies []*types.VulnerabilityExceptionPolicy, req *configservicev1.MatchVulnerabilityExceptionPoliciesRequest) []*types.VulnerabilityExceptionPolicy {
+	matched := []*types.VulnerabilityExceptionPolicy{}
+	for _, policy := range policies {
+		if req.Vulnerability != "" && slices.IndexFunc(policy.VulnerabilityPolicies, func(vulnerability armotypes.VulnerabilityPolicy) bool {
+			return vulnerability.Name == req.Vulnerability
+		}) < 0 {
+			continue
+		}
+		if slices.IndexFunc(policy.Designatores, func(designator armotypes.PortalDesignator) bool {
+			return designatorMatches(designator, req)
+		}) >= 0 {
+			matched = append(matched, policy)
+		}
+	}
+	return matched
+}
+
+func designatorMatches(designator armotypes.PortalDesignator, req *configservicev1.MatchVulnerabilityExceptionPoliciesRequest) bool {
+	attributes := designator.DigestPortalDesignator()
+	patterns := [][2]string{
+		{attributes.GetCluster(), req.ClusterName},
+		{attributes.GetNamespace(), req.Namespace},
+		{attributes.GetKind(), req.Kind},
+		{attributes.GetName(), req.Name},
+		{designator.Attributes[armotypes.AttributeContainerName], req.ContainerName},
+	}
+	empty := true
+	for _, pattern := range patterns {
+		if pattern[0] == "" {
+			continue
+		}
+		empty = false
+		if matched, _ := regexp.MatchString(fmt.Sprintf("^%s$", pattern[0]), pattern[1]); !matched {
+			return false
+		}
+	}
+	//designators without attributes do not match
+	return !empty
+}
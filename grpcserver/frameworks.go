@@ -0,0 +1,60 @@
+package grpcserver
+
+import (
+	configservicev1 "config-service/api/configservice/v1"
+	"config-service/db"
+	"config-service/types"
+	"config-service/utils/consts"
+	"config-service/utils/log"
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type frameworkServer struct {
+	configservicev1.UnimplementedFrameworkServiceServer
+}
+
+func (s *frameworkServer) GetFramework(ctx context.Context, req *configservicev1.GetFrameworkRequest) (*configservicev1.Document, error) {
+	defer log.LogNTraceEnterExit("GetFramework", ctx)()
+	ctx = withCollection(ctx, consts.FrameworkCollection)
+	var framework *types.Framework
+	var err error
+	switch {
+	case req.Guid != "":
+		framework, err = db.GetDocByGUID[types.Framework](ctx, req.Guid)
+	case req.Name != "":
+		framework, err = db.GetDocByName[types.Framework](ctx, req.Name)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "guid or name is required")
+	}
+	if err != nil {
+		return nil, internalError(ctx, "failed to get framework", err)
+	}
+	if framework == nil {
+		return nil, status.Error(codes.NotFound, "framework not found")
+	}
+	document, err := toDocument(framework)
+	if err != nil {
+		return nil, internalError(ctx, "failed to encode framework", err)
+	}
+	return document, nil
+}
+
+func (s *frameworkServer) ListFrameworks(ctx context.Context, req *configservicev1.ListFrameworksRequest) (*configservicev1.ListDocumentsResponse, error) {
+	defer log.LogNTraceEnterExit("ListFrameworks", ctx)()
+	filterBuilder := db.NewFilterBuilder()
+	if len(req.Names) > 0 {
+		filterBuilder.WithIn(consts.NameField, req.Names)
+	}
+	frameworks, err := db.FindForCustomer[*types.Framework](withCollection(ctx, consts.FrameworkCollection), filterBuilder, nil)
+	if err != nil {
+		return nil, internalError(ctx, "failed to list frameworks", err)
+	}
+	response, err := toDocuments(frameworks)
+	if err != nil {
+		return nil, internalError(ctx, "failed to encode frameworks", err)
+	}
+	return response, nil
+}
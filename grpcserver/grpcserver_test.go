@@ -0,0 +1,231 @@
+package grpcserver
+
+import (
+	configservicev1 "config-service/api/configservice/v1"
+	"config-service/auth"
+	"config-service/types"
+	"config-service/utils"
+	"config-service/utils/consts"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func postureDesignator(attributes map[string]string) armotypes.PortalDesignator {
+	return armotypes.PortalDesignator{DesignatorType: armotypes.DesignatorAttributes, Attributes: attributes}
+}
+
+func TestMatchPosturePolicies(t *testing.T) {
+	policy := func(guid string, posturePolicies []armotypes.PosturePolicy, resources ...armotypes.PortalDesignator) *types.PostureExceptionPolicy {
+		return &types.PostureExceptionPolicy{PortalBase: armotypes.PortalBase{GUID: guid, Name: guid}, PosturePolicies: posturePolicies, Resources: resources}
+	}
+	policies := []*types.PostureExceptionPolicy{
+		policy("all-rules", nil,
+			postureDesignator(map[string]string{armotypes.AttributeCluster: "cluster1", armotypes.AttributeNamespace: "default"}),
+			//second matching designator of the same policy
+			postureDesignator(map[string]string{armotypes.AttributeKind: "Deployment"})),
+		policy("control", []armotypes.PosturePolicy{{FrameworkName: "NSA", ControlID: "C-0001"}},
+			postureDesignator(map[string]string{armotypes.AttributeName: "nginx-.*"})),
+		policy("other-control", []armotypes.PosturePolicy{{FrameworkName: "NSA", ControlID: "C-0002"}},
+			postureDesignator(map[string]string{armotypes.AttributeName: "nginx-.*"})),
+		policy("other-cluster", nil,
+			postureDesignator(map[string]string{armotypes.AttributeCluster: "cluster2"})),
+	}
+	resource, err := structpb.NewStruct(map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "nginx-1", "namespace": "default"},
+	})
+	require.NoError(t, err)
+	matched := matchPosturePolicies(policies, &configservicev1.MatchPostureExceptionPoliciesRequest{
+		ClusterName:   "cluster1",
+		FrameworkName: "NSA",
+		ControlId:     "C-0001",
+		Resource:      resource,
+	})
+	guids := []string{}
+	for _, policy := range matched {
+		guids = append(guids, policy.GUID)
+	}
+	assert.Equal(t, []string{"all-rules", "control"}, guids)
+}
+
+func TestMatchVulnerabilityPolicies(t *testing.T) {
+	policy := func(guid string, vulnerabilities []string, designators ...armotypes.PortalDesignator) *types.VulnerabilityExceptionPolicy {
+		p := &types.VulnerabilityExceptionPolicy{PortalBase: armotypes.PortalBase{GUID: guid}, Designatores: designators}
+		for _, vulnerability := range vulnerabilities {
+			p.VulnerabilityPolicies = append(p.VulnerabilityPolicies, armotypes.VulnerabilityPolicy{Name: vulnerability})
+		}
+		return p
+	}
+	policies := []*types.VulnerabilityExceptionPolicy{
+		policy("container", []string{"CVE-1"}, postureDesignator(map[string]string{
+			armotypes.AttributeCluster:       "cluster1",
+			armotypes.AttributeNamespace:     "default",
+			armotypes.AttributeKind:          "deployment",
+			armotypes.AttributeName:          "nginx",
+			armotypes.AttributeContainerName: "nginx",
+		})),
+		policy("namespace-regex", []string{"CVE-1", "CVE-2"}, postureDesignator(map[string]string{armotypes.AttributeNamespace: "def.*"})),
+		policy("other-vulnerability", []string{"CVE-2"}, postureDesignator(map[string]string{armotypes.AttributeNamespace: "default"})),
+		policy("other-container", []string{"CVE-1"}, postureDesignator(map[string]string{armotypes.AttributeContainerName: "sidecar"})),
+		policy("empty-designator", []string{"CVE-1"}, postureDesignator(map[string]string{})),
+	}
+	match := func(req *configservicev1.MatchVulnerabilityExceptionPoliciesRequest) []string {
+		guids := []string{}
+		for _, policy := range matchVulnerabilityPolicies(policies, req) {
+			guids = append(guids, policy.GUID)
+		}
+		return guids
+	}
+	req := &configservicev1.MatchVulnerabilityExceptionPoliciesRequest{
+		ClusterName:   "cluster1",
+		Namespace:     "default",
+		Kind:          "deployment",
+		Name:          "nginx",
+		ContainerName: "nginx",
+		Vulnerability: "CVE-1",
+	}
+	assert.Equal(t, []string{"container", "namespace-regex"}, match(req))
+	req.Vulnerability = ""
+	assert.Equal(t, []string{"container", "namespace-regex", "other-vulnerability"}, match(req), "all the vulnerabilities")
+	req.ClusterName = "cluster2"
+	assert.Equal(t, []string{"namespace-regex", "other-vulnerability"}, match(req))
+}
+
+func TestAuthorize(t *testing.T) {
+	apiKey := func(scopes ...string) *auth.Identity {
+		return &auth.Identity{CustomerGUID: "customer1", Method: auth.MethodAPIKey, Scopes: scopes}
+	}
+	ctx := context.Background()
+	assert.NoError(t, authorize(ctx, &auth.Identity{CustomerGUID: "customer1", Admin: true}, consts.FrameworkPath))
+	assert.NoError(t, authorize(ctx, apiKey("v1_opa_framework:read"), consts.FrameworkPath))
+	assert.NoError(t, authorize(ctx, apiKey("v1_opa_framework:write"), consts.FrameworkPath), "write scope allows reading")
+	err := authorize(ctx, apiKey("v1_opa_framework:read"), consts.PostureExceptionPolicyPath)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Contains(t, err.Error(), "API key is missing scope v1_posture_exception_policy:read")
+}
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	_, err := authenticate(ctx, "/configservice.v1.ConfigService/GetClusterConfig")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = authenticate(ctx, "/configservice.v1.ConfigService/Unknown")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "methods without a path are not allowed")
+	_, err = authenticate(ctx, "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo")
+	assert.NoError(t, err, "reflection without authentication")
+
+	//every method of the services has a path
+	for _, desc := range []grpc.ServiceDesc{configservicev1.ConfigService_ServiceDesc, configservicev1.ExceptionPolicyService_ServiceDesc, configservicev1.FrameworkService_ServiceDesc} {
+		for _, method := range desc.Methods {
+			assert.Contains(t, methodPaths, "/"+desc.ServiceName+"/"+method.MethodName)
+		}
+		for _, stream := range desc.Streams {
+			assert.Contains(t, methodPaths, "/"+desc.ServiceName+"/"+stream.StreamName)
+		}
+	}
+}
+
+func TestRequestContext(t *testing.T) {
+	ctx := &requestContext{Context: context.Background(), values: map[string]interface{}{consts.CustomerGUID: "customer1"}}
+	collectionCtx := withCollection(ctx, consts.FrameworkCollection)
+	assert.Equal(t, "customer1", collectionCtx.Value(consts.CustomerGUID))
+	assert.Equal(t, consts.FrameworkCollection, collectionCtx.Value(consts.Collection))
+	assert.Nil(t, ctx.Value(consts.Collection))
+}
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	names := []string{"a", "a", "b", "b", "a"}
+	calls := 0
+	sent := []string{}
+	get := func() (*configservicev1.Document, error) {
+		name := names[calls]
+		calls++
+		if calls == len(names) {
+			cancel()
+		}
+		return &configservicev1.Document{Name: name}, nil
+	}
+	send := func(doc *configservicev1.Document) error {
+		sent = append(sent, doc.Name)
+		return nil
+	}
+	require.NoError(t, watch(ctx, time.Millisecond, get, send))
+	assert.Equal(t, []string{"a", "b", "a"}, sent, "sent only on changes")
+
+	getErr := errors.New("get failed")
+	err := watch(context.Background(), time.Millisecond, func() (*configservicev1.Document, error) { return nil, getErr }, send)
+	assert.Equal(t, getErr, err)
+}
+
+func TestToDocument(t *testing.T) {
+	framework := &types.Framework{}
+	framework.GUID = "guid1"
+	framework.Name = "framework1"
+	framework.UpdatedTime = "2022-11-01T10:00:00Z"
+	framework.Attributes = map[string]interface{}{"builtin": true}
+	doc, err := toDocument(framework)
+	require.NoError(t, err)
+	assert.Equal(t, "guid1", doc.Guid)
+	assert.Equal(t, "framework1", doc.Name)
+	assert.Equal(t, "2022-11-01T10:00:00Z", doc.UpdatedTime)
+	assert.Equal(t, "framework1", doc.Content.AsMap()["name"])
+	assert.Equal(t, map[string]interface{}{"builtin": true}, doc.Content.AsMap()["attributes"])
+}
+
+func TestServer(t *testing.T) {
+	server, err := NewServer(utils.Configuration{GRPC: utils.GRPCConfig{Reflection: true}})
+	require.NoError(t, err)
+	listener := bufconn.Listen(1024 * 1024)
+	go server.Serve(listener)
+	defer server.Stop()
+	conn, err := grpc.Dial("bufnet", grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	//calls without credentials are rejected
+	_, err = configservicev1.NewConfigServiceClient(conn).GetClusterConfig(ctx, &configservicev1.GetClusterConfigRequest{ClusterName: "cluster1"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	watchStream, err := configservicev1.NewExceptionPolicyServiceClient(conn).WatchPostureExceptionPolicies(ctx, &configservicev1.ListExceptionPoliciesRequest{})
+	require.NoError(t, err)
+	_, err = watchStream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	//reflection lists the services
+	reflectionStream, err := grpc_reflection_v1alpha.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	require.NoError(t, err)
+	require.NoError(t, reflectionStream.Send(&grpc_reflection_v1alpha.ServerReflectionRequest{
+		MessageRequest: &grpc_reflection_v1alpha.ServerReflectionRequest_ListServices{},
+	}))
+	resp, err := reflectionStream.Recv()
+	require.NoError(t, err)
+	services := []string{}
+	for _, service := range resp.GetListServicesResponse().Service {
+		services = append(services, service.Name)
+	}
+	assert.ElementsMatch(t, []string{
+		"configservice.v1.ConfigService",
+		"configservice.v1.ExceptionPolicyService",
+		"configservice.v1.FrameworkService",
+		"grpc.reflection.v1alpha.ServerReflection",
+	}, services)
+}
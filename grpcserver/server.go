@@ -0,0 +1,85 @@
+package grpcserver
+
+import (
+	configservicev1 "config-service/api/configservice/v1"
+	"config-service/utils"
+	"config-service/utils/tlsconfig"
+	"fmt"
+	"net"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/reflection"
+)
+
+// gRPC API - the services of api/configservice/v1 served on a separate port,
+// the services read the same db documents as the REST API with the same authentication and access checks
+
+const (
+	defaultWatchInterval = 10 * time.Second
+	//time to wait for running calls before the server is stopped, watch calls are stopped only by the client or by the timeout
+	gracefulStopTimeout = 5 * time.Second
+)
+
+// Start starts the gRPC server when a gRPC port is configured and returns a function that stops it
+func Start(conf utils.Configuration) (stop func(), err error) {
+	if conf.GRPC.Port == "" {
+		return func() {}, nil
+	}
+	server, err := NewServer(conf)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", ":"+conf.GRPC.Port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on gRPC port %s: %w", conf.GRPC.Port, err)
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			zap.L().Error("gRPC server stopped", zap.Error(err))
+		}
+	}()
+	zap.L().Info("Starting gRPC server on port "+conf.GRPC.Port, zap.Bool("tls", conf.Server.TLS.CertFile != ""), zap.Bool("reflection", conf.GRPC.Reflection))
+	return func() {
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(gracefulStopTimeout):
+			server.Stop()
+		}
+		zap.L().Info("gRPC server stopped")
+	}, nil
+}
+
+// NewServer returns a gRPC server with the registered services, the server uses the TLS configuration of the REST server
+func NewServer(conf utils.Configuration) (*grpc.Server, error) {
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(unaryInterceptor),
+		grpc.StreamInterceptor(streamInterceptor),
+	}
+	if conf.Server.TLS.CertFile != "" {
+		tlsConfig, err := tlsconfig.New(conf.Server.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize tls: %w", err)
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	watchInterval := defaultWatchInterval
+	if conf.GRPC.WatchIntervalSeconds > 0 {
+		watchInterval = time.Duration(conf.GRPC.WatchIntervalSeconds) * time.Second
+	}
+	server := grpc.NewServer(opts...)
+	configservicev1.RegisterConfigServiceServer(server, &configServer{watchInterval: watchInterval})
+	configservicev1.RegisterExceptionPolicyServiceServer(server, &exceptionPolicyServer{watchInterval: watchInterval})
+	configservicev1.RegisterFrameworkServiceServer(server, &frameworkServer{})
+	if conf.GRPC.Reflection {
+		reflection.Register(server)
+	}
+	return server, nil
+}
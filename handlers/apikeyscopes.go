@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"config-service/utils/consts"
+	"config-service/utils/log"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/exp/slices"
+)
+
+// API key scopes are "<path>:<access>" e.g. "v1_posture_exception_policy:write", the path is a path registered by AddRoutes
+// read scope allows GET requests, write scope allows all requests
+
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// paths registered by AddRoutes (without the leading slash), only these paths can be accessed with API keys
+var scopedPaths = sync.Map{}
+
+func registerScopedPath(path string) {
+	scopedPaths.Store(strings.TrimPrefix(path, "/"), true)
+}
+
+// ScopedPaths returns the sorted list of paths that can be used in API key scopes
+func ScopedPaths() []string {
+	paths := []string{}
+	scopedPaths.Range(func(key, _ interface{}) bool {
+		paths = append(paths, key.(string))
+		return true
+	})
+	sort.Strings(paths)
+	return paths
+}
+
+// IsScopedRoute returns true if the route full path was registered by AddRoutes
+func IsScopedRoute(fullPath string) bool {
+	_, ok := scopedPaths.Load(RouteBasePath(fullPath))
+	return ok
+}
+
+// ValidateAPIKeyScopes validates the format, path and access of API key scopes
+func ValidateAPIKeyScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("scopes are required")
+	}
+	for _, scope := range scopes {
+		path, access, found := strings.Cut(scope, ":")
+		if !found || (access != ScopeRead && access != ScopeWrite) {
+			return fmt.Errorf("invalid scope %s, scope must be <path>:%s or <path>:%s", scope, ScopeRead, ScopeWrite)
+		}
+		if _, ok := scopedPaths.Load(path); !ok {
+			return fmt.Errorf("invalid scope %s, unknown path %s", scope, path)
+		}
+	}
+	return nil
+}
+
+// APIKeyScopesMiddleware aborts requests authenticated with an API key that has no scope for the path and request method
+func APIKeyScopesMiddleware(path string) gin.HandlerFunc {
+	path = strings.TrimPrefix(path, "/")
+	return func(c *gin.Context) {
+		scopes, ok := c.Get(consts.APIKeyScopes)
+		if !ok {
+			c.Next()
+			return
+		}
+		keyScopes, _ := scopes.([]string)
+		if requiredScope, ok := HasScope(keyScopes, path, c.Request.Method); !ok {
+			log.LogNTrace("API key is missing scope "+requiredScope, c)
+			ResponseProblem(c, http.StatusForbidden, ErrorCodeMissingScope, "API key is missing scope "+requiredScope, gin.H{"scope": requiredScope})
+			return
+		}
+		c.Next()
+	}
+}
+
+// HasScope returns the scope required for the request method in the path (without the leading slash)
+// and true if the API key scopes allow it, the write scope also allows reading
+func HasScope(scopes []string, path, method string) (requiredScope string, ok bool) {
+	requiredScope = path + ":" + ScopeWrite
+	if method == http.MethodGet || method == http.MethodHead {
+		requiredScope = path + ":" + ScopeRead
+	}
+	return requiredScope, slices.Contains(scopes, requiredScope) || slices.Contains(scopes, path+":"+ScopeWrite)
+}
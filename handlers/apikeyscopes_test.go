@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"config-service/utils/consts"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyScopesMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registerScopedPath("/test_path")
+	tests := []struct {
+		name       string
+		method     string
+		scopes     []string
+		wantStatus int
+	}{
+		{name: "no API key", method: http.MethodPut, scopes: nil, wantStatus: http.StatusOK},
+		{name: "read with read scope", method: http.MethodGet, scopes: []string{"test_path:read"}, wantStatus: http.StatusOK},
+		{name: "read with write scope", method: http.MethodGet, scopes: []string{"test_path:write"}, wantStatus: http.StatusOK},
+		{name: "write with write scope", method: http.MethodPost, scopes: []string{"test_path:write"}, wantStatus: http.StatusOK},
+		{name: "write with read scope", method: http.MethodDelete, scopes: []string{"test_path:read"}, wantStatus: http.StatusForbidden},
+		{name: "other path scope", method: http.MethodGet, scopes: []string{"other_path:write"}, wantStatus: http.StatusForbidden},
+		{name: "no scopes", method: http.MethodGet, scopes: []string{}, wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.scopes != nil {
+					c.Set(consts.APIKeyScopes, tt.scopes)
+				}
+			})
+			group := router.Group("/test_path")
+			group.Use(APIKeyScopesMiddleware("/test_path"))
+			group.Handle(tt.method, "/:guid", func(c *gin.Context) { c.Status(http.StatusOK) })
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, "/test_path/1", nil)
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestValidateAPIKeyScopes(t *testing.T) {
+	registerScopedPath("/test_path")
+	assert.NoError(t, ValidateAPIKeyScopes([]string{"test_path:read", "test_path:write"}))
+	assert.Error(t, ValidateAPIKeyScopes(nil))
+	assert.Error(t, ValidateAPIKeyScopes([]string{"test_path"}))
+	assert.Error(t, ValidateAPIKeyScopes([]string{"test_path:admin"}))
+	assert.Error(t, ValidateAPIKeyScopes([]string{"unknown_path:read"}))
+	assert.True(t, IsScopedRoute("/test_path/:guid"))
+	assert.False(t, IsScopedRoute("/v1_admin/customers"))
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Conditional GET - responses of documents have a strong ETag of the documents content (including the updatedTime)
+// and single documents have a Last-Modified header of their updatedTime
+
+type updatedTimeGetter interface {
+	GetUpdatedTime() *time.Time
+}
+
+// ETag returns a strong ETag of the documents in the negotiated response format,
+// documents can be nil (e.g. missing layers of a merged configuration) and are hashed in order
+func ETag(c *gin.Context, docs ...interface{}) string {
+	hash := sha256.New()
+	format := "json"
+	if AcceptsYAML(c) {
+		format = "yaml"
+	}
+	hash.Write([]byte(format + "\n"))
+	encoder := json.NewEncoder(hash)
+	for _, doc := range docs {
+		if err := encoder.Encode(doc); err != nil {
+			//not expected for documents that are sent as json, the error makes the ETag unique
+			hash.Write([]byte(err.Error()))
+		}
+	}
+	return `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// LastModified returns the latest updatedTime of the documents, nil if none of them has an updatedTime
+func LastModified(docs ...interface{}) *time.Time {
+	var lastModified *time.Time
+	for _, doc := range docs {
+		if isNilDoc(doc) {
+			continue
+		}
+		if getter, ok := doc.(updatedTimeGetter); ok {
+			if updatedTime := getter.GetUpdatedTime(); updatedTime != nil && (lastModified == nil || updatedTime.After(*lastModified)) {
+				lastModified = updatedTime
+			}
+		}
+	}
+	return lastModified
+}
+
+func isNilDoc(doc interface{}) bool {
+	if doc == nil {
+		return true
+	}
+	value := reflect.ValueOf(doc)
+	return value.Kind() == reflect.Pointer && value.IsNil()
+}
+
+// NotModified sets the ETag and Last-Modified (when not nil) headers of a GET response and responds 304 Not Modified
+// when the If-None-Match or If-Modified-Since request headers match them. Returns true if responded.
+func NotModified(c *gin.Context, etag string, lastModified *time.Time) bool {
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return false
+	}
+	c.Header("ETag", etag)
+	c.Writer.Header().Add("Vary", "Accept")
+	if lastModified != nil {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	//If-Modified-Since is ignored when If-None-Match is sent (RFC 7232)
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" {
+		if !etagMatch(ifNoneMatch, etag) {
+			return false
+		}
+	} else if ifModifiedSince := c.GetHeader("If-Modified-Since"); ifModifiedSince == "" || lastModified == nil {
+		return false
+	} else if since, err := http.ParseTime(ifModifiedSince); err != nil || lastModified.Truncate(time.Second).After(since) {
+		return false
+	}
+	c.AbortWithStatus(http.StatusNotModified)
+	return true
+}
+
+// etagMatch returns true if one of the If-None-Match ETags matches the ETag by weak comparison
+func etagMatch(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+func docsETag[T any](c *gin.Context, docs []T) string {
+	items := make([]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		items = append(items, doc)
+	}
+	return ETag(c, items...)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"config-service/types"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newConditionalTestContext(method string, headers map[string]string) (*gin.Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(method, "/cluster", nil)
+	for key, value := range headers {
+		c.Request.Header.Set(key, value)
+	}
+	return c, w
+}
+
+func TestETag(t *testing.T) {
+	c, _ := newConditionalTestContext(http.MethodGet, nil)
+	cluster := &types.Cluster{PortalBase: armotypes.PortalBase{GUID: "1", Name: "c1", UpdatedTime: "2023-01-01T00:00:00Z"}}
+	etag := ETag(c, cluster)
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+	assert.Equal(t, etag, ETag(c, cluster), "same content same ETag")
+
+	updated := *cluster
+	updated.UpdatedTime = "2023-01-02T00:00:00Z"
+	assert.NotEqual(t, etag, ETag(c, &updated), "updatedTime changes the ETag")
+	//layers order and missing layers
+	var missing *types.CustomerConfig
+	assert.NotEqual(t, ETag(c, cluster, &updated), ETag(c, &updated, cluster))
+	assert.NotEqual(t, ETag(c, cluster), ETag(c, missing, cluster))
+	//representation
+	yamlContext, _ := newConditionalTestContext(http.MethodGet, map[string]string{"Accept": "application/yaml"})
+	assert.NotEqual(t, etag, ETag(yamlContext, cluster))
+}
+
+func TestLastModified(t *testing.T) {
+	var missing *types.CustomerConfig
+	assert.Nil(t, LastModified(missing, nil, &types.CustomerConfig{}))
+	older := &types.CustomerConfig{UpdatedTime: "2023-01-01T00:00:00Z"}
+	newer := &types.CustomerConfig{UpdatedTime: "2023-02-01T00:00:00Z"}
+	assert.Equal(t, time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), *LastModified(older, missing, newer))
+}
+
+func TestNotModified(t *testing.T) {
+	etag := `"abc"`
+	lastModified := time.Date(2023, 1, 1, 10, 0, 0, 500, time.UTC)
+	tests := []struct {
+		name         string
+		method       string
+		headers      map[string]string
+		lastModified *time.Time
+		expected     bool
+	}{
+		{name: "no conditions", method: http.MethodGet, lastModified: &lastModified},
+		{name: "etag match", method: http.MethodGet, headers: map[string]string{"If-None-Match": `"xyz", "abc"`}, expected: true},
+		{name: "weak etag match", method: http.MethodGet, headers: map[string]string{"If-None-Match": `W/"abc"`}, expected: true},
+		{name: "any etag", method: http.MethodGet, headers: map[string]string{"If-None-Match": `*`}, expected: true},
+		{name: "etag mismatch", method: http.MethodGet, headers: map[string]string{"If-None-Match": `"xyz"`}},
+		{name: "not modified since", method: http.MethodGet, headers: map[string]string{"If-Modified-Since": "Sun, 01 Jan 2023 10:00:00 GMT"}, lastModified: &lastModified, expected: true},
+		{name: "modified since", method: http.MethodGet, headers: map[string]string{"If-Modified-Since": "Sun, 01 Jan 2023 09:59:59 GMT"}, lastModified: &lastModified},
+		{name: "no last modified", method: http.MethodGet, headers: map[string]string{"If-Modified-Since": "Sun, 01 Jan 2023 10:00:00 GMT"}},
+		{name: "etag takes precedence", method: http.MethodGet, headers: map[string]string{"If-None-Match": `"xyz"`, "If-Modified-Since": "Sun, 01 Jan 2023 10:00:00 GMT"}, lastModified: &lastModified},
+		{name: "not a GET", method: http.MethodPut, headers: map[string]string{"If-None-Match": `"abc"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := newConditionalTestContext(tt.method, tt.headers)
+			assert.Equal(t, tt.expected, NotModified(c, etag, tt.lastModified))
+			if tt.method != http.MethodGet {
+				assert.Empty(t, w.Header().Get("ETag"))
+				return
+			}
+			assert.Equal(t, etag, w.Header().Get("ETag"))
+			if tt.lastModified != nil {
+				assert.Equal(t, "Sun, 01 Jan 2023 10:00:00 GMT", w.Header().Get("Last-Modified"))
+			}
+			if tt.expected {
+				assert.Equal(t, http.StatusNotModified, c.Writer.Status())
+				assert.True(t, c.IsAborted())
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"bytes"
+	"config-service/db"
+	"config-service/types"
+	"config-service/utils/consts"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DocType - document type of routes added by AddRoutes, used by APIs that query all the document types (e.g. GraphQL)
+type DocType struct {
+	Name       string      //name of the document type schema e.g. Cluster
+	Path       string      //base path of the document routes
+	Collection string      //db collection of the documents
+	Schema     *JSONSchema //schema of the document json encoding
+	//Find returns the json values of the not deleted customer documents that match the filter
+	Find func(c *gin.Context, filterBuilder *db.FilterBuilder) ([]map[string]interface{}, error)
+}
+
+// document types by name, a type that is served by several routes is registered by the first one
+var docTypes = sync.Map{}
+
+func registerDocType[T types.DocContent](opts *routerOptions[T]) {
+	schema := NewJSONSchema[T]()
+	collection := opts.dbCollection
+	docTypes.LoadOrStore(schema.Title, DocType{
+		Name:       schema.Title,
+		Path:       opts.path,
+		Collection: collection,
+		Schema:     schema,
+		Find: func(c *gin.Context, filterBuilder *db.FilterBuilder) ([]map[string]interface{}, error) {
+			collectionCtx := c.Copy()
+			collectionCtx.Set(consts.Collection, collection)
+			docs, err := db.FindForCustomer[T](collectionCtx, filterBuilder, nil)
+			if err != nil {
+				return nil, err
+			}
+			return jsonValues(docs)
+		},
+	})
+}
+
+// DocTypes returns the registered document types sorted by name
+func DocTypes() []DocType {
+	registered := []DocType{}
+	docTypes.Range(func(_, value interface{}) bool {
+		registered = append(registered, value.(DocType))
+		return true
+	})
+	sort.Slice(registered, func(i, j int) bool {
+		return registered[i].Name < registered[j].Name
+	})
+	return registered
+}
+
+// jsonValues returns the decoded json encoding of the documents, numbers are kept as json.Number
+func jsonValues[T any](docs []T) ([]map[string]interface{}, error) {
+	data, err := json.Marshal(docs)
+	if err != nil {
+		return nil, err
+	}
+	values := []map[string]interface{}{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
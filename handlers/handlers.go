@@ -5,6 +5,7 @@ import (
 	"config-service/types"
 	"config-service/utils/consts"
 	"config-service/utils/log"
+	"config-service/webhooks"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -45,9 +46,13 @@ func HandleGet[T types.DocContent](opts *routerOptions[T]) gin.HandlerFunc {
 	}
 }
 
-// HandleGetAll - get all customer's documents of type T for collection in context
+// HandleGetAll - get all customer's documents of type T for collection in context, streamed as NDJSON when the Accept header asks for it
 func HandleGetAll[T types.DocContent](c *gin.Context) {
 	defer log.LogNTraceEnterExit("HandleGetAll", c)()
+	if sender, _ := GetCustomResponseSender[T](c); sender == nil && AcceptsNDJSON(c) {
+		streamDocsResponse[T](c, false)
+		return
+	}
 	if docs, err := db.GetAllForCustomer[T](c, false); err != nil {
 		ResponseInternalServerError(c, "failed to read all documents for customer", err)
 		return
@@ -56,9 +61,13 @@ func HandleGetAll[T types.DocContent](c *gin.Context) {
 	}
 }
 
-// HandleGetAll - get all global and customer's documents of type T for collection in context
+// HandleGetAll - get all global and customer's documents of type T for collection in context, streamed as NDJSON when the Accept header asks for it
 func HandleGetAllWithGlobals[T types.DocContent](c *gin.Context) {
 	defer log.LogNTraceEnterExit("HandleGetAllWithGlobals", c)()
+	if sender, _ := GetCustomResponseSender[T](c); sender == nil && AcceptsNDJSON(c) {
+		streamDocsResponse[T](c, true)
+		return
+	}
 	if docs, err := db.GetAllForCustomer[T](c, true); err != nil {
 		ResponseInternalServerError(c, "failed to read all documents for customer", err)
 		return
@@ -80,7 +89,11 @@ func GetNamesListHandler[T types.DocContent](c *gin.Context, includeGlobals bool
 			for _, docContent := range docNames {
 				names = append(names, docContent.GetName())
 			}
-			c.JSON(http.StatusOK, names)
+			if IsV2Request(c) {
+				listResponse(c, http.StatusOK, names)
+				return true
+			}
+			ResponseNegotiated(c, http.StatusOK, names)
 			return true
 		}
 	}
@@ -98,6 +111,14 @@ func GetByNameParamHandler[T types.DocContent](c *gin.Context, nameParam string)
 		if doc, err := db.GetDocByName[T](c, name); err != nil {
 			ResponseInternalServerError(c, "failed to read document", err)
 			return true
+		} else if IsV2Request(c) {
+			//v2 query by name responds with a list of the matching document
+			docs := []T{}
+			if doc != nil {
+				docs = append(docs, *doc)
+			}
+			listResponse(c, http.StatusOK, docs)
+			return true
 		} else {
 			docResponse(c, doc)
 			return true
@@ -226,11 +247,15 @@ func PostDocHandler[T types.DocContent](c *gin.Context, docs []T) {
 			ResponseInternalServerError(c, "failed to create document", err)
 			return
 		}
+	}
+	enqueueWebhooks(c, webhooks.EventCreated, docs)
+	if IsV2Request(c) {
+		listResponse(c, http.StatusCreated, docs)
 	} else {
 		if len(docs) == 1 {
-			c.JSON(http.StatusCreated, docs[0])
+			ResponseNegotiated(c, http.StatusCreated, docs[0])
 		} else {
-			c.JSON(http.StatusCreated, docs)
+			ResponseNegotiated(c, http.StatusCreated, docs)
 		}
 	}
 }
@@ -244,7 +269,7 @@ func PostDBDocumentHandler[T types.DocContent](c *gin.Context, dbDoc types.Docum
 		ResponseInternalServerError(c, "failed to create document", err)
 		return
 	} else {
-		c.JSON(http.StatusCreated, dbDoc.Content)
+		ResponseNegotiated(c, http.StatusCreated, dbDoc.Content)
 	}
 }
 
@@ -276,17 +301,26 @@ func PutDocHandler[T types.DocContent](c *gin.Context, doc T) {
 	update, err := db.GetUpdateDocCommand(doc, GetCustomPutFields(c), doc.GetReadOnlyFields()...)
 	if err != nil {
 		if db.IsNoFieldsToUpdateError(err) {
-			ResponseBadRequest(c, "no fields to update")
+			//the request has only read only or not updatable fields
+			ResponseProblem(c, http.StatusBadRequest, ErrorCodeReadOnlyField, "no fields to update", gin.H{"readOnlyFields": doc.GetReadOnlyFields()})
 			return
 		}
 		ResponseInternalServerError(c, "failed to generate update command", err)
 		return
 	}
-	if res, err := db.UpdateDocument[T](c, doc.GetGUID(), update); err != nil {
+	res, err := db.UpdateDocument[T](c, doc.GetGUID(), update)
+	if err != nil {
 		ResponseInternalServerError(c, "failed to update document", err)
+		return
 	} else if res == nil {
 		ResponseDocumentNotFound(c)
 		return
+	}
+	//the update responds with the old and the updated documents
+	enqueueWebhooks(c, webhooks.EventUpdated, res[len(res)-1:])
+	if IsV2Request(c) {
+		//v2 PUT responds with the updated document
+		ResponseNegotiated(c, http.StatusOK, res[len(res)-1])
 	} else {
 		docsResponse(c, res)
 	}
@@ -337,11 +371,19 @@ func HandleDeleteDocByName[T types.DocContent](nameParam string) gin.HandlerFunc
 
 func BulkDeleteDocByNameHandler[T types.DocContent](c *gin.Context, names []string) {
 	defer log.LogNTraceEnterExit("BulkDeleteDocByNameHandler", c)()
+	//the documents of the deleted event
+	deletedDocs, err := db.FindForCustomer[T](c, db.NewFilterBuilder().WithIn(consts.NameField, names), nil)
+	if err != nil {
+		ResponseInternalServerError(c, "failed to read documents", err)
+		return
+	}
 	if deletedCount, err := db.BulkDeleteByName[T](c, names); err != nil {
+		ResponseInternalServerError(c, "failed to delete documents", err)
 	} else if deletedCount == 0 {
 		ResponseDocumentNotFound(c)
 	} else {
-		c.JSON(http.StatusOK, gin.H{"deletedCount": deletedCount})
+		enqueueWebhooks(c, webhooks.EventDeleted, deletedDocs)
+		ResponseNegotiated(c, http.StatusOK, gin.H{"deletedCount": deletedCount})
 	}
 }
 
@@ -352,7 +394,8 @@ func DeleteDocByGUIDHandler[T types.DocContent](c *gin.Context, guid string) {
 	} else if deletedDoc == nil {
 		ResponseDocumentNotFound(c)
 	} else {
-		c.JSON(http.StatusOK, deletedDoc)
+		enqueueWebhooks(c, webhooks.EventDeleted, []T{*deletedDoc})
+		ResponseNegotiated(c, http.StatusOK, deletedDoc)
 	}
 }
 
@@ -363,7 +406,21 @@ func DeleteDocByNameHandler[T types.DocContent](c *gin.Context, name string) {
 	} else if deletedDoc == nil {
 		ResponseDocumentNotFound(c)
 	} else {
-		c.JSON(http.StatusOK, deletedDoc)
+		enqueueWebhooks(c, webhooks.EventDeleted, []T{*deletedDoc})
+		if IsV2Request(c) {
+			//v2 delete by name responds with the deleted count also for a single name
+			ResponseNegotiated(c, http.StatusOK, gin.H{"deletedCount": 1})
+		} else {
+			ResponseNegotiated(c, http.StatusOK, deletedDoc)
+		}
+	}
+}
+
+// enqueueWebhooks adds the webhook deliveries of the event of a successful write,
+// the write response is not failed when the deliveries cannot be added
+func enqueueWebhooks[T types.DocContent](c *gin.Context, eventType webhooks.EventType, docs []T) {
+	if err := webhooks.Enqueue(c, eventType, docs); err != nil {
+		log.LogNTraceError("failed to enqueue webhook deliveries", err, c)
 	}
 }
 
@@ -399,7 +456,7 @@ func HandlerAddToArray(requestHandler ContainerHandler) func(c *gin.Context) {
 			ResponseInternalServerError(c, "failed to add to unsubscribedUsers", err)
 			return
 		} else {
-			c.JSON(http.StatusOK, gin.H{"added": modified})
+			ResponseNegotiated(c, http.StatusOK, gin.H{"added": modified})
 		}
 	}
 }
@@ -419,7 +476,7 @@ func HandlerRemoveFromArray(requestHandler ContainerHandler) func(c *gin.Context
 			ResponseInternalServerError(c, "failed to remove from  unsubscribedUsers", err)
 			return
 		} else {
-			c.JSON(http.StatusOK, gin.H{"removed": modified})
+			ResponseNegotiated(c, http.StatusOK, gin.H{"removed": modified})
 		}
 	}
 }
@@ -445,7 +502,7 @@ func HandlerSetField(requestHandler ContainerHandler, set bool) func(c *gin.Cont
 			ResponseInternalServerError(c, "failed to add to unsubscribedUsers", err)
 			return
 		} else {
-			c.JSON(http.StatusOK, gin.H{"modified": modified})
+			ResponseNegotiated(c, http.StatusOK, gin.H{"modified": modified})
 		}
 	}
 }
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"bytes"
+	"config-service/types"
+	"config-service/utils/consts"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	jsonSchemaDialect     = "https://json-schema.org/draft/2020-12/schema"
+	jsonSchemaContentType = "application/schema+json"
+	jsonSchemaDefsPrefix  = "#/$defs/"
+)
+
+// JSONSchema - JSON schema of a document type, generated from the type json encoding and refined by the router options.
+// The named types are defined in $defs, the same as the OpenAPI components schemas.
+type JSONSchema struct {
+	Schema string             `json:"$schema"`
+	Title  string             `json:"title"`
+	Ref    string             `json:"$ref"`
+	Defs   map[string]*Schema `json:"$defs"`
+}
+
+// SchemaError - violation of the JSON schema at a field path (e.g. posturePolicies[0].frameworkName)
+type SchemaError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e SchemaError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// NewJSONSchema returns the JSON schema generated from the document type
+func NewJSONSchema[T types.DocContent]() *JSONSchema {
+	var content T
+	reflector := newSchemaReflector(jsonSchemaDefsPrefix)
+	ref := reflector.schemaOf(content)
+	return &JSONSchema{
+		Schema: jsonSchemaDialect,
+		Title:  strings.TrimPrefix(ref.Ref, jsonSchemaDefsPrefix),
+		Ref:    ref.Ref,
+		Defs:   reflector.components,
+	}
+}
+
+// Definition returns the schema of the document type, refiners can modify it (e.g. add required fields or enums)
+func (s *JSONSchema) Definition() *Schema {
+	return s.Defs[strings.TrimPrefix(s.Ref, jsonSchemaDefsPrefix)]
+}
+
+// Property returns the schema of a property of the document type, nil if not exist
+func (s *JSONSchema) Property(name string) *Schema {
+	return s.Definition().Properties[name]
+}
+
+// Strict rejects unknown fields in all the objects of the schema
+func (s *JSONSchema) Strict() {
+	for _, def := range s.Defs {
+		closeObjects(def)
+	}
+}
+
+func closeObjects(schema *Schema) {
+	if schema == nil {
+		return
+	}
+	//struct schemas have properties, maps have additional properties only
+	if schema.Type == "object" && schema.Properties != nil && schema.AdditionalProperties == nil {
+		schema.AdditionalProperties = &Schema{Not: &Schema{}}
+	}
+	closeObjects(schema.Items)
+	closeObjects(schema.AdditionalProperties)
+	for _, property := range schema.Properties {
+		closeObjects(property)
+	}
+	for _, oneOf := range schema.OneOf {
+		closeObjects(oneOf)
+	}
+}
+
+// Validate validates a json decoded value (with json.Number numbers), the required fields are validated only when requireFields is true.
+// Null values are valid as they are ignored by json decoding.
+func (s *JSONSchema) Validate(value interface{}, requireFields bool) []SchemaError {
+	return s.validate(&Schema{Ref: s.Ref}, value, "", requireFields)
+}
+
+func (s *JSONSchema) validate(schema *Schema, value interface{}, path string, requireFields bool) []SchemaError {
+	if schema.Ref != "" {
+		def, ok := s.Defs[strings.TrimPrefix(schema.Ref, jsonSchemaDefsPrefix)]
+		if !ok {
+			return []SchemaError{{Path: path, Message: "unknown schema " + schema.Ref}}
+		}
+		schema = def
+	}
+	if schema.Not != nil && reflect.DeepEqual(schema.Not, &Schema{}) {
+		//the false schema of additional properties in strict mode
+		return []SchemaError{{Path: path, Message: "unknown field"}}
+	}
+	if value == nil {
+		return nil
+	}
+	if schema.Not != nil {
+		if len(s.validate(schema.Not, value, path, requireFields)) == 0 {
+			return []SchemaError{{Path: path, Message: "value is not allowed"}}
+		}
+	}
+	if len(schema.OneOf) > 0 {
+		matches := 0
+		for _, oneOf := range schema.OneOf {
+			if len(s.validate(oneOf, value, path, requireFields)) == 0 {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return []SchemaError{{Path: path, Message: "must match exactly one schema"}}
+		}
+	}
+	if len(schema.Enum) > 0 && !enumContains(schema.Enum, value) {
+		return []SchemaError{{Path: path, Message: fmt.Sprintf("must be one of %s", enumString(schema.Enum))}}
+	}
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return []SchemaError{typeError(path, schema.Type, value)}
+		}
+		return s.validateObject(schema, object, path, requireFields)
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return []SchemaError{typeError(path, schema.Type, value)}
+		}
+		var errs []SchemaError
+		if schema.Items != nil {
+			for i, item := range array {
+				errs = append(errs, s.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i), requireFields)...)
+			}
+		}
+		return errs
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return []SchemaError{typeError(path, schema.Type, value)}
+		}
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+				return []SchemaError{{Path: path, Message: "must be a RFC 3339 date-time"}}
+			}
+		}
+	case "integer":
+		number, ok := value.(json.Number)
+		if !ok {
+			return []SchemaError{typeError(path, schema.Type, value)}
+		}
+		if _, err := number.Int64(); err != nil {
+			return []SchemaError{{Path: path, Message: "must be an integer"}}
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return []SchemaError{typeError(path, schema.Type, value)}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []SchemaError{typeError(path, schema.Type, value)}
+		}
+	}
+	return nil
+}
+
+func (s *JSONSchema) validateObject(schema *Schema, object map[string]interface{}, path string, requireFields bool) []SchemaError {
+	var errs []SchemaError
+	if requireFields {
+		for _, field := range schema.Required {
+			if object[field] == nil {
+				errs = append(errs, SchemaError{Path: joinFieldPath(path, field), Message: "field is required"})
+			}
+		}
+	}
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if property, ok := schema.Properties[key]; ok {
+			errs = append(errs, s.validate(property, object[key], joinFieldPath(path, key), requireFields)...)
+		} else if schema.AdditionalProperties != nil {
+			errs = append(errs, s.validate(schema.AdditionalProperties, object[key], joinFieldPath(path, key), requireFields)...)
+		}
+	}
+	return errs
+}
+
+func joinFieldPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+func typeError(path, expectedType string, value interface{}) SchemaError {
+	actualType := "object"
+	switch value.(type) {
+	case string:
+		actualType = "string"
+	case json.Number:
+		actualType = "number"
+	case bool:
+		actualType = "boolean"
+	case []interface{}:
+		actualType = "array"
+	}
+	return SchemaError{Path: path, Message: fmt.Sprintf("expected %s, got %s", expectedType, actualType)}
+}
+
+func enumContains(enum []interface{}, value interface{}) bool {
+	encodedValue, _ := json.Marshal(value)
+	for _, option := range enum {
+		if encodedOption, _ := json.Marshal(option); bytes.Equal(encodedOption, encodedValue) {
+			return true
+		}
+	}
+	return false
+}
+
+func enumString(enum []interface{}) string {
+	options := make([]string, 0, len(enum))
+	for _, option := range enum {
+		encoded, _ := json.Marshal(option)
+		options = append(options, string(encoded))
+	}
+	return strings.Join(options, ", ")
+}
+
+// SchemaValidationMiddleware validates the request body with the JSON schema of the documents before it is decoded.
+// In POST the body can be a document or a list of documents and the required fields are validated,
+// in PUT the body is a document with the fields to update.
+// Requests with strict=true are validated with the strict schema that rejects unknown fields (when not nil).
+func SchemaValidationMiddleware(schema, strictSchema *JSONSchema, post bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		schema := schema
+		if str := c.Query(consts.StrictParam); str != "" && strictSchema != nil {
+			strict, err := strconv.ParseBool(str)
+			if err != nil {
+				ResponseBadRequest(c, consts.StrictParam+" must be true or false")
+				return
+			}
+			if strict {
+				schema = strictSchema
+			}
+		}
+		body, err := requestBody(c)
+		if err != nil {
+			ResponseFailedToBindJson(c, err)
+			return
+		}
+		var value interface{}
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			ResponseFailedToBindJson(c, err)
+			return
+		}
+		var errs []SchemaError
+		if docs, isList := value.([]interface{}); isList && post {
+			for i, doc := range docs {
+				errs = append(errs, schema.validate(&Schema{Ref: schema.Ref}, doc, fmt.Sprintf("[%d]", i), true)...)
+			}
+		} else {
+			errs = schema.Validate(value, post)
+		}
+		if len(errs) > 0 {
+			ResponseSchemaViolation(c, errs)
+			return
+		}
+		c.Next()
+	}
+}
+
+// HandleGetSchema serves the JSON schema of the documents
+func HandleGetSchema(schema *JSONSchema) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", jsonSchemaContentType)
+		c.JSON(http.StatusOK, schema)
+	}
+}
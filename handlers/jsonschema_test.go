@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"bytes"
+	"config-service/types"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeJSONValue(t *testing.T, body string) interface{} {
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+func TestJSONSchemaValidate(t *testing.T) {
+	schema := NewJSONSchema[*types.PostureExceptionPolicy]()
+	assert.Equal(t, "PostureExceptionPolicy", schema.Title)
+	schema.Definition().Required = []string{"name", "policyType"}
+	schema.Property("actions").Items.Enum = []interface{}{"alertOnly", "disable"}
+
+	tests := []struct {
+		name          string
+		body          string
+		requireFields bool
+		strict        bool
+		expected      []SchemaError
+	}{
+		{
+			name: "valid",
+			body: `{"name":"p1","policyType":"postureExceptionPolicy","actions":["alertOnly"],"posturePolicies":[{"frameworkName":"MITRE"}],"attributes":{"a":1}}`,
+		},
+		{
+			name: "null values are ignored",
+			body: `{"name":null,"actions":null}`,
+		},
+		{
+			name:          "required fields",
+			body:          `{"name":"p1"}`,
+			requireFields: true,
+			expected:      []SchemaError{{Path: "policyType", Message: "field is required"}},
+		},
+		{
+			name:     "field path errors",
+			body:     `{"name":1,"actions":["ignore"],"posturePolicies":[{"frameworkName":"MITRE"},{"frameworkName":true}],"resources":{}}`,
+			expected: []SchemaError{{Path: "actions[0]", Message: `must be one of "alertOnly", "disable"`}, {Path: "name", Message: "expected string, got number"}, {Path: "posturePolicies[1].frameworkName", Message: "expected string, got boolean"}, {Path: "resources", Message: "expected array, got object"}},
+		},
+		{
+			name: "unknown fields are allowed",
+			body: `{"name":"p1","posturePolicy":[{"frameworkName":"MITRE"}]}`,
+		},
+		{
+			name:     "unknown fields in strict mode",
+			body:     `{"name":"p1","posturePolicy":[],"posturePolicies":[{"framework":"MITRE"}],"attributes":{"a":1}}`,
+			strict:   true,
+			expected: []SchemaError{{Path: "posturePolicies[0].framework", Message: "unknown field"}, {Path: "posturePolicy", Message: "unknown field"}},
+		},
+		{
+			name:     "not an object",
+			body:     `[]`,
+			expected: []SchemaError{{Path: "", Message: "expected object, got array"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.strict {
+				schema.Strict()
+			}
+			assert.Equal(t, tt.expected, schema.Validate(decodeJSONValue(t, tt.body), tt.requireFields))
+		})
+	}
+}
+
+// the generated schemas must accept the test data documents
+func TestJSONSchemaTestData(t *testing.T) {
+	for file, schema := range map[string]*JSONSchema{
+		"clusters.json":              NewJSONSchema[*types.Cluster](),
+		"posturePolicies.json":       NewJSONSchema[*types.PostureExceptionPolicy](),
+		"vulnerabilityPolicies.json": NewJSONSchema[*types.VulnerabilityExceptionPolicy](),
+		"frameworks.json":            NewJSONSchema[*types.Framework](),
+		"repositories.json":          NewJSONSchema[*types.Repository](),
+		"registryCronJob.json":       NewJSONSchema[*types.RegistryCronJob](),
+	} {
+		t.Run(file, func(t *testing.T) {
+			data, err := os.ReadFile("../test_data/" + file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			docs, _ := decodeJSONValue(t, string(data)).([]interface{})
+			assert.NotEmpty(t, docs)
+			for _, doc := range docs {
+				assert.Empty(t, schema.Validate(doc, true))
+			}
+		})
+	}
+}
+
+func TestSchemaValidationMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	schema := NewJSONSchema[*types.PostureExceptionPolicy]()
+	schema.Definition().Required = []string{"name"}
+	router := gin.New()
+	handler := func(c *gin.Context) {
+		//the body can be bound after the validation
+		var docs []*types.PostureExceptionPolicy
+		if err := c.ShouldBindBodyWith(&docs, binding.JSON); err != nil {
+			var doc *types.PostureExceptionPolicy
+			if err := c.ShouldBindBodyWith(&doc, binding.JSON); err != nil {
+				c.Status(http.StatusTeapot)
+				return
+			}
+		}
+		c.Status(http.StatusOK)
+	}
+	strictSchema := NewJSONSchema[*types.PostureExceptionPolicy]()
+	strictSchema.Strict()
+	router.POST("/policy", SchemaValidationMiddleware(schema, strictSchema, true), handler)
+	router.PUT("/policy", SchemaValidationMiddleware(schema, strictSchema, false), handler)
+
+	tests := []struct {
+		method   string
+		query    string
+		body     string
+		status   int
+		expected string
+	}{
+		{method: http.MethodPost, body: `{"name":"p1"}`, status: http.StatusOK},
+		{method: http.MethodPost, body: `[{"name":"p1"},{"name":"p2"}]`, status: http.StatusOK},
+		{method: http.MethodPost, body: `[{"name":"p1"},{"policyType":1}]`, status: http.StatusBadRequest,
+			expected: `"detail":"[1].name: field is required (and 1 more errors)","instance":"/policy","code":"schema_violation",` +
+				`"details":{"errors":[{"path":"[1].name","message":"field is required"},{"path":"[1].policyType","message":"expected string, got number"}]}`},
+		{method: http.MethodPut, body: `{"policyType":"postureExceptionPolicy"}`, status: http.StatusOK},
+		{method: http.MethodPut, body: `[{"name":"p1"}]`, status: http.StatusBadRequest,
+			expected: `"details":{"errors":[{"path":"","message":"expected object, got array"}]}`},
+		{method: http.MethodPut, body: `{"name":`, status: http.StatusBadRequest, expected: `"code":"invalid_body"`},
+		//unknown fields are rejected only in requests with strict=true
+		{method: http.MethodPut, body: `{"posturePolicy":[]}`, status: http.StatusOK},
+		{method: http.MethodPut, query: "?strict=false", body: `{"posturePolicy":[]}`, status: http.StatusOK},
+		{method: http.MethodPut, query: "?strict=true", body: `{"posturePolicy":[]}`, status: http.StatusBadRequest,
+			expected: `"details":{"errors":[{"path":"posturePolicy","message":"unknown field"}]}`},
+		{method: http.MethodPost, query: "?strict=true", body: `[{"name":"p1","posturePolicy":[]}]`, status: http.StatusBadRequest,
+			expected: `"details":{"errors":[{"path":"[0].posturePolicy","message":"unknown field"}]}`},
+		{method: http.MethodPost, query: "?strict=maybe", body: `{"name":"p1"}`, status: http.StatusBadRequest, expected: `strict must be true or false`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(tt.method, "/policy"+tt.query, bytes.NewBufferString(tt.body))
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tt.status, w.Code, tt.query+tt.body)
+		assert.Contains(t, w.Body.String(), tt.expected, tt.query+tt.body)
+	}
+}
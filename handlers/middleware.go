@@ -25,6 +25,11 @@ func PutFieldsContextMiddleware(fields []string) gin.HandlerFunc {
 	}
 }
 
+func ClientGUIDsContextMiddleware(c *gin.Context) {
+	c.Set(consts.ClientGUIDs, true)
+	c.Next()
+}
+
 func BodyDecoderContextMiddleware[T types.DocContent](decoder *BodyDecoder[T]) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Set(consts.BodyDecoder, decoder)
@@ -39,7 +44,8 @@ func ResponseSenderContextMiddleware[T types.DocContent](sender *ResponseSender[
 	}
 }
 
-// PostValidationMiddleware validate post request and if valid sets one or many DocContents in context for next handler, otherwise abort request
+// PostValidationMiddleware validate post request and if valid sets one or many DocContents in context for next handler, otherwise abort request.
+// JSON and YAML bodies are supported, a YAML stream of multiple documents is a bulk request
 func PostValidationMiddleware[T types.DocContent](validators ...MutatorValidator[T]) func(c *gin.Context) {
 	return func(c *gin.Context) {
 		defer log.LogNTraceEnterExit("HandlePostValidation", c)()
@@ -51,6 +57,9 @@ func PostValidationMiddleware[T types.DocContent](validators ...MutatorValidator
 				ResponseFailedToBindJson(c, err)
 				return
 			}
+		} else if _, err := requestBody(c); err != nil {
+			ResponseFailedToBindJson(c, err)
+			return
 		} else if err := c.ShouldBindBodyWith(&doc, binding.JSON); err != nil || doc == nil {
 			//check if bulk request
 			if err := c.ShouldBindBodyWith(&docs, binding.JSON); err != nil || docs == nil {
@@ -79,7 +88,8 @@ func PostValidationMiddleware[T types.DocContent](validators ...MutatorValidator
 	}
 }
 
-// PutValidationMiddleware validate put request and if valid set DocContent in context for next handler, otherwise abort request
+// PutValidationMiddleware validate put request and if valid set DocContent in context for next handler, otherwise abort request.
+// JSON and YAML bodies are supported
 func PutValidationMiddleware[T types.DocContent](validators ...MutatorValidator[T]) func(c *gin.Context) {
 	return func(c *gin.Context) {
 		defer log.LogNTraceEnterExit("HandlePutValidation", c)()
@@ -91,7 +101,10 @@ func PutValidationMiddleware[T types.DocContent](validators ...MutatorValidator[
 			} else {
 				doc = docs[0]
 			}
-		} else if err := c.ShouldBindJSON(&doc); err != nil {
+		} else if _, err := requestBody(c); err != nil {
+			ResponseFailedToBindJson(c, err)
+			return
+		} else if err := c.ShouldBindBodyWith(&doc, binding.JSON); err != nil {
 			ResponseFailedToBindJson(c, err)
 			return
 		}
@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"config-service/types"
+	"config-service/utils/consts"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPI 3 document of the routes added by AddRoutes, the operations are generated from the router options
+// and the documents schemas are reflected from the DocContent types
+
+type OpenAPIDocument struct {
+	OpenAPI    string                     `json:"openapi"`
+	Info       OpenAPIInfo                `json:"info"`
+	Paths      map[string]OpenAPIPathItem `json:"paths"`
+	Components OpenAPIComponents          `json:"components"`
+	Security   []map[string][]string      `json:"security"`
+}
+
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpenAPIComponents struct {
+	Schemas         map[string]*Schema                `json:"schemas"`
+	SecuritySchemes map[string]*OpenAPISecurityScheme `json:"securitySchemes"`
+}
+
+type OpenAPISecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+// OpenAPIPathItem - operations of a path by lower case method
+type OpenAPIPathItem map[string]*OpenAPIOperation
+
+type OpenAPIOperation struct {
+	OperationID string                      `json:"operationId"`
+	Summary     string                      `json:"summary"`
+	Description string                      `json:"description,omitempty"`
+	Tags        []string                    `json:"tags"`
+	Parameters  []OpenAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+type OpenAPIParameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+var (
+	openAPIMutex     sync.Mutex
+	openAPIPaths     = map[string]OpenAPIPathItem{}
+	openAPIReflector = newSchemaReflector("#/components/schemas/")
+	pathParamsRegex  = regexp.MustCompile(`:([^/]+)`)
+)
+
+// OpenAPISpec returns the OpenAPI document of all the routes added by AddRoutes
+func OpenAPISpec() OpenAPIDocument {
+	openAPIMutex.Lock()
+	defer openAPIMutex.Unlock()
+	paths := make(map[string]OpenAPIPathItem, len(openAPIPaths))
+	for path, item := range openAPIPaths {
+		paths[path] = item
+	}
+	schemas := make(map[string]*Schema, len(openAPIReflector.components))
+	for name, schema := range openAPIReflector.components {
+		schemas[name] = schema
+	}
+	schemas["ListMetadata"] = &Schema{Type: "object", Properties: map[string]*Schema{"count": {Type: "integer", Format: "int32"}}}
+	return OpenAPIDocument{
+		OpenAPI: "3.0.3",
+		Info:    OpenAPIInfo{Title: "config-service", Version: "v1"},
+		Paths:   paths,
+		Components: OpenAPIComponents{
+			Schemas: schemas,
+			SecuritySchemes: map[string]*OpenAPISecurityScheme{
+				"bearer":  {Type: "http", Scheme: "bearer"},
+				"session": {Type: "apiKey", In: "cookie", Name: consts.SessionCookie},
+				"apiKey":  {Type: "apiKey", In: "header", Name: consts.APIKeyHeader},
+			},
+		},
+		Security: []map[string][]string{{"bearer": {}}, {"session": {}}, {"apiKey": {}}},
+	}
+}
+
+// HandleOpenAPISpec serves the OpenAPI document
+func HandleOpenAPISpec(c *gin.Context) {
+	c.JSON(http.StatusOK, OpenAPISpec())
+}
+
+// registerOpenAPIPaths adds the operations of the routes served by the router options under the path
+func registerOpenAPIPaths[T types.DocContent](opts *routerOptions[T], path string) {
+	openAPIMutex.Lock()
+	defer openAPIMutex.Unlock()
+	var content T
+	docSchema := openAPIReflector.schemaOf(content)
+	requestSchema, responseSchema := docSchema, docSchema
+	//custom decoders and senders are JSON only
+	bodyOf, responseOf := documentBody, documentResponse
+	if opts.bodyDecoder != nil {
+		requestSchema = &Schema{Type: "object", Description: "custom request body"}
+		bodyOf = jsonBody
+	}
+	if opts.responseSender != nil {
+		responseSchema = &Schema{Description: "custom response"}
+		responseOf = jsonResponse
+	}
+	v2 := strings.HasPrefix(path, consts.V2Path+"/")
+	ops := openAPIOperations{path: path, tag: strings.TrimPrefix(path, "/"), problemSchema: openAPIReflector.schemaOf(Problem{})}
+	listSchema := func(items *Schema) *Schema {
+		if v2 {
+			return &Schema{Type: "object", Properties: map[string]*Schema{
+				"items":    {Type: "array", Items: items},
+				"metadata": {Ref: "#/components/schemas/ListMetadata"},
+			}}
+		}
+		return &Schema{Type: "array", Items: items}
+	}
+	deletedCountSchema := &Schema{Type: "object", Properties: map[string]*Schema{"deletedCount": {Type: "integer", Format: "int64"}}}
+
+	if opts.serveGet {
+		if !opts.serveGetWithGUIDOnly {
+			get := ops.add(http.MethodGet, "", "get all documents", responseOf(http.StatusOK, listSchema(responseSchema)))
+			if opts.serveGetNamesList {
+				get.Parameters = append(get.Parameters, queryParam(consts.ListParam, "return the documents names only"))
+			}
+			if opts.nameQueryParam != "" {
+				get.Parameters = append(get.Parameters, queryParam(opts.nameQueryParam, "get the document by name"))
+			}
+			if opts.QueryConfig != nil {
+				get.Description = "documents can be filtered by scope query params " + scopeQueryParamsDescription(opts.QueryConfig)
+			}
+			if opts.responseSender == nil {
+				//each line of the stream is a document
+				get.Responses[strconv.Itoa(http.StatusOK)].Content[NDJSONContentType] = OpenAPIMediaType{Schema: responseSchema}
+			}
+		}
+		ops.add(http.MethodGet, "/:"+consts.GUIDField, "get a document by GUID", responseOf(http.StatusOK, responseSchema))
+	}
+	if opts.servePost {
+		postResponseSchema := oneOrMany(responseSchema)
+		if v2 {
+			postResponseSchema = listSchema(responseSchema)
+		}
+		post := ops.add(http.MethodPost, "", "create documents", documentResponse(http.StatusCreated, postResponseSchema))
+		post.RequestBody = bodyOf(oneOrMany(requestSchema))
+		post.Parameters = append(post.Parameters, OpenAPIParameter{Name: consts.IdempotencyKeyHeader, In: "header",
+			Description: "key of a retried request, the response of the first request with the key is replayed", Schema: &Schema{Type: "string"}})
+		post.Parameters = append(post.Parameters, strictParam(opts)...)
+	}
+	if opts.servePut {
+		//v1 PUT responds with the document before and after the update, v2 PUT responds with the updated document
+		putResponseSchema := &Schema{Type: "array", Items: responseSchema}
+		if v2 {
+			putResponseSchema = responseSchema
+		}
+		put := ops.add(http.MethodPut, "", "update a document by GUID in body", documentResponse(http.StatusOK, putResponseSchema))
+		put.RequestBody = bodyOf(requestSchema)
+		put.Parameters = append(put.Parameters, strictParam(opts)...)
+		put = ops.add(http.MethodPut, "/:"+consts.GUIDField, "update a document by GUID in path", documentResponse(http.StatusOK, putResponseSchema))
+		put.RequestBody = bodyOf(requestSchema)
+		put.Parameters = append(put.Parameters, strictParam(opts)...)
+	}
+	if opts.servesSync() {
+		keyName, _, _ := opts.syncKey()
+		syncOp := ops.add(http.MethodPut, syncPath, "sync the documents to the desired set", documentResponse(http.StatusOK, openAPIReflector.schemaOf(SyncResponse{})))
+		syncOp.Description = fmt.Sprintf("documents are matched by %s, unmatched documents are created with the %s attribute, changed documents are updated "+
+			"and documents of the manager that are not in the desired set are deleted", keyName, consts.ManagedByAttribute)
+		syncOp.RequestBody = documentBody(&Schema{Type: "array", Items: requestSchema})
+		syncOp.Parameters = append(syncOp.Parameters,
+			queryParam(consts.DryRunParam, "when true, responds with the plan without applying it"),
+			queryParam(consts.ManagedByParam, "the manager of the synced documents, default "+DefaultManager),
+			queryParam(consts.AdoptParam, "when true, matched documents of other managers or without a manager are updated and marked with the manager"))
+		syncOp.Parameters = append(syncOp.Parameters, strictParam(opts)...)
+	}
+	if opts.serveDelete {
+		if opts.serveDeleteByName {
+			deleteResponseSchema := &Schema{OneOf: []*Schema{responseSchema, deletedCountSchema}}
+			if v2 {
+				deleteResponseSchema = deletedCountSchema
+			}
+			deleteByName := ops.add(http.MethodDelete, "", "delete documents by name", documentResponse(http.StatusOK, deleteResponseSchema))
+			deleteByName.Parameters = append(deleteByName.Parameters, queryParam(opts.nameQueryParam, "names of the documents to delete"))
+		}
+		ops.add(http.MethodDelete, "/:"+consts.GUIDField, "delete a document by GUID", responseOf(http.StatusOK, responseSchema))
+	}
+	if opts.bodyDecoder == nil && (opts.servePost || opts.servePut) {
+		schema := ops.add(http.MethodGet, "/schema", "get the JSON schema of the documents", jsonResponse(http.StatusOK, &Schema{Type: "object"}))
+		schema.Description = "POST and PUT request bodies are validated with the schema"
+	}
+	for _, containerHandler := range opts.containersHandlers {
+		field := "modified"
+		if containerHandler.containerType == ContainerTypeArray {
+			field = "added"
+		}
+		if containerHandler.servePut {
+			put := ops.add(http.MethodPut, containerHandler.path, "add items to the document "+string(containerHandler.containerType),
+				documentResponse(http.StatusOK, &Schema{Type: "object", Properties: map[string]*Schema{field: {Type: "integer", Format: "int64"}}}))
+			put.RequestBody = jsonBody(&Schema{})
+		}
+		if containerHandler.serveDelete {
+			if containerHandler.containerType == ContainerTypeArray {
+				field = "removed"
+			}
+			ops.add(http.MethodDelete, containerHandler.path, "remove items from the document "+string(containerHandler.containerType),
+				documentResponse(http.StatusOK, &Schema{Type: "object", Properties: map[string]*Schema{field: {Type: "integer", Format: "int64"}}}))
+		}
+	}
+}
+
+// openAPIOperations adds operations of a router group
+type openAPIOperations struct {
+	path          string
+	tag           string
+	problemSchema *Schema
+}
+
+func (o *openAPIOperations) add(method, relativePath, summary string, responses map[string]*OpenAPIResponse) *OpenAPIOperation {
+	ginPath := o.path + relativePath
+	path := pathParamsRegex.ReplaceAllString(ginPath, "{$1}")
+	op := &OpenAPIOperation{
+		OperationID: operationID(method, ginPath),
+		Summary:     summary,
+		Tags:        []string{o.tag},
+		Responses:   responses,
+	}
+	for _, match := range pathParamsRegex.FindAllStringSubmatch(ginPath, -1) {
+		op.Parameters = append(op.Parameters, OpenAPIParameter{Name: match[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	for code, response := range errorResponses(o.problemSchema) {
+		responses[code] = response
+	}
+	if openAPIPaths[path] == nil {
+		openAPIPaths[path] = OpenAPIPathItem{}
+	}
+	openAPIPaths[path][strings.ToLower(method)] = op
+	return op
+}
+
+// operationID returns a unique operation id of the method and path e.g. get_cluster_guid
+func operationID(method, ginPath string) string {
+	parts := []string{strings.ToLower(method)}
+	for _, part := range strings.Split(ginPath, "/") {
+		if part = strings.TrimPrefix(part, ":"); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "_")
+}
+
+func jsonResponse(code int, schema *Schema) map[string]*OpenAPIResponse {
+	return map[string]*OpenAPIResponse{
+		strconv.Itoa(code): {Description: http.StatusText(code), Content: map[string]OpenAPIMediaType{"application/json": {Schema: schema}}},
+	}
+}
+
+// documentResponse - response of the generic handlers, YAML is returned when the Accept header asks for it
+func documentResponse(code int, schema *Schema) map[string]*OpenAPIResponse {
+	responses := jsonResponse(code, schema)
+	responses[strconv.Itoa(code)].Content[YAMLContentType] = OpenAPIMediaType{Schema: schema}
+	return responses
+}
+
+func errorResponses(problemSchema *Schema) map[string]*OpenAPIResponse {
+	responses := map[string]*OpenAPIResponse{}
+	for _, code := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError} {
+		responses[strconv.Itoa(code)] = &OpenAPIResponse{Description: http.StatusText(code),
+			Content: map[string]OpenAPIMediaType{ProblemContentType: {Schema: problemSchema}}}
+	}
+	return responses
+}
+
+func jsonBody(schema *Schema) *OpenAPIRequestBody {
+	return &OpenAPIRequestBody{Required: true, Content: map[string]OpenAPIMediaType{"application/json": {Schema: schema}}}
+}
+
+// documentBody - request body of the generic handlers, JSON or YAML
+func documentBody(schema *Schema) *OpenAPIRequestBody {
+	body := jsonBody(schema)
+	body.Content[YAMLContentType] = OpenAPIMediaType{Schema: schema}
+	return body
+}
+
+func queryParam(name, description string) OpenAPIParameter {
+	return OpenAPIParameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+// strictParam returns the strict query param of requests with bodies that are validated by the JSON schema
+func strictParam[T types.DocContent](opts *routerOptions[T]) []OpenAPIParameter {
+	if opts.bodyDecoder != nil {
+		return nil
+	}
+	return []OpenAPIParameter{queryParam(consts.StrictParam, "when true, unknown fields of the body are rejected")}
+}
+
+func oneOrMany(schema *Schema) *Schema {
+	return &Schema{OneOf: []*Schema{schema, {Type: "array", Items: schema}}}
+}
+
+func scopeQueryParamsDescription(conf *QueryParamsConfig) string {
+	params := []string{}
+	for param := range conf.Params2Query {
+		if param == "" {
+			params = append(params, "<field>=<value>")
+		} else {
+			params = append(params, param+".<key>=<value>")
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, ", ")
+}
@@ -22,6 +22,49 @@ const (
 	DocumentNotFound = "document not found"
 )
 
+// ErrorCode - stable machine readable code of error responses
+type ErrorCode string
+
+const (
+	ErrorCodeBadRequest        ErrorCode = "bad_request"
+	ErrorCodeInvalidBody       ErrorCode = "invalid_body"
+	ErrorCodeSchemaViolation   ErrorCode = "schema_violation"
+	ErrorCodeMissingParam      ErrorCode = "missing_param"
+	ErrorCodeDuplicateKey      ErrorCode = "duplicate_key"
+	ErrorCodeReadOnlyField     ErrorCode = "readonly_field"
+	ErrorCodeBulkNotSupported  ErrorCode = "bulk_not_supported"
+	ErrorCodeUnauthorized      ErrorCode = "unauthorized"
+	ErrorCodeForbidden         ErrorCode = "forbidden"
+	ErrorCodeMissingScope      ErrorCode = "missing_scope"
+	ErrorCodeMissingPermission ErrorCode = "missing_permission"
+	ErrorCodeNotFound          ErrorCode = "not_found"
+	ErrorCodeRouteNotFound     ErrorCode = "route_not_found"
+	ErrorCodeIdempotencyReused ErrorCode = "idempotency_key_reused"
+	ErrorCodeIdempotencyInUse  ErrorCode = "idempotency_key_in_use"
+	ErrorCodeRateLimited       ErrorCode = "rate_limited"
+	ErrorCodeRequestCanceled   ErrorCode = "request_canceled"
+	ErrorCodeNotSupported      ErrorCode = "not_supported"
+	ErrorCodeInternal          ErrorCode = "internal_error"
+)
+
+const (
+	ProblemContentType = "application/problem+json"
+	problemTypePrefix  = "urn:config-service:problem:"
+)
+
+// Problem - RFC 7807 problem details of error responses
+type Problem struct {
+	Type     string      `json:"type"`
+	Title    string      `json:"title"`
+	Status   int         `json:"status"`
+	Detail   string      `json:"detail"`
+	Instance string      `json:"instance"`
+	Code     ErrorCode   `json:"code"`
+	Details  interface{} `json:"details,omitempty"`
+	TraceID  string      `json:"traceId,omitempty"`
+	Error    string      `json:"error"` //same as detail, for clients of the former {"error":"<message>"} responses
+}
+
 var pluralize = plural.NewClient()
 
 func ResponseInternalServerError(c *gin.Context, msg string, err error) {
@@ -40,17 +83,17 @@ func ResponseInternalServerError(c *gin.Context, msg string, err error) {
 	if err != nil {
 		errText = fmt.Sprintf("%s error: %s", msg, err.Error())
 	}
-	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": errText})
+	ResponseProblem(c, http.StatusInternalServerError, ErrorCodeInternal, errText, nil)
 }
 
 func ResponseCanceled(c *gin.Context) {
 	log.LogNTrace("request canceled", c)
-	c.AbortWithStatusJSON(http.StatusNoContent, gin.H{"error": "request canceled"})
+	ResponseProblem(c, http.StatusNoContent, ErrorCodeRequestCanceled, "request canceled", nil)
 }
 
 func ResponseDocumentNotFound(c *gin.Context) {
 	log.LogNTrace(DocumentNotFound, c)
-	c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": DocumentNotFound})
+	ResponseProblem(c, http.StatusNotFound, ErrorCodeNotFound, DocumentNotFound, nil)
 }
 
 func ResponseDuplicateNames(c *gin.Context, names ...string) {
@@ -82,7 +125,15 @@ func ResponseDuplicateKeysNValues(c *gin.Context, key2Values map[string][]string
 			msg = fmt.Sprintf("%s %s already exist", pluralize.Plural(key), strings.Join(values, ","))
 		}
 	}
-	ResponseBadRequest(c, msg)
+	log.LogNTrace(msg, c)
+	ResponseProblem(c, http.StatusBadRequest, ErrorCodeDuplicateKey, msg, key2Values)
+}
+
+// ResponseConflict responds that a document with the key value already exists, e.g. a tenant that is created with the guid of an existing tenant
+func ResponseConflict(c *gin.Context, key, value string) {
+	msg := fmt.Sprintf("%s %s already exists", key, value)
+	log.LogNTrace(msg, c)
+	ResponseProblem(c, http.StatusConflict, ErrorCodeDuplicateKey, msg, map[string][]string{key: {value}})
 }
 
 func ResponseMissingGUID(c *gin.Context) {
@@ -94,25 +145,89 @@ func ResponseMissingName(c *gin.Context) {
 }
 
 func ResponseMissingKey(c *gin.Context, key string) {
-	ResponseBadRequest(c, fmt.Sprintf(MissingKey, key))
+	msg := fmt.Sprintf(MissingKey, key)
+	log.LogNTrace(msg, c)
+	ResponseProblem(c, http.StatusBadRequest, ErrorCodeMissingParam, msg, gin.H{"param": key})
 }
 
 func ResponseMissingQueryParam(c *gin.Context, paramNem string) {
-	ResponseBadRequest(c, fmt.Sprintf(MissingKey, paramNem+" query param"))
+	msg := fmt.Sprintf(MissingKey, paramNem+" query param")
+	log.LogNTrace(msg, c)
+	ResponseProblem(c, http.StatusBadRequest, ErrorCodeMissingParam, msg, gin.H{"param": paramNem, "in": "query"})
 }
 
 func ResponseBulkNotSupported(c *gin.Context) {
-	ResponseBadRequest(c, "bulk operations are not supported")
+	msg := "bulk operations are not supported"
+	log.LogNTrace(msg, c)
+	ResponseProblem(c, http.StatusBadRequest, ErrorCodeBulkNotSupported, msg, nil)
 }
 
 func ResponseBadRequest(c *gin.Context, msg string) {
 	log.LogNTrace(msg, c)
-	c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg})
+	ResponseError(c, http.StatusBadRequest, msg)
+}
+
+func ResponseForbidden(c *gin.Context, msg string) {
+	log.LogNTrace(msg, c)
+	ResponseError(c, http.StatusForbidden, msg)
 }
 
 func ResponseFailedToBindJson(c *gin.Context, err error) {
 	log.LogNTraceError("failed to bind json", err, c)
-	c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	ResponseProblem(c, http.StatusBadRequest, ErrorCodeInvalidBody, err.Error(), nil)
+}
+
+// ResponseSchemaViolation responds with the field path errors of a body that is not valid by the documents JSON schema
+func ResponseSchemaViolation(c *gin.Context, errs []SchemaError) {
+	msg := errs[0].Error()
+	if len(errs) > 1 {
+		msg = fmt.Sprintf("%s (and %d more errors)", msg, len(errs)-1)
+	}
+	log.LogNTrace("schema violation: "+msg, c)
+	ResponseProblem(c, http.StatusBadRequest, ErrorCodeSchemaViolation, msg, gin.H{"errors": errs})
+}
+
+// ResponseError aborts the request with a problem response with the default error code of the status
+func ResponseError(c *gin.Context, status int, msg string) {
+	ResponseProblem(c, status, statusErrorCode(status), msg, nil)
+}
+
+// ResponseProblem aborts the request with a problem details response, details are optional structured information of the error
+func ResponseProblem(c *gin.Context, status int, code ErrorCode, msg string, details interface{}) {
+	problem := Problem{
+		Type:     problemTypePrefix + string(code),
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   msg,
+		Instance: c.Request.URL.Path,
+		Code:     code,
+		Details:  details,
+		Error:    msg,
+	}
+	if span := log.GetTraceSpan(c); span != nil {
+		problem.TraceID = span.SpanContext().TraceID().String()
+	}
+	c.Abort()
+	//the content type is not replaced by the json renderer
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(status, problem)
+}
+
+func statusErrorCode(status int) ErrorCode {
+	switch status {
+	case http.StatusUnauthorized:
+		return ErrorCodeUnauthorized
+	case http.StatusForbidden:
+		return ErrorCodeForbidden
+	case http.StatusNotFound:
+		return ErrorCodeNotFound
+	case http.StatusTooManyRequests:
+		return ErrorCodeRateLimited
+	}
+	if status >= http.StatusInternalServerError {
+		return ErrorCodeInternal
+	}
+	return ErrorCodeBadRequest
 }
 
 func docResponse[T types.DocContent](c *gin.Context, doc *T) {
@@ -124,12 +239,14 @@ func docResponse[T types.DocContent](c *gin.Context, doc *T) {
 		sender(c, *doc, nil)
 		return
 	}
-	c.JSON(http.StatusOK, doc)
-
+	if NotModified(c, ETag(c, *doc), LastModified(*doc)) {
+		return
+	}
+	ResponseNegotiated(c, http.StatusOK, doc)
 }
 
 func docsResponse[T types.DocContent](c *gin.Context, docs []T) {
-	if docs == nil {
+	if docs == nil && !IsV2Request(c) {
 		ResponseDocumentNotFound(c)
 		return
 	}
@@ -137,5 +254,13 @@ func docsResponse[T types.DocContent](c *gin.Context, docs []T) {
 		sender(c, nil, docs)
 		return
 	}
-	c.JSON(http.StatusOK, docs)
+	//lists have no Last-Modified, deleted documents do not change the latest updatedTime
+	if NotModified(c, docsETag(c, docs), nil) {
+		return
+	}
+	if IsV2Request(c) {
+		listResponse(c, http.StatusOK, docs)
+		return
+	}
+	ResponseNegotiated(c, http.StatusOK, docs)
 }
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseProblem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name     string
+		respond  func(c *gin.Context)
+		status   int
+		expected string
+	}{
+		{
+			name:    "duplicate keys",
+			respond: func(c *gin.Context) { ResponseDuplicateKey(c, "name", "cluster1") },
+			status:  http.StatusBadRequest,
+			expected: `{"type":"urn:config-service:problem:duplicate_key","title":"Bad Request","status":400,"detail":"name cluster1 already exists",` +
+				`"instance":"/test","code":"duplicate_key","details":{"name":["cluster1"]},"error":"name cluster1 already exists"}`,
+		},
+		{
+			name:    "missing query param",
+			respond: func(c *gin.Context) { ResponseMissingQueryParam(c, "customerGUID") },
+			status:  http.StatusBadRequest,
+			expected: `{"type":"urn:config-service:problem:missing_param","title":"Bad Request","status":400,"detail":"customerGUID query param is required",` +
+				`"instance":"/test","code":"missing_param","details":{"in":"query","param":"customerGUID"},"error":"customerGUID query param is required"}`,
+		},
+		{
+			name:    "not found",
+			respond: ResponseDocumentNotFound,
+			status:  http.StatusNotFound,
+			expected: `{"type":"urn:config-service:problem:not_found","title":"Not Found","status":404,"detail":"document not found",` +
+				`"instance":"/test","code":"not_found","error":"document not found"}`,
+		},
+		{
+			name:    "default code of status",
+			respond: func(c *gin.Context) { ResponseError(c, http.StatusUnauthorized, "Unauthorized") },
+			status:  http.StatusUnauthorized,
+			expected: `{"type":"urn:config-service:problem:unauthorized","title":"Unauthorized","status":401,"detail":"Unauthorized",` +
+				`"instance":"/test","code":"unauthorized","error":"Unauthorized"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/test", tt.respond)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expected, w.Body.String())
+		})
+	}
+}
@@ -4,33 +4,40 @@ import (
 	"config-service/types"
 	"config-service/utils/consts"
 	"fmt"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 // router options
 type routerOptions[T types.DocContent] struct {
-	dbCollection              string                     //mandatory db collection name
-	path                      string                     //mandatory uri path
-	serveGet                  bool                       //default true, serve GET /<path> to get all documents and GET /<path>/<GUID> to get document by GUID
-	serveGetNamesList         bool                       //default true, GET will return all documents names if "list" query param exist
-	serveGetWithGUIDOnly      bool                       //default false, GET will return the document by GUID only
-	serveGetIncludeGlobalDocs bool                       //default false, when true, in GET all the response will include global documents (with customers[""])
-	servePost                 bool                       //default true, serve POST
-	servePut                  bool                       //default true, serve PUT /<path> to update document by GUID in body and PUT /<path>/<GUID> to update document by GUID in path
-	serveDelete               bool                       //default true, serve DELETE  /<path>/<GUID> to delete document by GUID in path
-	serveDeleteByName         bool                       //default false, when true, DELETE will check for name param and will delete the document by name
-	validatePostUniqueName    bool                       //default true, POST will validate that the name is unique
-	validatePutGUID           bool                       //default true, PUT will validate GUID existence in body or path
-	nameQueryParam            string                     //default empty, the param name that indicates query by name (e.g. clusterName) when set GET will check for this param and will return the document by name
-	QueryConfig               *QueryParamsConfig         //default nil, when set, GET will check for the specified query params and will return the documents by the query params
-	uniqueShortName           func(T) string             //default nil, when set, POST will create a unique short name (aka "alias") attribute from the value returned from the function & Put will validate that the short name is not deleted
-	putValidators             []MutatorValidator[T]      //default nil, when set, PUT will call the mutators/validators before updating the document
-	postValidators            []MutatorValidator[T]      //default nil, when set, POST will call the mutators/validators before creating the document
-	bodyDecoder               BodyDecoder[T]             //default nil, when set, replace the default body decoder
-	responseSender            ResponseSender[T]          //default nil, when set, replace the default response sender
-	putFields                 []string                   //default nil, when set, PUT will update only the specified fields
+	dbCollection              string                    //mandatory db collection name
+	path                      string                    //mandatory uri path
+	serveGet                  bool                      //default true, serve GET /<path> to get all documents and GET /<path>/<GUID> to get document by GUID
+	serveGetNamesList         bool                      //default true, GET will return all documents names if "list" query param exist
+	serveGetWithGUIDOnly      bool                      //default false, GET will return the document by GUID only
+	serveGetIncludeGlobalDocs bool                      //default false, when true, in GET all the response will include global documents (with customers[""])
+	servePost                 bool                      //default true, serve POST
+	servePut                  bool                      //default true, serve PUT /<path> to update document by GUID in body and PUT /<path>/<GUID> to update document by GUID in path
+	serveDelete               bool                      //default true, serve DELETE  /<path>/<GUID> to delete document by GUID in path
+	serveDeleteByName         bool                      //default false, when true, DELETE will check for name param and will delete the document by name
+	validatePostUniqueName    bool                      //default true, POST will validate that the name is unique
+	validatePutGUID           bool                      //default true, PUT will validate GUID existence in body or path
+	nameQueryParam            string                    //default empty, the param name that indicates query by name (e.g. clusterName) when set GET will check for this param and will return the document by name
+	QueryConfig               *QueryParamsConfig        //default nil, when set, GET will check for the specified query params and will return the documents by the query params
+	uniqueShortName           func(T) string            //default nil, when set, POST will create a unique short name (aka "alias") attribute from the value returned from the function & Put will validate that the short name is not deleted
+	putValidators             []MutatorValidator[T]     //default nil, when set, PUT will call the mutators/validators before updating the document
+	postValidators            []MutatorValidator[T]     //default nil, when set, POST will call the mutators/validators before creating the document
+	bodyDecoder               BodyDecoder[T]            //default nil, when set, replace the default body decoder
+	responseSender            ResponseSender[T]         //default nil, when set, replace the default response sender
+	putFields                 []string                  //default nil, when set, PUT will update only the specified fields
 	containersHandlers        []containerHandlerOptions //default nil, list of container handlers to put and remove items from document's containers
+	v2Path                    string                    //default empty, when set, the routes are served also under this /v2 path with v2 responses
+	schemaRefiners            []func(*JSONSchema)       //default nil, when set, the refiners modify the JSON schema generated from the document type (e.g. add required fields or enums)
+	strictSchema              bool                      //default false, when true, POST and PUT reject unknown fields
+	clientGUIDs               bool                      //default false, when true, POST keeps the GUIDs of the documents in the body (validated as UUIDs) instead of generating new GUIDs
+	serveSync                 bool                      //default true, serve PUT /<path>/sync to make the customer documents as the desired set in the body, when GET, POST, PUT and DELETE are served with the default decoder and fields
+	syncKey                   UniqueKeyValueInfo[T]     //default name, the key that matches the desired documents of sync to the existing documents
 
 }
 
@@ -60,6 +67,8 @@ func newRouterOptions[T types.DocContent]() *routerOptions[T] {
 		serveGetNamesList:         true,
 		serveGetIncludeGlobalDocs: false,
 		serveDeleteByName:         false,
+		serveSync:                 true,
+		syncKey:                   NameKeyGetter[T],
 	}
 }
 
@@ -69,8 +78,48 @@ func AddRoutes[T types.DocContent](g *gin.Engine, options ...RouterOption[T]) *g
 	if err := opts.validate(); err != nil {
 		panic(err)
 	}
-	routerGroup := g.Group(opts.path)
+	registerScopedPath(opts.path)
+	registerDocType(opts)
+	registerOpenAPIPaths(opts, opts.path)
+	schema, strictSchema := opts.jsonSchema(), opts.strictJSONSchema()
+	routerGroup := addRouterGroup(g, opts.path, opts, schema, strictSchema)
+	if opts.v2Path != "" {
+		registerV2Path(opts.v2Path, opts.path)
+		registerOpenAPIPaths(opts, opts.v2Path)
+		addRouterGroup(g, opts.v2Path, opts, schema, strictSchema)
+	}
+	return routerGroup
+}
+
+// jsonSchema returns the refined JSON schema of the documents, nil when the body is decoded by a custom decoder
+func (opts *routerOptions[T]) jsonSchema() *JSONSchema {
+	if opts.bodyDecoder != nil || (!opts.servePost && !opts.servePut) {
+		return nil
+	}
+	schema := NewJSONSchema[T]()
+	for _, refine := range opts.schemaRefiners {
+		refine(schema)
+	}
+	if opts.strictSchema {
+		schema.Strict()
+	}
+	return schema
+}
+
+// strictJSONSchema returns the JSON schema of the documents that rejects unknown fields, used in requests with strict=true
+func (opts *routerOptions[T]) strictJSONSchema() *JSONSchema {
+	schema := opts.jsonSchema()
+	if schema != nil {
+		schema.Strict()
+	}
+	return schema
+}
+
+// addRouterGroup adds the routes served by the router options under the path
+func addRouterGroup[T types.DocContent](g *gin.Engine, path string, opts *routerOptions[T], schema, strictSchema *JSONSchema) *gin.RouterGroup {
+	routerGroup := g.Group(path)
 	//add middleware
+	routerGroup.Use(APIKeyScopesMiddleware(opts.path))
 	routerGroup.Use(DBContextMiddleware(opts.dbCollection))
 	if opts.responseSender != nil {
 		routerGroup.Use(ResponseSenderContextMiddleware(&opts.responseSender))
@@ -81,8 +130,18 @@ func AddRoutes[T types.DocContent](g *gin.Engine, options ...RouterOption[T]) *g
 	if opts.putFields != nil {
 		routerGroup.Use(PutFieldsContextMiddleware(opts.putFields))
 	}
+	if opts.clientGUIDs {
+		routerGroup.Use(ClientGUIDsContextMiddleware)
+	}
 
 	//add routes
+	if opts.servesSync() {
+		syncHandlers := []gin.HandlerFunc{HandleSync(opts)}
+		if schema != nil {
+			syncHandlers = append([]gin.HandlerFunc{SchemaValidationMiddleware(schema, strictSchema, true)}, syncHandlers...)
+		}
+		routerGroup.PUT(syncPath, syncHandlers...)
+	}
 	if opts.serveGet {
 		if !opts.serveGetWithGUIDOnly {
 			routerGroup.GET("", HandleGet(opts))
@@ -91,6 +150,9 @@ func AddRoutes[T types.DocContent](g *gin.Engine, options ...RouterOption[T]) *g
 	}
 	if opts.servePost {
 		postValidators := []MutatorValidator[T]{}
+		if opts.clientGUIDs {
+			postValidators = append(postValidators, ValidateClientGUIDs[T])
+		}
 		if opts.validatePostUniqueName {
 			postValidators = append(postValidators, ValidateUniqueValues(NameKeyGetter[T]))
 		}
@@ -98,7 +160,11 @@ func AddRoutes[T types.DocContent](g *gin.Engine, options ...RouterOption[T]) *g
 			postValidators = append(postValidators, ValidatePostAttributeShortName(opts.uniqueShortName))
 		}
 		postValidators = append(postValidators, opts.postValidators...)
-		routerGroup.POST("", HandlePostDocWithValidation(postValidators...)...)
+		postHandlers := HandlePostDocWithValidation(postValidators...)
+		if schema != nil {
+			postHandlers = append([]gin.HandlerFunc{SchemaValidationMiddleware(schema, strictSchema, true)}, postHandlers...)
+		}
+		routerGroup.POST("", postHandlers...)
 	}
 	if opts.servePut {
 		putValidators := []MutatorValidator[T]{}
@@ -109,8 +175,15 @@ func AddRoutes[T types.DocContent](g *gin.Engine, options ...RouterOption[T]) *g
 			putValidators = append(putValidators, ValidatePutAttributerShortName[T])
 		}
 		putValidators = append(putValidators, opts.putValidators...)
-		routerGroup.PUT("", HandlePutDocWithValidation(putValidators...)...)
-		routerGroup.PUT("/:"+consts.GUIDField, HandlePutDocWithValidation(putValidators...)...)
+		putHandlers := HandlePutDocWithValidation(putValidators...)
+		if schema != nil {
+			putHandlers = append([]gin.HandlerFunc{SchemaValidationMiddleware(schema, strictSchema, false)}, putHandlers...)
+		}
+		routerGroup.PUT("", putHandlers...)
+		routerGroup.PUT("/:"+consts.GUIDField, putHandlers...)
+	}
+	if schema != nil {
+		routerGroup.GET("/schema", HandleGetSchema(schema))
 	}
 	if opts.serveDelete {
 		if opts.serveDeleteByName {
@@ -141,9 +214,10 @@ func AddRoutes[T types.DocContent](g *gin.Engine, options ...RouterOption[T]) *g
 }
 
 // Common router config for policies
-func AddPolicyRoutes[T types.DocContent](g *gin.Engine, path, dbCollection string, paramConf *QueryParamsConfig) *gin.RouterGroup {
-	return AddRoutes(g, NewRouterOptionsBuilder[T]().
+func AddPolicyRoutes[T types.DocContent](g *gin.Engine, path, v2Path, dbCollection string, paramConf *QueryParamsConfig, options ...RouterOption[T]) *gin.RouterGroup {
+	return AddRoutes(g, append(NewRouterOptionsBuilder[T]().
 		WithPath(path).
+		WithV2Path(v2Path).
 		WithDBCollection(dbCollection).
 		WithNameQuery(consts.PolicyNameParam).
 		WithQueryConfig(paramConf).
@@ -151,7 +225,7 @@ func AddPolicyRoutes[T types.DocContent](g *gin.Engine, path, dbCollection strin
 		WithDeleteByName(true).
 		WithValidatePostUniqueName(true).
 		WithValidatePutGUID(true).
-		Get()...)
+		Get(), options...)...)
 }
 
 func (opts *routerOptions[T]) apply(options []RouterOption[T]) {
@@ -176,9 +250,17 @@ func (opts *routerOptions[T]) validate() error {
 	if opts.serveGetWithGUIDOnly && !opts.serveGet {
 		return fmt.Errorf("serveGetWithGUIDOnly can only be true when serveGet is true")
 	}
+	if opts.v2Path != "" && !strings.HasPrefix(opts.v2Path, consts.V2Path+"/") {
+		return fmt.Errorf("v2Path must start with %s/", consts.V2Path)
+	}
 	return nil
 }
 
+// servesSync returns true when the sync route is served, sync creates, updates and deletes documents as the generic handlers
+func (opts *routerOptions[T]) servesSync() bool {
+	return opts.serveSync && opts.serveGet && opts.servePost && opts.servePut && opts.serveDelete && opts.bodyDecoder == nil && opts.putFields == nil
+}
+
 type RouterOption[T types.DocContent] func(*routerOptions[T])
 
 type RouterOptionsBuilder[T types.DocContent] struct {
@@ -228,6 +310,48 @@ func (b *RouterOptionsBuilder[T]) WithPath(path string) *RouterOptionsBuilder[T]
 	return b
 }
 
+func (b *RouterOptionsBuilder[T]) WithV2Path(v2Path string) *RouterOptionsBuilder[T] {
+	b.options = append(b.options, func(opts *routerOptions[T]) {
+		opts.v2Path = v2Path
+	})
+	return b
+}
+
+func (b *RouterOptionsBuilder[T]) WithSchemaRefiner(refine func(schema *JSONSchema)) *RouterOptionsBuilder[T] {
+	b.options = append(b.options, func(opts *routerOptions[T]) {
+		opts.schemaRefiners = append(opts.schemaRefiners, refine)
+	})
+	return b
+}
+
+func (b *RouterOptionsBuilder[T]) WithStrictSchema(strictSchema bool) *RouterOptionsBuilder[T] {
+	b.options = append(b.options, func(opts *routerOptions[T]) {
+		opts.strictSchema = strictSchema
+	})
+	return b
+}
+
+func (b *RouterOptionsBuilder[T]) WithClientGUIDs(clientGUIDs bool) *RouterOptionsBuilder[T] {
+	b.options = append(b.options, func(opts *routerOptions[T]) {
+		opts.clientGUIDs = clientGUIDs
+	})
+	return b
+}
+
+func (b *RouterOptionsBuilder[T]) WithServeSync(serveSync bool) *RouterOptionsBuilder[T] {
+	b.options = append(b.options, func(opts *routerOptions[T]) {
+		opts.serveSync = serveSync
+	})
+	return b
+}
+
+func (b *RouterOptionsBuilder[T]) WithSyncKey(syncKey UniqueKeyValueInfo[T]) *RouterOptionsBuilder[T] {
+	b.options = append(b.options, func(opts *routerOptions[T]) {
+		opts.syncKey = syncKey
+	})
+	return b
+}
+
 func (b *RouterOptionsBuilder[T]) WithServeGet(serveGet bool) *RouterOptionsBuilder[T] {
 	b.options = append(b.options, func(opts *routerOptions[T]) {
 		opts.serveGet = serveGet
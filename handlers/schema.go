@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"config-service/types"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Schema - OpenAPI 3 schema object (a subset of JSON schema)
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Not                  *Schema            `json:"not,omitempty"`
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	secretType          = reflect.TypeOf(types.Secret(""))
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	invalidNameChars    = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+)
+
+// schemaReflector reflects schemas of go types according to their json encoding,
+// named struct types are added to the components schemas and referenced by name with the ref prefix
+type schemaReflector struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+	refPrefix  string
+}
+
+func newSchemaReflector(refPrefix string) *schemaReflector {
+	return &schemaReflector{components: map[string]*Schema{}, names: map[reflect.Type]string{}, refPrefix: refPrefix}
+}
+
+// schemaOf returns the schema of the type of value
+func (r *schemaReflector) schemaOf(value interface{}) *Schema {
+	return r.reflect(reflect.TypeOf(value))
+}
+
+func (r *schemaReflector) reflect(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == secretType:
+		return &Schema{Type: "string", Description: "write only, encoded as null in responses"}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType),
+		reflect.PointerTo(t).Implements(jsonUnmarshalerType):
+		//custom json encoding
+		return &Schema{}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: r.reflect(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.reflect(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t)
+		}
+		name, ok := r.names[t]
+		if !ok {
+			name = r.componentName(t)
+			r.names[t] = name
+			//add before reflecting the fields to support recursive types
+			r.components[name] = &Schema{}
+			*r.components[name] = *r.structSchema(t)
+		}
+		return &Schema{Ref: r.refPrefix + name}
+	}
+	//interfaces and other kinds can hold any value
+	return &Schema{}
+}
+
+func (r *schemaReflector) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	r.addFields(schema, t)
+	return schema
+}
+
+func (r *schemaReflector) addFields(schema *Schema, t reflect.Type) {
+	embedded := []reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			//embedded struct fields are promoted unless hidden by outer fields
+			embedded = append(embedded, fieldType)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if _, exists := schema.Properties[name]; exists {
+			continue
+		}
+		if strings.Contains(opts, "string") {
+			schema.Properties[name] = &Schema{Type: "string"}
+			continue
+		}
+		schema.Properties[name] = r.reflect(field.Type)
+	}
+	for _, embeddedType := range embedded {
+		r.addFields(schema, embeddedType)
+	}
+}
+
+// componentName returns the type name, or the package and type name if the name is used by another type
+func (r *schemaReflector) componentName(t reflect.Type) string {
+	name := invalidNameChars.ReplaceAllString(t.Name(), "_")
+	if _, taken := r.components[name]; !taken {
+		return name
+	}
+	pkg := t.PkgPath()
+	pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+	return invalidNameChars.ReplaceAllString(pkg, "_") + "." + name
+}
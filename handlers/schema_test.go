@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type schemaTestBase struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+type schemaTestDoc struct {
+	schemaTestBase `json:",inline"`
+	Kind           int                    `json:"kind"`
+	Tags           []string               `json:"tags,omitempty"`
+	Attributes     map[string]interface{} `json:"attributes"`
+	Created        *time.Time             `json:"created"`
+	Count          int64                  `json:"count,string"`
+	Children       []*schemaTestDoc       `json:"children"`
+	Ignored        string                 `json:"-"`
+	internal       string
+}
+
+func TestSchemaReflector(t *testing.T) {
+	r := newSchemaReflector("#/components/schemas/")
+	assert.Equal(t, &Schema{Ref: "#/components/schemas/schemaTestDoc"}, r.schemaOf(&schemaTestDoc{}))
+	ref := &Schema{Ref: "#/components/schemas/schemaTestDoc"}
+	assert.Equal(t, map[string]*Schema{
+		"schemaTestDoc": {Type: "object", Properties: map[string]*Schema{
+			"name":       {Type: "string"},
+			"kind":       {Type: "integer", Format: "int32"},
+			"tags":       {Type: "array", Items: &Schema{Type: "string"}},
+			"attributes": {Type: "object", AdditionalProperties: &Schema{}},
+			"created":    {Type: "string", Format: "date-time"},
+			"count":      {Type: "string"},
+			"children":   {Type: "array", Items: ref},
+		}},
+	}, r.components)
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Type: "string", Format: "byte"}}, r.schemaOf([][]byte{}))
+}
+
+func TestOperationID(t *testing.T) {
+	assert.Equal(t, "get_cluster", operationID("GET", "/cluster"))
+	assert.Equal(t, "put_v1_notification_config_unsubscribe_userId", operationID("PUT", "/v1_notification_config/unsubscribe/:userId"))
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"config-service/db"
+	"config-service/types"
+	"config-service/utils/log"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const NDJSONContentType = "application/x-ndjson"
+
+// streamFlushSize is the number of documents written between flushes of the response
+const streamFlushSize = 100
+
+var ndjsonMediaTypes = []string{NDJSONContentType, "application/ndjson"}
+
+// AcceptsNDJSON returns true when the first media type of the Accept header that the service can produce is NDJSON
+func AcceptsNDJSON(c *gin.Context) bool {
+	mediaType := preferredMediaType(c, append(ndjsonMediaTypes, yamlMediaTypes...)...)
+	return mediaType != "" && !isYAMLMediaType(mediaType)
+}
+
+// streamDocsResponse writes the customer's documents as newline delimited JSON while they are read from the db cursor,
+// so the memory use does not depend on the number of documents
+func streamDocsResponse[T types.DocContent](c *gin.Context, includeGlobals bool) {
+	defer log.LogNTraceEnterExit("streamDocsResponse", c)()
+	encoder := json.NewEncoder(c.Writer)
+	count := 0
+	err := db.StreamAllForCustomer(c, nil, includeGlobals, func(doc T) error {
+		if count == 0 {
+			c.Header("Content-Type", NDJSONContentType)
+			c.Status(http.StatusOK)
+		}
+		//Encode ends each document with a new line
+		if err := encoder.Encode(doc); err != nil {
+			return err
+		}
+		if count++; count%streamFlushSize == 0 {
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	switch {
+	case err != nil && count == 0:
+		ResponseInternalServerError(c, "failed to read all documents for customer", err)
+	case err != nil:
+		//the status is already sent, the stream ends without the rest of the documents
+		log.LogNTraceError("failed to stream documents", err, c)
+		c.Abort()
+	case count == 0:
+		//empty stream
+		c.Data(http.StatusOK, NDJSONContentType, nil)
+	default:
+		c.Writer.Flush()
+	}
+}
@@ -0,0 +1,408 @@
+package handlers
+
+import (
+	"config-service/db"
+	"config-service/db/mongo"
+	"config-service/types"
+	"config-service/utils/consts"
+	"config-service/utils/log"
+	"config-service/webhooks"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go.mongodb.org/mongo-driver/bson"
+	mongoDB "go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	syncPath = "/sync"
+	//DefaultManager is the managed-by marker of synced documents when the request does not set one
+	DefaultManager = "sync"
+)
+
+type SyncAction string
+
+const (
+	SyncActionCreate SyncAction = "create"
+	SyncActionUpdate SyncAction = "update"
+	SyncActionDelete SyncAction = "delete"
+	SyncActionSkip   SyncAction = "skip" //the document of the desired set exists but is not managed by the sync
+)
+
+// SyncChange - a change of the plan of a desired state sync
+type SyncChange struct {
+	Action SyncAction `json:"action"`
+	Key    string     `json:"key"`              //the value of the sync key of the document
+	GUID   string     `json:"guid,omitempty"`   //the GUID of the existing document, empty in creates
+	Fields []string   `json:"fields,omitempty"` //the changed fields of updates
+	Reason string     `json:"reason,omitempty"` //the reason of skips
+}
+
+// SyncSummary - counts of the changes of a desired state sync
+type SyncSummary struct {
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Deleted   int `json:"deleted"`
+	Unchanged int `json:"unchanged"`
+	Skipped   int `json:"skipped"`
+}
+
+// SyncResponse - the plan of a desired state sync, applied unless it is a dry run
+type SyncResponse struct {
+	DryRun    bool         `json:"dryRun"`
+	Atomic    bool         `json:"atomic"` //true when the changes were applied in one transaction
+	Key       string       `json:"key"`    //the field that matches the desired documents to the existing documents
+	ManagedBy string       `json:"managedBy"`
+	Summary   SyncSummary  `json:"summary"`
+	Changes   []SyncChange `json:"changes"`
+}
+
+// syncPlan - the writes that make the customer documents as the desired set
+type syncPlan[T types.DocContent] struct {
+	creates       []T
+	updates       []T                 //desired documents with the GUIDs of the existing documents
+	removedFields map[string][]string //fields of the existing documents that are not in the desired documents by GUID
+	deletes       []T                 //existing documents
+	unchanged     int
+	changes       []SyncChange
+}
+
+// planSync matches the desired documents to the existing documents by key and returns the writes of the sync:
+// unmatched desired documents are created, matched documents with different fields are updated and existing documents
+// of the manager that are not in the desired set are deleted. Matched documents of other managers, or created in the UI
+// without a manager, are skipped unless adopt is true. The desired documents are marked with the manager and get the
+// preserved attributes of the documents they update, updates replace the documents so fields that are not in the
+// desired documents are removed.
+func planSync[T types.DocContent](desired, existing []T, keyOf func(T) string, manager string, adopt bool, preservedAttributes ...string) *syncPlan[T] {
+	plan := &syncPlan[T]{creates: []T{}, updates: []T{}, removedFields: map[string][]string{}, deletes: []T{}, changes: []SyncChange{}}
+	existingByKey := map[string]int{}
+	for i, doc := range existing {
+		if _, ok := existingByKey[keyOf(doc)]; !ok {
+			existingByKey[keyOf(doc)] = i
+		}
+	}
+	matched := map[int]bool{}
+	for _, doc := range desired {
+		key := keyOf(doc)
+		setManager(doc, manager)
+		index, ok := existingByKey[key]
+		if !ok {
+			plan.creates = append(plan.creates, doc)
+			plan.changes = append(plan.changes, SyncChange{Action: SyncActionCreate, Key: key})
+			continue
+		}
+		matched[index] = true
+		current := existing[index]
+		if currentManager := managerOf(current); currentManager != manager && !adopt {
+			reason := "the document is not managed by the sync"
+			if currentManager != "" {
+				reason = fmt.Sprintf("the document is managed by %s", currentManager)
+			}
+			plan.changes = append(plan.changes, SyncChange{Action: SyncActionSkip, Key: key, GUID: current.GetGUID(), Reason: reason})
+			continue
+		}
+		doc.SetGUID(current.GetGUID())
+		for _, attribute := range preservedAttributes {
+			if value, ok := current.GetAttributes()[attribute]; ok {
+				if _, set := doc.GetAttributes()[attribute]; !set {
+					doc.GetAttributes()[attribute] = value
+				}
+			}
+		}
+		if fields, removed := changedSyncFields(doc, current); len(fields) > 0 {
+			plan.updates = append(plan.updates, doc)
+			if len(removed) > 0 {
+				plan.removedFields[current.GetGUID()] = removed
+			}
+			plan.changes = append(plan.changes, SyncChange{Action: SyncActionUpdate, Key: key, GUID: current.GetGUID(), Fields: fields})
+		} else {
+			plan.unchanged++
+		}
+	}
+	for i, doc := range existing {
+		if !matched[i] && managerOf(doc) == manager {
+			plan.deletes = append(plan.deletes, doc)
+			plan.changes = append(plan.changes, SyncChange{Action: SyncActionDelete, Key: keyOf(doc), GUID: doc.GetGUID()})
+		}
+	}
+	return plan
+}
+
+func (plan *syncPlan[T]) summary() SyncSummary {
+	summary := SyncSummary{Created: len(plan.creates), Updated: len(plan.updates), Deleted: len(plan.deletes), Unchanged: plan.unchanged}
+	for _, change := range plan.changes {
+		if change.Action == SyncActionSkip {
+			summary.Skipped++
+		}
+	}
+	return summary
+}
+
+func managerOf[T types.DocContent](doc T) string {
+	manager, _ := doc.GetAttributes()[consts.ManagedByAttribute].(string)
+	return manager
+}
+
+func setManager[T types.DocContent](doc T, manager string) {
+	attributes := doc.GetAttributes()
+	if attributes == nil {
+		attributes = map[string]interface{}{}
+	}
+	attributes[consts.ManagedByAttribute] = manager
+	doc.SetAttributes(attributes)
+}
+
+// changedSyncFields returns the fields of the desired and the current documents with different values and the removed fields,
+// the fields of the current document that are not set in the desired document. Read only fields and fields that are not in
+// the json encoding of the document (e.g. the customers of the document) are not compared.
+func changedSyncFields[T types.DocContent](desired, current T) (fields []string, removed []string) {
+	excluded := append([]string{consts.UpdatedTimeField}, desired.GetReadOnlyFields()...)
+	desiredFields, currentFields := updateFields(desired, excluded), updateFields(current, excluded)
+	fields = []string{}
+	for field, value := range desiredFields {
+		if !reflect.DeepEqual(value, currentFields[field]) {
+			fields = append(fields, field)
+		}
+	}
+	jsonFields := map[string]json.RawMessage{}
+	if currentJson, err := json.Marshal(current); err == nil {
+		_ = json.Unmarshal(currentJson, &jsonFields)
+	}
+	for field := range currentFields {
+		if _, ok := desiredFields[field]; ok {
+			continue
+		}
+		topField, _, _ := strings.Cut(field, ".")
+		if _, ok := jsonFields[topField]; ok && !overlapsFields(field, desiredFields) {
+			removed = append(removed, field)
+		}
+	}
+	fields = append(fields, removed...)
+	sort.Strings(fields)
+	sort.Strings(removed)
+	return fields, removed
+}
+
+// overlapsFields returns true if the field is a parent or a child of one of the fields, a document update cannot set and remove overlapping fields
+func overlapsFields(field string, fields map[string]interface{}) bool {
+	for other := range fields {
+		if strings.HasPrefix(field, other+".") || strings.HasPrefix(other, field+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func updateFields[T types.DocContent](doc T, excluded []string) map[string]interface{} {
+	update, err := db.GetUpdateDocCommand(doc, nil, excluded...)
+	if err != nil {
+		//no fields to update
+		return map[string]interface{}{}
+	}
+	fields, _ := update[0].Value.(map[string]interface{})
+	return fields
+}
+
+// HandleSync - PUT /<path>/sync makes the customer documents as the desired set in the body,
+// with dryRun=true responds with the plan without applying it
+func HandleSync[T types.DocContent](opts *routerOptions[T]) gin.HandlerFunc {
+	keyName, _, keyOf := opts.syncKey()
+	return func(c *gin.Context) {
+		defer log.LogNTraceEnterExit("HandleSync", c)()
+		dryRun, adopt := false, false
+		for param, value := range map[string]*bool{consts.DryRunParam: &dryRun, consts.AdoptParam: &adopt} {
+			if str := c.Query(param); str != "" {
+				var err error
+				if *value, err = strconv.ParseBool(str); err != nil {
+					ResponseBadRequest(c, fmt.Sprintf("%s must be true or false", param))
+					return
+				}
+			}
+		}
+		manager := DefaultManager
+		if managedBy, ok := c.GetQuery(consts.ManagedByParam); ok {
+			if managedBy == "" {
+				ResponseBadRequest(c, consts.ManagedByParam+" must not be empty")
+				return
+			}
+			manager = managedBy
+		}
+
+		var desired []T
+		if _, err := requestBody(c); err != nil {
+			ResponseFailedToBindJson(c, err)
+			return
+		} else if err := c.ShouldBindBodyWith(&desired, binding.JSON); err != nil || desired == nil {
+			if err == nil {
+				err = errors.New("the body must be an array of the desired documents")
+			}
+			ResponseFailedToBindJson(c, err)
+			return
+		}
+		keys := map[string]bool{}
+		for _, doc := range desired {
+			key := keyOf(doc)
+			if key == "" {
+				ResponseMissingKey(c, keyName)
+				return
+			} else if keys[key] {
+				ResponseDuplicateKey(c, keyName, key)
+				return
+			}
+			keys[key] = true
+			//documents are matched by key, GUIDs of the body are ignored
+			doc.SetGUID("")
+		}
+
+		existing, err := db.GetAllForCustomer[T](c, false)
+		if err != nil {
+			ResponseInternalServerError(c, "failed to read documents", err)
+			return
+		}
+		var preservedAttributes []string
+		if opts.uniqueShortName != nil {
+			preservedAttributes = append(preservedAttributes, consts.ShortNameAttribute)
+		}
+		plan := planSync(desired, existing, keyOf, manager, adopt, preservedAttributes...)
+		if plan.creates, err = runValidators(c, plan.creates, syncPostValidators(opts)); err != nil {
+			return
+		}
+		if plan.updates, err = runValidators(c, plan.updates, opts.putValidators); err != nil {
+			return
+		}
+		response := SyncResponse{DryRun: dryRun, Key: keyName, ManagedBy: manager, Summary: plan.summary(), Changes: plan.changes}
+		if dryRun {
+			ResponseNegotiated(c, http.StatusOK, response)
+			return
+		}
+
+		//the changes are applied in one transaction when the db supports transactions
+		if mongoDB.SessionFromContext(c) != nil {
+			//already in a transaction, e.g. of an atomic batch, that is rolled back on errors
+			response.Atomic = true
+			if _, err := applySync(c, plan); err != nil {
+				responseSyncFailed(c, err, 0)
+				return
+			}
+		} else if session, err := mongo.StartSession(c); err == nil {
+			defer session.EndSession(context.Background())
+			if err := session.StartTransaction(); err != nil {
+				ResponseInternalServerError(c, "failed to start db transaction", err)
+				return
+			}
+			request := c.Request
+			c.Request = request.WithContext(mongoDB.NewSessionContext(request.Context(), session))
+			defer func() { c.Request = request }()
+			response.Atomic = true
+			if _, err := applySync(c, plan); err != nil {
+				//use a new context to end the transaction, the request context may be canceled
+				if err := session.AbortTransaction(context.Background()); err != nil {
+					log.LogNTraceError("failed to abort sync transaction", err, c)
+				}
+				responseSyncFailed(c, err, 0)
+				return
+			} else if err := session.CommitTransaction(context.Background()); err != nil {
+				ResponseInternalServerError(c, "failed to commit sync transaction", err)
+				return
+			}
+		} else if !errors.Is(err, mongo.ErrTransactionsNotSupported) {
+			ResponseInternalServerError(c, "failed to start db session", err)
+			return
+		} else if applied, err := applySync(c, plan); err != nil {
+			//without transactions the changes that were applied before the error are kept
+			responseSyncFailed(c, err, applied)
+			return
+		}
+		ResponseNegotiated(c, http.StatusOK, response)
+	}
+}
+
+// syncPostValidators returns the validators of the POST route that apply to the created documents
+func syncPostValidators[T types.DocContent](opts *routerOptions[T]) []MutatorValidator[T] {
+	validators := []MutatorValidator[T]{}
+	if opts.validatePostUniqueName {
+		validators = append(validators, ValidateUniqueValues(NameKeyGetter[T]))
+	}
+	if opts.uniqueShortName != nil {
+		validators = append(validators, ValidatePostAttributeShortName(opts.uniqueShortName))
+	}
+	return append(validators, opts.postValidators...)
+}
+
+// runValidators calls the validators with the documents, the validators respond when the documents are not valid
+func runValidators[T types.DocContent](c *gin.Context, docs []T, validators []MutatorValidator[T]) ([]T, error) {
+	if len(docs) == 0 {
+		return docs, nil
+	}
+	for _, validator := range validators {
+		var ok bool
+		if docs, ok = validator(c, docs); !ok {
+			return nil, errors.New("invalid documents")
+		}
+	}
+	return docs, nil
+}
+
+// applySync deletes, updates and creates the documents of the plan and returns the number of applied changes,
+// deletes are first so that a document can be replaced by a document with another key and the same unique fields
+func applySync[T types.DocContent](c *gin.Context, plan *syncPlan[T]) (int, error) {
+	applied := 0
+	for _, doc := range plan.deletes {
+		if deletedDoc, err := db.DeleteByGUID[T](c, doc.GetGUID()); err != nil {
+			return applied, fmt.Errorf("failed to delete document %s: %w", doc.GetGUID(), err)
+		} else if deletedDoc != nil {
+			enqueueWebhooks(c, webhooks.EventDeleted, []T{*deletedDoc})
+		}
+		applied++
+	}
+	for _, doc := range plan.updates {
+		doc.SetUpdatedTime(nil)
+		update, err := db.GetUpdateDocCommand(doc, nil, doc.GetReadOnlyFields()...)
+		if err != nil {
+			return applied, fmt.Errorf("failed to generate update command of document %s: %w", doc.GetGUID(), err)
+		}
+		if removed := plan.removedFields[doc.GetGUID()]; len(removed) > 0 {
+			unset := bson.D{}
+			for _, field := range removed {
+				unset = append(unset, bson.E{Key: field, Value: ""})
+			}
+			update = append(update, bson.E{Key: "$unset", Value: unset})
+		}
+		res, err := db.UpdateDocument[T](c, doc.GetGUID(), update)
+		if err != nil {
+			return applied, fmt.Errorf("failed to update document %s: %w", doc.GetGUID(), err)
+		} else if res != nil {
+			enqueueWebhooks(c, webhooks.EventUpdated, res[len(res)-1:])
+		}
+		applied++
+	}
+	if len(plan.creates) > 0 {
+		created, err := db.InsertDocuments(c, plan.creates)
+		if err != nil {
+			return applied, fmt.Errorf("failed to create documents: %w", err)
+		}
+		enqueueWebhooks(c, webhooks.EventCreated, created)
+		applied += len(created)
+	}
+	return applied, nil
+}
+
+// responseSyncFailed responds with the error of a sync and the number of changes that were applied before the error,
+// no changes are kept when the sync is in a transaction
+func responseSyncFailed(c *gin.Context, err error, applied int) {
+	if errors.Is(err, context.Canceled) {
+		ResponseCanceled(c)
+		return
+	}
+	log.LogNTraceError("failed to sync documents", err, c)
+	ResponseProblem(c, http.StatusInternalServerError, ErrorCodeInternal, err.Error(), gin.H{"appliedChanges": applied})
+}
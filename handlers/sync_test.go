@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"config-service/types"
+	"config-service/utils/consts"
+	"testing"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func syncCluster(guid, name string, attributes map[string]interface{}) *types.Cluster {
+	return &types.Cluster{PortalBase: armotypes.PortalBase{GUID: guid, Name: name, Attributes: attributes}, SubscriptionDate: "2023-01-01T00:00:00Z"}
+}
+
+func TestPlanSync(t *testing.T) {
+	existing := func() []*types.Cluster {
+		return []*types.Cluster{
+			syncCluster("1", "managed", map[string]interface{}{consts.ManagedByAttribute: DefaultManager, consts.ShortNameAttribute: "M", "env": "dev"}),
+			syncCluster("2", "ui", map[string]interface{}{"env": "dev"}),
+			syncCluster("3", "removed", map[string]interface{}{consts.ManagedByAttribute: DefaultManager}),
+			syncCluster("4", "other", map[string]interface{}{consts.ManagedByAttribute: "terraform"}),
+		}
+	}
+	desired := func(env string) []*types.Cluster {
+		return []*types.Cluster{
+			syncCluster("", "managed", map[string]interface{}{"env": env}),
+			syncCluster("", "ui", map[string]interface{}{"env": "prod"}),
+			syncCluster("", "new", nil),
+		}
+	}
+
+	plan := planSync(desired("prod"), existing(), NameValueGetter[*types.Cluster], DefaultManager, false, consts.ShortNameAttribute)
+	assert.Equal(t, []SyncChange{
+		{Action: SyncActionUpdate, Key: "managed", GUID: "1", Fields: []string{consts.AttributesField}},
+		{Action: SyncActionSkip, Key: "ui", GUID: "2", Reason: "the document is not managed by the sync"},
+		{Action: SyncActionCreate, Key: "new"},
+		{Action: SyncActionDelete, Key: "removed", GUID: "3"},
+	}, plan.changes)
+	assert.Equal(t, SyncSummary{Created: 1, Updated: 1, Deleted: 1, Skipped: 1}, plan.summary())
+	//updates keep the guid and the preserved attributes of the existing document, creates and updates are marked
+	assert.Equal(t, "1", plan.updates[0].GUID)
+	assert.Equal(t, map[string]interface{}{consts.ManagedByAttribute: DefaultManager, consts.ShortNameAttribute: "M", "env": "prod"}, plan.updates[0].Attributes)
+	assert.Equal(t, DefaultManager, plan.creates[0].Attributes[consts.ManagedByAttribute])
+	assert.Equal(t, "3", plan.deletes[0].GUID)
+
+	//unchanged documents are counted and not updated
+	plan = planSync(desired("dev"), existing(), NameValueGetter[*types.Cluster], DefaultManager, false, consts.ShortNameAttribute)
+	assert.Empty(t, plan.updates)
+	assert.Equal(t, SyncSummary{Created: 1, Deleted: 1, Unchanged: 1, Skipped: 1}, plan.summary())
+
+	//adopt updates the documents of other managers and of the UI
+	plan = planSync(desired("dev"), existing(), NameValueGetter[*types.Cluster], DefaultManager, true, consts.ShortNameAttribute)
+	assert.Len(t, plan.updates, 1)
+	assert.Equal(t, "2", plan.updates[0].GUID)
+	assert.Equal(t, DefaultManager, plan.updates[0].Attributes[consts.ManagedByAttribute])
+
+	//updates remove the fields that are not in the desired documents and keep the read only fields
+	removedField := existing()
+	removedField[0].LastLoginDate = "2023-02-01T00:00:00Z"
+	plan = planSync(desired("dev"), removedField, NameValueGetter[*types.Cluster], DefaultManager, false, consts.ShortNameAttribute)
+	assert.Equal(t, SyncChange{Action: SyncActionUpdate, Key: "managed", GUID: "1", Fields: []string{"last_login_date"}}, plan.changes[0])
+	assert.Equal(t, map[string][]string{"1": {"last_login_date"}}, plan.removedFields)
+
+	//documents are deleted only by their manager
+	plan = planSync([]*types.Cluster{}, existing(), NameValueGetter[*types.Cluster], "terraform", false)
+	assert.Equal(t, []SyncChange{{Action: SyncActionDelete, Key: "other", GUID: "4"}}, plan.changes)
+}
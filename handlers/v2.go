@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"config-service/utils/consts"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// v2 API - routes added with a v2 path are served also under /v2 by the same handlers with:
+// - resource style plural paths e.g. /v2/clusters
+// - list responses (GET all, GET by name or query, POST) in a ListResponse envelope, also when empty or with one document
+// - PUT responds with the updated document
+// - problem responses of requests without a matching route
+
+// ListResponse - v2 envelope of list responses
+type ListResponse[T any] struct {
+	Items    []T          `json:"items"`
+	Metadata ListMetadata `json:"metadata"`
+}
+
+type ListMetadata struct {
+	Count int `json:"count"`
+}
+
+// v2 base paths to the base paths of the v1 routes of the same documents
+var v2Paths = sync.Map{}
+
+func registerV2Path(v2Path, path string) {
+	v2Paths.Store(strings.TrimPrefix(v2Path, "/"), strings.TrimPrefix(path, "/"))
+}
+
+// IsV2Request returns true if the request path is under /v2
+func IsV2Request(c *gin.Context) bool {
+	return strings.HasPrefix(c.Request.URL.Path, consts.V2Path+"/")
+}
+
+// RouteBasePath returns the first element of the route full path, for v2 routes it returns the base path of the v1 routes
+// so authorization, API key scopes and rate limits are shared by both versions
+func RouteBasePath(fullPath string) string {
+	fullPath = strings.TrimPrefix(fullPath, "/")
+	if strings.HasPrefix(fullPath, strings.TrimPrefix(consts.V2Path, "/")+"/") {
+		parts := strings.SplitN(fullPath, "/", 3)
+		if path, ok := v2Paths.Load(parts[0] + "/" + parts[1]); ok {
+			return path.(string)
+		}
+	}
+	path, _, _ := strings.Cut(fullPath, "/")
+	return path
+}
+
+// ResponseNoRoute responds with a v2 error to v2 requests without a matching route, other requests get the default gin response
+func ResponseNoRoute(c *gin.Context) {
+	if IsV2Request(c) {
+		ResponseProblem(c, http.StatusNotFound, ErrorCodeRouteNotFound, "route not found", nil)
+	}
+}
+
+func listResponse[T any](c *gin.Context, status int, items []T) {
+	if items == nil {
+		items = []T{}
+	}
+	ResponseNegotiated(c, status, ListResponse[T]{Items: items, Metadata: ListMetadata{Count: len(items)}})
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteBasePath(t *testing.T) {
+	registerV2Path("/v2/test-paths", "/test_path")
+	assert.Equal(t, "test_path", RouteBasePath("/test_path/:guid"))
+	assert.Equal(t, "test_path", RouteBasePath("/v2/test-paths"))
+	assert.Equal(t, "test_path", RouteBasePath("/v2/test-paths/:guid"))
+	assert.Equal(t, "v2", RouteBasePath("/v2/unknown"))
+	assert.Equal(t, "", RouteBasePath(""))
+}
+
+func TestResponseNoRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.NoRoute(ResponseNoRoute)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/v2/unknown", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"route_not_found"`)
+	//v1 requests get the default response
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/unknown", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "404 page not found", w.Body.String())
+}
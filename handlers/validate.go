@@ -5,8 +5,11 @@ import (
 	"config-service/types"
 	"config-service/utils/consts"
 	"config-service/utils/log"
+	"fmt"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
 	"golang.org/x/exp/slices"
 )
 
@@ -28,6 +31,31 @@ func ValidateGUIDExistence[T types.DocContent](c *gin.Context, docs []T) ([]T, b
 	return docs, true
 }
 
+// ValidateClientGUIDs validates that the client supplied GUIDs of new documents are UUIDs that are not repeated in the request
+func ValidateClientGUIDs[T types.DocContent](c *gin.Context, docs []T) ([]T, bool) {
+	guids := []string{}
+	for i := range docs {
+		if docs[i].GetGUID() == "" {
+			continue
+		}
+		guid, err := uuid.FromString(docs[i].GetGUID())
+		if err != nil {
+			msg := fmt.Sprintf("guid %s is not a valid UUID", docs[i].GetGUID())
+			log.LogNTrace(msg, c)
+			ResponseProblem(c, http.StatusBadRequest, ErrorCodeInvalidBody, msg, gin.H{"guid": docs[i].GetGUID()})
+			return nil, false
+		}
+		//canonical form
+		docs[i].SetGUID(guid.String())
+		if slices.Contains(guids, guid.String()) {
+			ResponseDuplicateKey(c, consts.GUIDField, guid.String())
+			return nil, false
+		}
+		guids = append(guids, guid.String())
+	}
+	return docs, true
+}
+
 type UniqueKeyValueInfo[T types.DocContent] func() (key string, mandatory bool, valueGetter func(T) string)
 
 func ValidateUniqueValues[T types.DocContent](uniqueKeyValues ...UniqueKeyValueInfo[T]) func(c *gin.Context, docs []T) ([]T, bool) {
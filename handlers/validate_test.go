@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"config-service/types"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateClientGUIDs(t *testing.T) {
+	newCluster := func(guid string) *types.Cluster {
+		return &types.Cluster{PortalBase: armotypes.PortalBase{GUID: guid}}
+	}
+	tests := []struct {
+		name     string
+		guids    []string
+		expected []string
+		status   int
+	}{
+		{name: "generated guids", guids: []string{"", ""}, expected: []string{"", ""}},
+		{name: "canonical form", guids: []string{"{1C73D160-84FE-4F41-A89B-D7D54BB1B685}", ""}, expected: []string{"1c73d160-84fe-4f41-a89b-d7d54bb1b685", ""}},
+		{name: "not a uuid", guids: []string{"cluster-1"}, status: http.StatusBadRequest},
+		{name: "repeated guid", guids: []string{"1c73d160-84fe-4f41-a89b-d7d54bb1b685", "1C73D160-84FE-4F41-A89B-D7D54BB1B685"}, status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(http.MethodPost, "/cluster", nil)
+			docs := []*types.Cluster{}
+			for _, guid := range tt.guids {
+				docs = append(docs, newCluster(guid))
+			}
+			docs, ok := ValidateClientGUIDs(c, docs)
+			if tt.status != 0 {
+				assert.False(t, ok)
+				assert.Equal(t, tt.status, w.Code)
+				return
+			}
+			assert.True(t, ok)
+			for i, doc := range docs {
+				assert.Equal(t, tt.expected[i], doc.GetGUID())
+			}
+		})
+	}
+}
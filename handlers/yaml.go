@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	yamlv3 "gopkg.in/yaml.v3"
+	"sigs.k8s.io/yaml"
+)
+
+// YAML request bodies are converted to JSON before they are validated and decoded, so the field names follow the json tags.
+// YAML responses are encoded from the JSON encoding of the response.
+
+const YAMLContentType = "application/yaml"
+
+var yamlMediaTypes = []string{YAMLContentType, "application/x-yaml", "text/yaml", "text/x-yaml"}
+
+func isYAMLMediaType(mediaType string) bool {
+	for _, yamlMediaType := range yamlMediaTypes {
+		if strings.EqualFold(mediaType, yamlMediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// AcceptsYAML returns true when the first media type of the Accept header that the service can produce is YAML
+func AcceptsYAML(c *gin.Context) bool {
+	return isYAMLMediaType(preferredMediaType(c, yamlMediaTypes...))
+}
+
+// preferredMediaType returns the first media type of the Accept header that is one of the offered media types,
+// empty when JSON (or any type) comes first or none of the offered types is accepted
+func preferredMediaType(c *gin.Context, offered ...string) string {
+	for _, mediaRange := range strings.Split(c.GetHeader("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+		for _, offer := range offered {
+			if strings.EqualFold(mediaType, offer) {
+				return offer
+			}
+		}
+		if mediaType == "application/json" || mediaType == "application/*" || mediaType == "*/*" {
+			return ""
+		}
+	}
+	return ""
+}
+
+// ResponseNegotiated responds with YAML when the Accept header asks for it, otherwise with JSON
+func ResponseNegotiated(c *gin.Context, status int, obj interface{}) {
+	if !AcceptsYAML(c) {
+		c.JSON(status, obj)
+		return
+	}
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		ResponseInternalServerError(c, "failed to encode yaml response", err)
+		return
+	}
+	c.Data(status, YAMLContentType+"; charset=utf-8", data)
+}
+
+// requestBody returns the JSON request body and keeps it for binding with ShouldBindBodyWith,
+// YAML bodies are converted to JSON
+func requestBody(c *gin.Context) ([]byte, error) {
+	if cached, ok := c.Get(gin.BodyBytesKey); ok {
+		if body, ok := cached.([]byte); ok {
+			return body, nil
+		}
+	}
+	body, err := c.GetRawData()
+	if err != nil {
+		return nil, err
+	}
+	if isYAMLMediaType(c.ContentType()) {
+		if body, err = yamlToJSON(body); err != nil {
+			return nil, err
+		}
+	}
+	c.Set(gin.BodyBytesKey, body)
+	return body, nil
+}
+
+// yamlToJSON converts a YAML document to JSON, a stream of multiple documents is converted to a JSON array of the documents
+func yamlToJSON(body []byte) ([]byte, error) {
+	decoder := yamlv3.NewDecoder(bytes.NewReader(body))
+	docs := []json.RawMessage{}
+	for {
+		var node yamlv3.Node
+		if err := decoder.Decode(&node); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid yaml: %w", err)
+		}
+		doc, err := yamlv3.Marshal(&node)
+		if err != nil {
+			return nil, fmt.Errorf("invalid yaml: %w", err)
+		}
+		jsonDoc, err := yaml.YAMLToJSON(doc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid yaml: %w", err)
+		}
+		//skip empty documents of the stream
+		if !bytes.Equal(jsonDoc, []byte("null")) {
+			docs = append(docs, jsonDoc)
+		}
+	}
+	switch len(docs) {
+	case 0:
+		return nil, fmt.Errorf("empty yaml body")
+	case 1:
+		return docs[0], nil
+	}
+	return json.Marshal(docs)
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"config-service/types"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestYAMLToJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected string
+		err      bool
+	}{
+		{
+			name:     "document",
+			yaml:     "name: p1\nposturePolicies:\n- frameworkName: MITRE\nattributes:\n  1: one\n",
+			expected: `{"attributes":{"1":"one"},"name":"p1","posturePolicies":[{"frameworkName":"MITRE"}]}`,
+		},
+		{
+			name:     "stream of documents",
+			yaml:     "---\nname: p1\n---\nname: p2\n---\n",
+			expected: `[{"name":"p1"},{"name":"p2"}]`,
+		},
+		{
+			name:     "list of documents",
+			yaml:     "- name: p1\n- name: p2\n",
+			expected: `[{"name":"p1"},{"name":"p2"}]`,
+		},
+		{
+			name: "empty",
+			yaml: "---\n",
+			err:  true,
+		},
+		{
+			name: "invalid",
+			yaml: "name: [p1\n",
+			err:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			json, err := yamlToJSON([]byte(tt.yaml))
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(json))
+		})
+	}
+}
+
+func TestAcceptsYAML(t *testing.T) {
+	for accept, expected := range map[string]bool{
+		"":                                     false,
+		"*/*":                                  false,
+		"application/json":                     false,
+		"application/json-patch+json":          false,
+		"application/yaml":                     true,
+		"text/yaml;q=0.9, application/json":    true,
+		"application/json, application/x-yaml": false,
+		"text/html, application/x-yaml":        true,
+	} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request, _ = http.NewRequest(http.MethodGet, "/", nil)
+		c.Request.Header.Set("Accept", accept)
+		assert.Equal(t, expected, AcceptsYAML(c), accept)
+	}
+}
+
+func TestAcceptsNDJSON(t *testing.T) {
+	for accept, expected := range map[string]bool{
+		"":                                       false,
+		"application/json":                       false,
+		"application/x-ndjson":                   true,
+		"application/ndjson; charset=utf-8":      true,
+		"application/json, application/x-ndjson": false,
+		"application/yaml, application/x-ndjson": false,
+		"text/html, application/x-ndjson":        true,
+	} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request, _ = http.NewRequest(http.MethodGet, "/", nil)
+		c.Request.Header.Set("Accept", accept)
+		assert.Equal(t, expected, AcceptsNDJSON(c), accept)
+	}
+}
+
+func TestYAMLRequestAndResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	schema := NewJSONSchema[*types.PostureExceptionPolicy]()
+	router.POST("/policy", SchemaValidationMiddleware(schema, nil, true), PostValidationMiddleware[*types.PostureExceptionPolicy](), func(c *gin.Context) {
+		docs, _ := MustGetDocContentFromContext[*types.PostureExceptionPolicy](c)
+		ResponseNegotiated(c, http.StatusCreated, docs)
+	})
+	tests := []struct {
+		name        string
+		contentType string
+		accept      string
+		body        string
+		status      int
+		expected    string
+	}{
+		{
+			name:        "yaml stream to yaml",
+			contentType: "application/yaml",
+			accept:      "application/yaml",
+			body:        "name: p1\nposturePolicies:\n- frameworkName: MITRE\n---\nname: p2\n",
+			status:      http.StatusCreated,
+			expected:    "- actions: null\n  creationTime: \"\"\n  guid: \"\"\n  name: p1\n  policyType: \"\"\n  posturePolicies:\n  - frameworkName: MITRE\n  resources: null\n- actions: null\n  creationTime: \"\"\n  guid: \"\"\n  name: p2\n  policyType: \"\"\n  posturePolicies: null\n  resources: null\n",
+		},
+		{
+			name:        "yaml to json",
+			contentType: "text/yaml; charset=utf-8",
+			body:        "name: p1\n",
+			status:      http.StatusCreated,
+			expected:    `[{"guid":"","name":"p1","policyType":"","creationTime":"","actions":null,"resources":null,"posturePolicies":null}]`,
+		},
+		{
+			name:        "yaml schema violation",
+			contentType: "application/yaml",
+			body:        "name: p1\nposturePolicies:\n- frameworkName: 1\n",
+			status:      http.StatusBadRequest,
+			expected:    `"detail":"posturePolicies[0].frameworkName: expected string, got number"`,
+		},
+		{
+			name:        "invalid yaml",
+			contentType: "application/yaml",
+			body:        "name: [p1\n",
+			status:      http.StatusBadRequest,
+			expected:    `"code":"invalid_body"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/policy", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req.Header.Set("Accept", tt.accept)
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expected)
+			if tt.accept == "application/yaml" {
+				assert.Equal(t, "application/yaml; charset=utf-8", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
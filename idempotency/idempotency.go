@@ -0,0 +1,199 @@
+package idempotency
+
+import (
+	"bytes"
+	"config-service/db"
+	"config-service/db/mongo"
+	"config-service/handlers"
+	"config-service/utils"
+	"config-service/utils/consts"
+	"config-service/utils/log"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	mongoDB "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// replay of POST requests that are retried with the same Idempotency-Key header
+// the first request of a customer key claims a record in db, the response is stored in the record when the request is served
+// and replayed to the retries with the same request until the record expires
+
+const (
+	defaultTTL = 24 * time.Hour
+	//lease of a claimed record, a record of a request that was not completed by a crashed replica can be claimed again after this duration
+	claimLeaseDuration = 2 * time.Minute
+	maxKeyLength       = 255
+)
+
+var ttl = defaultTTL
+
+// record - idempotency key document in db
+type record struct {
+	ID              string    `bson:"_id"`
+	CustomerGUID    string    `bson:"customerGUID"`
+	Key             string    `bson:"key"`
+	RequestHash     string    `bson:"requestHash"`
+	Completed       bool      `bson:"completed"`
+	Status          int       `bson:"status,omitempty"`
+	ContentType     string    `bson:"contentType,omitempty"`
+	Body            []byte    `bson:"body,omitempty"`
+	LeaseExpiration time.Time `bson:"leaseExpiration"`
+	ExpirationTime  time.Time `bson:"expirationTime"`
+}
+
+// Init sets the idempotency keys TTL and creates the db index that removes expired keys
+func Init(config utils.IdempotencyConfig) {
+	if config.TTLSeconds > 0 {
+		ttl = time.Duration(config.TTLSeconds) * time.Second
+	}
+	index := mongoDB.IndexModel{
+		Keys:    bson.D{{Key: "expirationTime", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+	if _, err := mongo.GetWriteCollection(consts.IdempotencyKeysCollection).Indexes().CreateOne(context.Background(), index); err != nil {
+		//expired keys are still ignored, they are only not removed from db
+		zap.L().Error("failed to create idempotency keys expiration index", zap.Error(err))
+	}
+}
+
+// Middleware replays the stored response of POST requests with an Idempotency-Key header that was already used by the customer
+// a key that is used with a different request is rejected, as well as a retry while the first request is served
+func Middleware(c *gin.Context) {
+	key := c.GetHeader(consts.IdempotencyKeyHeader)
+	customerGUID := c.GetString(consts.CustomerGUID)
+	if c.Request.Method != http.MethodPost || key == "" || customerGUID == "" {
+		c.Next()
+		return
+	}
+	if len(key) > maxKeyLength {
+		handlers.ResponseBadRequest(c, consts.IdempotencyKeyHeader+" header is too long")
+		return
+	}
+	body, err := c.GetRawData()
+	if err != nil {
+		handlers.ResponseFailedToBindJson(c, err)
+		return
+	}
+	//restore the body for the handlers
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	hash := requestHash(c.Request.Method, c.Request.URL.RequestURI(), c.ContentType(), body)
+	rec, claimed, err := claim(c, customerGUID, key, hash)
+	if err != nil {
+		handlers.ResponseInternalServerError(c, "failed to check idempotency key", err)
+		return
+	}
+	switch {
+	case claimed:
+		serve(c, rec)
+	case rec.RequestHash != hash:
+		log.LogNTrace("idempotency key reused with a different request", c)
+		handlers.ResponseProblem(c, http.StatusUnprocessableEntity, handlers.ErrorCodeIdempotencyReused,
+			consts.IdempotencyKeyHeader+" was already used with a different request", nil)
+	case !rec.Completed:
+		c.Header("Retry-After", "1")
+		handlers.ResponseProblem(c, http.StatusConflict, handlers.ErrorCodeIdempotencyInUse,
+			"a request with the same "+consts.IdempotencyKeyHeader+" is in progress", gin.H{"retryAfterSeconds": 1})
+	default:
+		log.LogNTrace("replaying response of idempotency key", c)
+		c.Header(consts.IdempotentReplayedHeader, "true")
+		c.Data(rec.Status, rec.ContentType, rec.Body)
+		c.Abort()
+	}
+}
+
+// serve runs the request handlers and stores the response in the claimed record,
+// the record is released when the request fails with a server error or is canceled so the retry is served again
+func serve(c *gin.Context, rec *record) {
+	writer := &recordingWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+	c.Next()
+	c.Writer = writer.ResponseWriter
+
+	collection := mongo.GetWriteCollection(consts.IdempotencyKeysCollection)
+	filter := bson.D{{Key: consts.IdField, Value: rec.ID}, {Key: "requestHash", Value: rec.RequestHash}, {Key: "completed", Value: false}}
+	//use a new context, the request context may be canceled
+	var err error
+	if status := writer.Status(); status >= http.StatusInternalServerError || c.Request.Context().Err() != nil {
+		_, err = collection.DeleteOne(context.Background(), filter)
+	} else {
+		_, err = collection.UpdateOne(context.Background(), filter, bson.D{{Key: "$set", Value: bson.D{
+			{Key: "completed", Value: true},
+			{Key: "status", Value: status},
+			{Key: "contentType", Value: writer.Header().Get("Content-Type")},
+			{Key: "body", Value: writer.body.Bytes()},
+		}}})
+	}
+	if err != nil {
+		log.LogNTraceError("failed to store idempotency key response", err, c)
+	}
+}
+
+// claim inserts a new record of the customer key, an expired record or a record of the same request with an expired lease is replaced.
+// Returns the existing record when it is not claimed.
+func claim(c context.Context, customerGUID, key, hash string) (rec *record, claimed bool, err error) {
+	now := time.Now().UTC()
+	rec = &record{
+		ID:              customerGUID + "|" + key,
+		CustomerGUID:    customerGUID,
+		Key:             key,
+		RequestHash:     hash,
+		LeaseExpiration: now.Add(claimLeaseDuration),
+		ExpirationTime:  now.Add(ttl),
+	}
+	filter := bson.D{
+		{Key: consts.IdField, Value: rec.ID},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "expirationTime", Value: bson.D{{Key: "$lte", Value: now}}}},
+			bson.D{{Key: "requestHash", Value: hash}, {Key: "completed", Value: false}, {Key: "leaseExpiration", Value: bson.D{{Key: "$lte", Value: now}}}},
+		}},
+	}
+	collection := mongo.GetWriteCollection(consts.IdempotencyKeysCollection)
+	_, err = collection.ReplaceOne(c, filter, rec, options.Replace().SetUpsert(true))
+	if err == nil {
+		return rec, true, nil
+	}
+	if !db.IsDuplicateKeyError(err) {
+		return nil, false, err
+	}
+	//the key is used by a record that cannot be replaced
+	var existing record
+	if err := collection.FindOne(c, bson.D{{Key: consts.IdField, Value: rec.ID}}).Decode(&existing); err != nil {
+		return nil, false, err
+	}
+	return &existing, false, nil
+}
+
+// requestHash identifies the request of an idempotency key
+func requestHash(method, uri, contentType string, body []byte) string {
+	hash := sha256.New()
+	for _, part := range [][]byte{[]byte(method), []byte(uri), []byte(contentType), body} {
+		hash.Write(part)
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// recordingWriter keeps a copy of the response body
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
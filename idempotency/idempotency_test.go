@@ -0,0 +1,31 @@
+package idempotency
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestHash(t *testing.T) {
+	hash := requestHash(http.MethodPost, "/cluster", "application/json", []byte(`{"name":"c1"}`))
+	assert.Equal(t, hash, requestHash(http.MethodPost, "/cluster", "application/json", []byte(`{"name":"c1"}`)))
+	assert.NotEqual(t, hash, requestHash(http.MethodPost, "/cluster", "application/json", []byte(`{"name":"c2"}`)), "body")
+	assert.NotEqual(t, hash, requestHash(http.MethodPost, "/v2/clusters", "application/json", []byte(`{"name":"c1"}`)), "path")
+	assert.NotEqual(t, hash, requestHash(http.MethodPost, "/cluster", "application/yaml", []byte(`{"name":"c1"}`)), "content type")
+	//parts are separated
+	assert.NotEqual(t, requestHash(http.MethodPost, "/a", "", []byte("b")), requestHash(http.MethodPost, "/ab", "", nil))
+}
+
+func TestRecordingWriter(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	writer := &recordingWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+	c.JSON(http.StatusCreated, gin.H{"name": "c1"})
+	assert.Equal(t, http.StatusCreated, writer.Status())
+	assert.Equal(t, w.Body.String(), writer.body.String())
+	assert.JSONEq(t, `{"name":"c1"}`, writer.body.String())
+}
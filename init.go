@@ -4,9 +4,15 @@
 package main
 
 import (
+	"config-service/auth"
 	"config-service/db"
 	"config-service/db/mongo"
+	"config-service/grpcserver"
+	"config-service/idempotency"
+	"config-service/jobs"
+	"config-service/ratelimit"
 	"config-service/utils"
+	"config-service/webhooks"
 	"context"
 	"log"
 	"os"
@@ -33,13 +39,33 @@ func initialize() (shutdown func()) {
 	initLogger(conf.LoggerConfig)
 	//init tracer
 	tracer := initTracer(conf.Telemetry)
+	//init authentication
+	if err := auth.Init(conf.Auth); err != nil {
+		zapLogger.Fatal("failed to initialize authentication", zap.Error(err))
+	}
+	//init rate limits
+	ratelimit.Init(conf.RateLimit)
 	//connect db
 	mongo.MustConnect(conf.Mongo)
 	//init db library
 	db.Init()
+	//init idempotency keys
+	idempotency.Init(conf.Idempotency)
+	//start jobs worker
+	stopJobsWorker := jobs.StartWorker(conf.Jobs)
+	//start webhooks dispatcher
+	stopWebhooksDispatcher := webhooks.StartDispatcher(conf.Webhooks)
+	//start gRPC server (when a gRPC port is configured)
+	stopGRPCServer, err := grpcserver.Start(conf)
+	if err != nil {
+		zapLogger.Fatal("failed to start gRPC server", zap.Error(err))
+	}
 
 	//shutdown function
 	shutdown = func() {
+		stopGRPCServer()
+		stopJobsWorker()
+		stopWebhooksDispatcher()
 		mongo.Disconnect()
 		if err := tracer.Shutdown(context.Background()); err != nil {
 			log.Printf("Error shutting down tracer provider: %v", err)
@@ -0,0 +1,154 @@
+package jobs
+
+import (
+	"config-service/db/mongo"
+	"config-service/utils/consts"
+	"config-service/utils/log"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	mongoDB "go.mongodb.org/mongo-driver/mongo"
+)
+
+// persistent jobs for long-running operations
+// a job is submitted to the jobs collection, claimed by one replica with a lease and its progress is checkpointed
+// so when the replica crashes or the lease expires the job is resumed by another replica from the last checkpoint
+// a failed attempt is retried from the last checkpoint until the job reaches the max attempts
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+)
+
+// Job - job document in db
+type Job struct {
+	ID              string                 `json:"id" bson:"_id"`
+	Type            string                 `json:"type" bson:"type"`
+	Status          Status                 `json:"status" bson:"status"`
+	Params          map[string]interface{} `json:"params,omitempty" bson:"params,omitempty"`
+	Progress        map[string]Progress    `json:"progress,omitempty" bson:"progress,omitempty"`
+	Owner           string                 `json:"owner,omitempty" bson:"owner,omitempty"`
+	LeaseExpiration *time.Time             `json:"leaseExpiration,omitempty" bson:"leaseExpiration,omitempty"`
+	Attempts        int                    `json:"attempts" bson:"attempts"`
+	Error           string                 `json:"error,omitempty" bson:"error,omitempty"`         //the error of the failed job or of the last failed attempt
+	RetryTime       *time.Time             `json:"retryTime,omitempty" bson:"retryTime,omitempty"` //the time a failed attempt is retried
+	CreatedBy       string                 `json:"createdBy,omitempty" bson:"createdBy,omitempty"`
+	CreationTime    time.Time              `json:"creationTime" bson:"creationTime"`
+	UpdatedTime     time.Time              `json:"updatedTime" bson:"updatedTime"`
+	CompletionTime  *time.Time             `json:"completionTime,omitempty" bson:"completionTime,omitempty"`
+}
+
+// Progress - checkpoint of a job step (e.g. a collection in customer deletion)
+type Progress struct {
+	Done  bool   `json:"done" bson:"done"`
+	Count int64  `json:"count" bson:"count"`
+	Error string `json:"error,omitempty" bson:"error,omitempty"`
+}
+
+// Checkpointer persists the progress of a job step, it returns ErrLeaseLost if the job is no longer owned by this replica
+type Checkpointer func(step string, progress Progress) error
+
+// Handler runs a job, it should skip steps that are already done in job.Progress and checkpoint each completed step
+type Handler func(ctx context.Context, job *Job, checkpoint Checkpointer) error
+
+var ErrLeaseLost = errors.New("job lease lost")
+
+var handlers = sync.Map{}
+
+// RegisterHandler registers the handler of a job type, only registered job types are claimed by the worker
+func RegisterHandler(jobType string, handler Handler) {
+	handlers.Store(jobType, handler)
+}
+
+func getHandler(jobType string) Handler {
+	if h, ok := handlers.Load(jobType); ok {
+		return h.(Handler)
+	}
+	return nil
+}
+
+func registeredTypes() []string {
+	jobTypes := []string{}
+	handlers.Range(func(key, _ interface{}) bool {
+		jobTypes = append(jobTypes, key.(string))
+		return true
+	})
+	return jobTypes
+}
+
+// Submit creates a new pending job and returns it
+func Submit(c context.Context, jobType string, params map[string]interface{}, createdBy string) (*Job, error) {
+	defer log.LogNTraceEnterExit("jobs.Submit", c)()
+	if getHandler(jobType) == nil {
+		return nil, fmt.Errorf("unknown job type %s", jobType)
+	}
+	now := time.Now().UTC()
+	job := &Job{
+		ID:           uuid.NewV4().String(),
+		Type:         jobType,
+		Status:       StatusPending,
+		Params:       params,
+		Progress:     map[string]Progress{},
+		CreatedBy:    createdBy,
+		CreationTime: now,
+		UpdatedTime:  now,
+	}
+	if _, err := mongo.GetWriteCollection(consts.JobsCollection).InsertOne(c, job); err != nil {
+		return nil, err
+	}
+	notifyWorker()
+	return job, nil
+}
+
+// Get returns the job by id or nil if not found
+func Get(c context.Context, id string) (*Job, error) {
+	defer log.LogNTraceEnterExit("jobs.Get", c)()
+	var job Job
+	if err := mongo.GetReadCollection(consts.JobsCollection).FindOne(c, bson.D{{Key: consts.IdField, Value: id}}).Decode(&job); err != nil {
+		if err == mongoDB.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// StringsParam returns a string list param of the job
+func (job *Job) StringsParam(name string) []string {
+	values := []string{}
+	switch v := job.Params[name].(type) {
+	case []string:
+		values = v
+	case bson.A:
+		for _, i := range v {
+			if s, ok := i.(string); ok {
+				values = append(values, s)
+			}
+		}
+	case []interface{}:
+		for _, i := range v {
+			if s, ok := i.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
+
+// TotalCount returns the sum of all steps counts
+func (job *Job) TotalCount() int64 {
+	var total int64
+	for _, p := range job.Progress {
+		total += p.Count
+	}
+	return total
+}
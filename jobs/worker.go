@@ -0,0 +1,257 @@
+package jobs
+
+import (
+	"config-service/db/mongo"
+	"config-service/utils"
+	"config-service/utils/consts"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	rndStr "github.com/dchest/uniuri"
+	"go.mongodb.org/mongo-driver/bson"
+	mongoDB "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultLeaseDuration = time.Minute
+	defaultPollInterval  = 5 * time.Second
+	defaultMaxAttempts   = 5
+)
+
+// wakeup channel to let the local worker claim a new job without waiting for the next poll
+var wakeup = make(chan struct{}, 1)
+
+func notifyWorker() {
+	select {
+	case wakeup <- struct{}{}:
+	default:
+	}
+}
+
+type worker struct {
+	id            string
+	leaseDuration time.Duration
+	pollInterval  time.Duration
+	maxAttempts   int
+}
+
+// StartWorker starts the replica job worker and returns a function that stops it and waits for the running job to stop
+func StartWorker(config utils.JobsConfig) (stop func()) {
+	hostName, _ := os.Hostname()
+	w := &worker{
+		id:            fmt.Sprintf("%s-%s", hostName, rndStr.NewLen(6)),
+		leaseDuration: defaultLeaseDuration,
+		pollInterval:  defaultPollInterval,
+		maxAttempts:   defaultMaxAttempts,
+	}
+	if config.LeaseSeconds > 0 {
+		w.leaseDuration = time.Duration(config.LeaseSeconds) * time.Second
+	}
+	if config.PollIntervalSeconds > 0 {
+		w.pollInterval = time.Duration(config.PollIntervalSeconds) * time.Second
+	}
+	if config.MaxAttempts > 0 {
+		w.maxAttempts = config.MaxAttempts
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.run(ctx)
+	}()
+	zap.L().Info("jobs worker started", zap.String("worker", w.id))
+	return func() {
+		cancel()
+		wg.Wait()
+		zap.L().Info("jobs worker stopped", zap.String("worker", w.id))
+	}
+}
+
+func (w *worker) run(ctx context.Context) {
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+	for {
+		//run jobs until there is nothing to claim
+		for ctx.Err() == nil {
+			job, err := w.claim(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					zap.L().Error("failed to claim job", zap.Error(err))
+				}
+				break
+			}
+			if job == nil {
+				break
+			}
+			w.execute(ctx, job)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wakeup:
+		}
+	}
+}
+
+// claim takes the lease of the oldest pending job or of a running job with an expired lease
+func (w *worker) claim(ctx context.Context) (*Job, error) {
+	jobTypes := registeredTypes()
+	if len(jobTypes) == 0 {
+		return nil, nil
+	}
+	now := time.Now().UTC()
+	filter := bson.D{
+		{Key: "type", Value: bson.D{{Key: "$in", Value: jobTypes}}},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "status", Value: StatusPending}, {Key: "$or", Value: bson.A{
+				bson.D{{Key: "retryTime", Value: bson.D{{Key: "$exists", Value: false}}}},
+				bson.D{{Key: "retryTime", Value: bson.D{{Key: "$lte", Value: now}}}},
+			}}},
+			bson.D{{Key: "status", Value: StatusRunning}, {Key: "leaseExpiration", Value: bson.D{{Key: "$lt", Value: now}}}},
+		}},
+	}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "status", Value: StatusRunning},
+			{Key: "owner", Value: w.id},
+			{Key: "leaseExpiration", Value: now.Add(w.leaseDuration)},
+			{Key: "updatedTime", Value: now},
+		}},
+		{Key: "$inc", Value: bson.D{{Key: "attempts", Value: 1}}},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "creationTime", Value: 1}}).
+		SetReturnDocument(options.After)
+	var job Job
+	if err := mongo.GetWriteCollection(consts.JobsCollection).FindOneAndUpdate(ctx, filter, update, opts).Decode(&job); err != nil {
+		if err == mongoDB.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// execute runs the job handler while renewing the lease, the handler context is canceled when the lease is lost
+func (w *worker) execute(ctx context.Context, job *Job) {
+	logger := zap.L().With(zap.String("jobId", job.ID), zap.String("jobType", job.Type), zap.Int("attempt", job.Attempts))
+	if job.Attempts > w.maxAttempts {
+		logger.Error("job exceeded max attempts")
+		w.finish(ctx, job, fmt.Errorf("job exceeded max attempts (%d)", w.maxAttempts))
+		return
+	}
+	if job.Progress == nil {
+		job.Progress = map[string]Progress{}
+	}
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	//renew the lease in the background
+	go func() {
+		ticker := time.NewTicker(w.leaseDuration / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-jobCtx.Done():
+				return
+			case <-ticker.C:
+				if err := w.renew(jobCtx, job, nil); err != nil {
+					logger.Warn("failed to renew job lease", zap.Error(err))
+					if err == ErrLeaseLost {
+						cancel()
+						return
+					}
+				}
+			}
+		}
+	}()
+	checkpoint := func(step string, progress Progress) error {
+		job.Progress[step] = progress
+		return w.renew(jobCtx, job, bson.E{Key: "progress." + step, Value: progress})
+	}
+	logger.Info("job started")
+	err := getHandler(job.Type)(jobCtx, job, checkpoint)
+	if err == ErrLeaseLost || ctx.Err() != nil {
+		//another replica took the job or this replica is shutting down - the job will be resumed from its last checkpoint
+		logger.Warn("job interrupted", zap.Error(err))
+		return
+	}
+	if err != nil && job.Attempts < w.maxAttempts {
+		logger.Warn("job attempt failed, the job is retried from its last checkpoint", zap.Error(err))
+		w.release(ctx, job, err)
+		return
+	}
+	if err != nil {
+		logger.Error("job failed", zap.Error(err))
+	} else {
+		logger.Info("job completed")
+	}
+	w.finish(ctx, job, err)
+}
+
+// renew extends the job lease and sets the optional field, it returns ErrLeaseLost if the job is not owned by this worker
+func (w *worker) renew(ctx context.Context, job *Job, field interface{}) error {
+	now := time.Now().UTC()
+	set := bson.D{
+		{Key: "leaseExpiration", Value: now.Add(w.leaseDuration)},
+		{Key: "updatedTime", Value: now},
+	}
+	if e, ok := field.(bson.E); ok {
+		set = append(set, e)
+	}
+	res, err := mongo.GetWriteCollection(consts.JobsCollection).UpdateOne(ctx,
+		bson.D{{Key: consts.IdField, Value: job.ID}, {Key: "owner", Value: w.id}, {Key: "status", Value: StatusRunning}},
+		bson.D{{Key: "$set", Value: set}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// finish marks the job as completed or failed and releases the lease
+func (w *worker) finish(ctx context.Context, job *Job, jobErr error) {
+	now := time.Now().UTC()
+	set := bson.D{
+		{Key: "status", Value: StatusCompleted},
+		{Key: "updatedTime", Value: now},
+		{Key: "completionTime", Value: now},
+	}
+	unset := bson.D{{Key: "leaseExpiration", Value: ""}, {Key: "retryTime", Value: ""}}
+	if jobErr != nil {
+		set[0].Value = StatusFailed
+		set = append(set, bson.E{Key: "error", Value: jobErr.Error()})
+	} else {
+		//the error of a failed attempt
+		unset = append(unset, bson.E{Key: "error", Value: ""})
+	}
+	if _, err := mongo.GetWriteCollection(consts.JobsCollection).UpdateOne(ctx,
+		bson.D{{Key: consts.IdField, Value: job.ID}, {Key: "owner", Value: w.id}},
+		bson.D{{Key: "$set", Value: set}, {Key: "$unset", Value: unset}}); err != nil {
+		zap.L().Error("failed to update job status", zap.String("jobId", job.ID), zap.Error(err))
+	}
+}
+
+// release returns the job of a failed attempt to pending and releases the lease, the job is claimed again after the poll interval
+func (w *worker) release(ctx context.Context, job *Job, jobErr error) {
+	now := time.Now().UTC()
+	set := bson.D{
+		{Key: "status", Value: StatusPending},
+		{Key: "updatedTime", Value: now},
+		{Key: "retryTime", Value: now.Add(w.pollInterval)},
+		{Key: "error", Value: jobErr.Error()},
+	}
+	if _, err := mongo.GetWriteCollection(consts.JobsCollection).UpdateOne(ctx,
+		bson.D{{Key: consts.IdField, Value: job.ID}, {Key: "owner", Value: w.id}, {Key: "status", Value: StatusRunning}},
+		bson.D{{Key: "$set", Value: set}, {Key: "$unset", Value: bson.D{{Key: "leaseExpiration", Value: ""}, {Key: "owner", Value: ""}}}}); err != nil {
+		zap.L().Error("failed to release job", zap.String("jobId", job.ID), zap.Error(err))
+	}
+}
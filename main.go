@@ -1,9 +1,16 @@
 package main
 
 import (
+	"config-service/handlers"
+	"config-service/idempotency"
+	"config-service/ratelimit"
+	"config-service/routes/graphql"
 	"config-service/routes/login"
+	"config-service/routes/openapi"
 	"config-service/routes/prob"
 	"config-service/routes/v1/admin"
+	"config-service/routes/v1/api_keys"
+	"config-service/routes/v1/batch"
 	"config-service/routes/v1/cluster"
 	"config-service/routes/v1/customer"
 	"config-service/routes/v1/customer_config"
@@ -11,8 +18,11 @@ import (
 	"config-service/routes/v1/posture_exception"
 	"config-service/routes/v1/registry_cron_job"
 	"config-service/routes/v1/repository"
+	"config-service/routes/v1/user_roles"
 	"config-service/routes/v1/vulnerability_exception"
+	"config-service/routes/v1/webhook"
 	"config-service/utils"
+	"config-service/utils/tlsconfig"
 	"context"
 	"log"
 	"net/http"
@@ -54,18 +64,37 @@ func setupRouter() *gin.Engine {
 	router.Use(requestSummary())
 	//recover from panics with 500 response
 	router.Use(ginzap.RecoveryWithZap(zapLogger, true))
+	//CORS headers and preflight requests
+	if corsConfig := utils.GetConfig().CORS; len(corsConfig.AllowedOrigins) > 0 {
+		router.Use(corsMiddleware(corsConfig))
+	}
 
 	//Public routes
 
 	//login routes
-	login.AddRoutes(router)
+	login.AddPublicRoutes(router)
 	//public (not authenticate routes
 	customer.AddPublicRoutes(router)
+	//OpenAPI document and swagger UI
+	openapi.AddRoutes(router)
 
 	//auth middleware
 	router.Use(authenticate)
+	//CSRF protection middleware
+	router.Use(csrfProtect)
+	//role based access middleware
+	router.Use(authorize)
+	//rate limit middleware
+	router.Use(ratelimit.Middleware)
+	//replay responses of retried POST requests with Idempotency-Key header
+	router.Use(idempotency.Middleware)
+
+	//v2 errors of requests without a matching route
+	router.NoRoute(handlers.ResponseNoRoute)
 
 	//add protected routes
+	//logout route
+	login.AddRoutes(router)
 	admin.AddRoutes(router)
 	cluster.AddRoutes(router)
 	posture_exception.AddRoutes(router)
@@ -75,26 +104,45 @@ func setupRouter() *gin.Engine {
 	framework.AddRoutes(router)
 	repository.AddRoutes(router)
 	registry_cron_job.AddRoutes(router)
+	api_keys.AddRoutes(router)
+	user_roles.AddRoutes(router)
+	batch.AddRoutes(router)
+	//webhook subscriptions of the document types of the routes above
+	webhook.AddRoutes(router)
+	//GraphQL queries of the document types of the routes above
+	graphql.AddRoutes(router)
 
 	return router
 }
 
 func startServer(handler http.Handler) {
-	port := utils.GetConfig().Port
+	conf := utils.GetConfig()
+	port := conf.Port
 	if port == "" {
 		port = "8080"
 		log.Printf("Defaulting to port %s", port)
 	}
-	srv := &http.Server{
-		Addr:    ":" + port,
-		Handler: handler,
+	srv := newServer(":"+port, handler, conf.Server)
+	if conf.Server.TLS.CertFile != "" {
+		tlsConfig, err := tlsconfig.New(conf.Server.TLS)
+		if err != nil {
+			zapLogger.Fatal("failed to initialize tls", zap.Error(err))
+		}
+		srv.TLSConfig = tlsConfig
 	}
-	zapLogger.Info("Starting server on port " + port)
+	zapLogger.Info("Starting server on port "+port, zap.Bool("tls", srv.TLSConfig != nil), zap.Bool("mTLS", conf.Server.TLS.ClientCAFile != ""))
 
 	// Initializing the server in a goroutine so that
 	// it won't block the graceful shutdown handling below
 	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if srv.TLSConfig != nil {
+			//certificate is provided by the tls config
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("listen: %s\n", err)
 		}
 	}()
@@ -113,3 +161,26 @@ func startServer(handler http.Handler) {
 	}
 	zapLogger.Info("Server exiting")
 }
+
+// newServer returns http server with the configured timeouts
+func newServer(addr string, handler http.Handler, conf utils.ServerConfig) *http.Server {
+	seconds := func(value, defaultValue int) time.Duration {
+		if value <= 0 {
+			value = defaultValue
+		}
+		return time.Duration(value) * time.Second
+	}
+	maxHeaderBytes := conf.MaxHeaderBytes
+	if maxHeaderBytes <= 0 {
+		maxHeaderBytes = http.DefaultMaxHeaderBytes
+	}
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       seconds(conf.ReadTimeoutSeconds, 60),
+		ReadHeaderTimeout: seconds(conf.ReadHeaderTimeoutSeconds, 10),
+		WriteTimeout:      seconds(conf.WriteTimeoutSeconds, 120),
+		IdleTimeout:       seconds(conf.IdleTimeoutSeconds, 120),
+		MaxHeaderBytes:    maxHeaderBytes,
+	}
+}
@@ -1,8 +1,15 @@
 package main
 
 import (
+	"config-service/auth"
+	"config-service/handlers"
+	"config-service/routes/v1/batch"
+	"config-service/utils"
 	"config-service/utils/consts"
+	"config-service/utils/log"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,22 +28,215 @@ import (
 
 // authenticate middleware for request authentication
 func authenticate(c *gin.Context) {
+	//operations of a batch request act with the identity of the batch request
+	if batchIdentity := batch.Identity(c); batchIdentity != nil {
+		for key, value := range batchIdentity {
+			c.Set(key, value)
+		}
+		c.Set(consts.BatchOperation, true)
+		if c.GetString(consts.AuthMethod) == auth.MethodAPIKey && !apiKeyRouteAllowed(c.FullPath()) {
+			handlers.ResponseForbidden(c, "API keys are not allowed for this route")
+			return
+		}
+		c.Next()
+		return
+	}
+	var identity *auth.Identity
+	if customerGUID := c.GetHeader(consts.CustomerGUIDHeader); customerGUID != "" && c.Request.TLS != nil {
+		var err error
+		if identity, err = auth.ServiceIdentity(c.Request.TLS, customerGUID); err != nil {
+			log.LogNTraceError("service identity validation failed", err, c)
+		}
+	} else if authHeader := c.GetHeader("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+		var err error
+		if identity, err = auth.ValidateBearerToken(strings.TrimPrefix(authHeader, "Bearer ")); err != nil {
+			log.LogNTraceError("bearer token validation failed", err, c)
+		}
+	} else if apiKey := c.GetHeader(consts.APIKeyHeader); apiKey != "" {
+		var err error
+		if identity, err = auth.ValidateAPIKey(c, apiKey); err != nil {
+			log.LogNTraceError("API key validation failed", err, c)
+		}
+	} else if sessionToken, err := c.Cookie(consts.SessionCookie); err == nil && sessionToken != "" {
+		if identity, err = auth.ValidateSessionToken(c, sessionToken); err != nil {
+			log.LogNTraceError("session validation failed", err, c)
+		}
+	} else if auth.CookieAuthAllowed() {
+		identity = cookieIdentity(c)
+	}
+	if identity == nil {
+		handlers.ResponseError(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	c.Set(consts.CustomerGUID, identity.CustomerGUID)
+	c.Set(consts.AuthMethod, identity.Method)
+	if identity.UserID != "" {
+		c.Set(consts.UserID, identity.UserID)
+	}
+	if identity.Admin {
+		c.Set(consts.AdminAccess, true)
+	}
+	if identity.Method == auth.MethodAPIKey {
+		if !apiKeyRouteAllowed(c.FullPath()) {
+			handlers.ResponseForbidden(c, "API keys are not allowed for this route")
+			return
+		}
+		c.Set(consts.APIKeyScopes, identity.Scopes)
+	}
+	if identity.ReadOnly {
+		c.Set(consts.ReadOnly, true)
+	}
+	if identity.Impersonator != nil {
+		c.Set(consts.Impersonator, identity.Impersonator.CustomerGUID)
+		if identity.Impersonator.UserID != "" {
+			c.Set(consts.ImpersonatorID, identity.Impersonator.UserID)
+		}
+		//tag the request logger and span with the impersonator
+		c.Set(consts.ReqLogger, log.GetLogger(c).With(identityLogFields(c)...))
+		if span := log.GetTraceSpan(c); span != nil {
+			span.SetAttributes(attribute.String(consts.CustomerGUID, identity.CustomerGUID),
+				attribute.String(consts.Impersonator, identity.Impersonator.CustomerGUID),
+				attribute.String(consts.ImpersonatorID, identity.Impersonator.UserID),
+				attribute.Bool(consts.ReadOnly, identity.ReadOnly))
+		}
+		log.LogNTrace("impersonated request", c)
+	}
+	c.Next()
+}
+
+// authorize middleware enforces read only sessions and the role based access policy of tenant users
+// admins and API keys (that are limited by their scopes) are not checked, admin routes are checked by the admin middleware
+func authorize(c *gin.Context) {
+	//any authenticated caller can end its session
+	if c.FullPath() == consts.LogoutPath {
+		c.Next()
+		return
+	}
+	//GraphQL queries are read only also when sent with POST
+	if c.GetBool(consts.ReadOnly) && !isSafeMethod(c.Request.Method) && c.FullPath() != consts.GraphQLPath {
+		handlers.ResponseForbidden(c, "read only session")
+		return
+	}
+	//impersonation sessions with write access act as the tenant owner
+	if c.GetString(consts.Impersonator) != "" {
+		c.Next()
+		return
+	}
+	if c.GetBool(consts.AdminAccess) || c.GetString(consts.AuthMethod) == auth.MethodAPIKey || strings.HasPrefix(c.FullPath(), consts.AdminPath) {
+		c.Next()
+		return
+	}
+	var role auth.Role
+	if c.GetString(consts.AuthMethod) == auth.MethodClientCert {
+		//trusted services have no stored roles in the customers
+		role = auth.ServiceRole()
+	} else {
+		var err error
+		if role, err = auth.GetUserRole(c, c.GetString(consts.CustomerGUID), c.GetString(consts.UserID)); err != nil {
+			handlers.ResponseInternalServerError(c, "failed to get user role", err)
+			return
+		}
+	}
+	c.Set(consts.UserRole, string(role))
+	if permission := auth.RequiredPermission(c.Request.Method, handlers.RouteBasePath(c.FullPath())); !auth.HasPermission(role, permission) {
+		msg := fmt.Sprintf("missing permission %s", permission)
+		log.LogNTrace(msg, c)
+		handlers.ResponseProblem(c, http.StatusForbidden, handlers.ErrorCodeMissingPermission, msg, gin.H{"permission": permission})
+		return
+	}
+	c.Next()
+}
+
+// csrfProtect middleware requires a double submit CSRF token in state changing requests that are authenticated by cookies
+func csrfProtect(c *gin.Context) {
+	method := c.GetString(consts.AuthMethod)
+	//operations of a batch request are checked with the batch request
+	if !auth.CSRFProtectionEnabled() || isSafeMethod(c.Request.Method) || (method != auth.MethodSession && method != auth.MethodCookie) || c.GetBool(consts.BatchOperation) {
+		c.Next()
+		return
+	}
+	csrfHeader := c.GetHeader(consts.CSRFHeader)
+	csrfCookie, _ := c.Cookie(consts.CSRFCookie)
+	valid := csrfHeader != "" && csrfHeader == csrfCookie
+	if valid && method == auth.MethodSession {
+		//the token must also belong to the session
+		sessionToken, _ := c.Cookie(consts.SessionCookie)
+		valid = auth.ValidCSRFToken(sessionToken, csrfHeader)
+	}
+	if !valid {
+		handlers.ResponseForbidden(c, "invalid CSRF token")
+		return
+	}
+	c.Next()
+}
+
+// corsMiddleware handles CORS preflight requests and sets the CORS headers of allowed origins
+func corsMiddleware(conf utils.CORSConfig) gin.HandlerFunc {
+	allowedMethods := conf.AllowedMethods
+	if len(allowedMethods) == 0 {
+		allowedMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodHead}
+	}
+	allowedHeaders := conf.AllowedHeaders
+	if len(allowedHeaders) == 0 {
+		allowedHeaders = []string{"Content-Type", "Authorization", consts.APIKeyHeader, consts.CSRFHeader, consts.IdempotencyKeyHeader}
+	}
+	allowAll := slices.Contains(conf.AllowedOrigins, "*")
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		if !allowAll && !slices.Contains(conf.AllowedOrigins, origin) {
+			if c.Request.Method == http.MethodOptions {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			//not allowed origin gets no CORS headers so the browser blocks the response
+			c.Next()
+			return
+		}
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+		if allowAll && !conf.AllowCredentials {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if conf.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if len(conf.ExposedHeaders) > 0 {
+			header.Set("Access-Control-Expose-Headers", strings.Join(conf.ExposedHeaders, ", "))
+		}
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			header.Set("Access-Control-Allow-Methods", strings.Join(allowedMethods, ", "))
+			header.Set("Access-Control-Allow-Headers", strings.Join(allowedHeaders, ", "))
+			if conf.MaxAgeSeconds > 0 {
+				header.Set("Access-Control-Max-Age", strconv.Itoa(conf.MaxAgeSeconds))
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
+
+// cookieIdentity returns the identity from the unsigned customerGUID cookie or query param, for development only
+func cookieIdentity(c *gin.Context) *auth.Identity {
 	cookieVal, err := c.Cookie(consts.CustomerGUID)
 	customerValues := strings.Split(cookieVal, ";")
 	customerGuid := customerValues[0]
 	if err != nil || customerGuid == "" {
-
-		customerGuid := c.Query(consts.CustomerGUID)
-		if customerGuid == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			return
+		if customerGuid = c.Query(consts.CustomerGUID); customerGuid == "" {
+			return nil
 		}
 	}
-	c.Set(consts.CustomerGUID, customerGuid)
-	if len(customerValues) > 1 && slices.Contains(customerValues[1:], consts.AdminAccess) {
-		c.Set(consts.AdminAccess, true)
+	return &auth.Identity{
+		CustomerGUID: customerGuid,
+		Admin:        len(customerValues) > 1 && slices.Contains(customerValues[1:], consts.AdminAccess),
+		Method:       auth.MethodCookie,
 	}
-	c.Next()
 }
 
 // traceAttributesNHeader middleware adds tracing header in response and request attributes in span
@@ -72,17 +272,41 @@ func requestSummary() func(c *gin.Context) {
 
 /////////////////////////////////////helper functions/////////////////////////////////////
 
+// apiKeyRouteAllowed returns true for the scoped routes and GraphQL queries, the API key scopes are checked by the routes middleware
+// and by the GraphQL executor of each document type
+func apiKeyRouteAllowed(fullPath string) bool {
+	return handlers.IsScopedRoute(fullPath) || fullPath == consts.GraphQLPath
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
 // telemetryLogFields returns telemetry and customer id fields for  logging
 func telemetryLogFields(c *gin.Context) []zapcore.Field {
+	fields := identityLogFields(c)
+	// log trace and span ID
+	if trace.SpanFromContext(c.Request.Context()).SpanContext().IsValid() {
+		fields = append(fields, zap.String("trace_id", trace.SpanFromContext(c.Request.Context()).SpanContext().TraceID().String()))
+		fields = append(fields, zap.String("span_id", trace.SpanFromContext(c.Request.Context()).SpanContext().SpanID().String()))
+	}
+	return fields
+}
+
+// identityLogFields returns the customer id and impersonation fields for logging
+func identityLogFields(c *gin.Context) []zapcore.Field {
 	fields := []zapcore.Field{}
 	// log request ID
 	if customerGUID := c.GetString(consts.CustomerGUID); customerGUID != "" {
 		fields = append(fields, zap.String(consts.CustomerGUID, customerGUID))
 	}
-	// log trace and span ID
-	if trace.SpanFromContext(c.Request.Context()).SpanContext().IsValid() {
-		fields = append(fields, zap.String("trace_id", trace.SpanFromContext(c.Request.Context()).SpanContext().TraceID().String()))
-		fields = append(fields, zap.String("span_id", trace.SpanFromContext(c.Request.Context()).SpanContext().SpanID().String()))
+	// log impersonating admin
+	if impersonator := c.GetString(consts.Impersonator); impersonator != "" {
+		fields = append(fields, zap.String(consts.Impersonator, impersonator))
+		if impersonatorID := c.GetString(consts.ImpersonatorID); impersonatorID != "" {
+			fields = append(fields, zap.String(consts.ImpersonatorID, impersonatorID))
+		}
+		fields = append(fields, zap.Bool(consts.ReadOnly, c.GetBool(consts.ReadOnly)))
 	}
 	return fields
 }
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const openAPISpecFile = "openapi.json"
+
+var updateOpenAPISpec = flag.Bool("update-openapi", false, "update "+openAPISpecFile+" with the generated OpenAPI document")
+
+// TestOpenAPISpec fails when the routes change without updating openapi.json, run with -update-openapi to update it
+func TestOpenAPISpec(t *testing.T) {
+	if zapLogger == nil {
+		//the document is generated without initializing the service
+		zapLogger, zapInfoLevelLogger = zap.NewNop(), zap.NewNop()
+	}
+	router := setupRouter()
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/openapi.json", nil)
+	require.NoError(t, err)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	if *updateOpenAPISpec {
+		var spec interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec))
+		specBytes, err := json.MarshalIndent(spec, "", "  ")
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(openAPISpecFile, append(specBytes, '\n'), 0644))
+	}
+	expected, err := os.ReadFile(openAPISpecFile)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(expected), w.Body.String(), "routes changed, run the test with -update-openapi and commit "+openAPISpecFile)
+}
@@ -0,0 +1,183 @@
+package ratelimit
+
+import (
+	"config-service/db"
+	"config-service/db/mongo"
+	"config-service/handlers"
+	"config-service/utils"
+	"config-service/utils/consts"
+	"config-service/utils/log"
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// per customer rate limiting and concurrency quotas that are shared by all the replicas
+// each customer, route group and route class has a bucket document in db that is updated atomically on each request with:
+// - a token bucket of the request rate
+// - the list of in-flight requests leases, expired leases of crashed replicas are dropped on the next update
+
+const (
+	ClassRead  = "read"
+	ClassWrite = "write"
+)
+
+// in-flight lease duration, a lease that was not released by a crashed replica is dropped after this duration
+const inFlightLeaseDuration = 2 * time.Minute
+
+var config utils.RateLimitConfig
+
+// Init sets the rate limits configuration
+func Init(rateLimitConfig utils.RateLimitConfig) {
+	config = rateLimitConfig
+}
+
+// bucket - rate limit bucket document in db
+type bucket struct {
+	ID         string          `bson:"_id"`
+	Tokens     float64         `bson:"tokens"`
+	RefillTime time.Time       `bson:"refillTime"`
+	InFlight   []inFlightLease `bson:"inFlight"`
+	Allowed    bool            `bson:"allowed"`
+}
+
+type inFlightLease struct {
+	ID         string    `bson:"id"`
+	Expiration time.Time `bson:"expiration"`
+}
+
+// Middleware limits the requests rate and in-flight requests of the authenticated customer
+// requests are allowed when the limits cannot be checked (e.g. db errors)
+func Middleware(c *gin.Context) {
+	customerGUID := c.GetString(consts.CustomerGUID)
+	group := handlers.RouteBasePath(c.FullPath())
+	class := routeClass(c.Request.Method)
+	limit, ok := limitFor(config, customerGUID, group, class)
+	//operations of a batch request are limited as the batch request
+	if !ok || customerGUID == "" || c.GetBool(consts.BatchOperation) {
+		c.Next()
+		return
+	}
+	key := strings.Join([]string{customerGUID, group, class}, "|")
+	requestID := uuid.NewV4().String()
+	b, err := take(c, key, requestID, limit)
+	if err != nil {
+		log.LogNTraceError("failed to check rate limit", err, c)
+		c.Next()
+		return
+	}
+	if !b.Allowed {
+		retryAfter := 1
+		msg := "too many concurrent requests"
+		if limit.RequestsPerSecond > 0 && b.Tokens < 1 {
+			retryAfter = int(math.Ceil((1 - b.Tokens) / limit.RequestsPerSecond))
+			msg = "rate limit exceeded"
+		}
+		log.LogNTrace(msg+" for "+key, c)
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		handlers.ResponseProblem(c, http.StatusTooManyRequests, handlers.ErrorCodeRateLimited, msg, gin.H{"retryAfterSeconds": retryAfter})
+		return
+	}
+	if limit.MaxInFlight > 0 {
+		defer release(c, key, requestID)
+	}
+	c.Next()
+}
+
+// take consumes a token and adds an in-flight lease if both limits allow it
+func take(c context.Context, key, requestID string, limit utils.RateLimit) (*bucket, error) {
+	now := time.Now().UTC()
+	pipeline := bson.A{}
+	conditions := bson.A{}
+	apply := bson.D{}
+	if limit.RequestsPerSecond > 0 {
+		burst := float64(limit.Burst)
+		if burst < 1 {
+			burst = math.Max(limit.RequestsPerSecond, 1)
+		}
+		elapsedSeconds := bson.D{{Key: "$divide", Value: bson.A{
+			bson.D{{Key: "$subtract", Value: bson.A{now, bson.D{{Key: "$ifNull", Value: bson.A{"$refillTime", now}}}}}}, 1000}}}
+		refilled := bson.D{{Key: "$add", Value: bson.A{
+			bson.D{{Key: "$ifNull", Value: bson.A{"$tokens", burst}}},
+			bson.D{{Key: "$multiply", Value: bson.A{elapsedSeconds, limit.RequestsPerSecond}}}}}}
+		pipeline = append(pipeline, bson.D{{Key: "$set", Value: bson.D{
+			{Key: "tokens", Value: bson.D{{Key: "$min", Value: bson.A{burst, refilled}}}},
+			{Key: "refillTime", Value: now},
+		}}})
+		conditions = append(conditions, bson.D{{Key: "$gte", Value: bson.A{"$tokens", 1}}})
+		apply = append(apply, bson.E{Key: "tokens", Value: bson.D{{Key: "$cond", Value: bson.A{"$allowed", bson.D{{Key: "$subtract", Value: bson.A{"$tokens", 1}}}, "$tokens"}}}})
+	}
+	if limit.MaxInFlight > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$set", Value: bson.D{
+			{Key: "inFlight", Value: bson.D{{Key: "$filter", Value: bson.D{
+				{Key: "input", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$inFlight", bson.A{}}}}},
+				{Key: "cond", Value: bson.D{{Key: "$gt", Value: bson.A{"$$this.expiration", now}}}},
+			}}}},
+		}}})
+		conditions = append(conditions, bson.D{{Key: "$lt", Value: bson.A{bson.D{{Key: "$size", Value: "$inFlight"}}, limit.MaxInFlight}}})
+		lease := bson.A{bson.D{{Key: "id", Value: requestID}, {Key: "expiration", Value: now.Add(inFlightLeaseDuration)}}}
+		apply = append(apply, bson.E{Key: "inFlight", Value: bson.D{{Key: "$cond", Value: bson.A{"$allowed", bson.D{{Key: "$concatArrays", Value: bson.A{"$inFlight", lease}}}, "$inFlight"}}}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$set", Value: bson.D{{Key: "allowed", Value: bson.D{{Key: "$and", Value: conditions}}}}}},
+		bson.D{{Key: "$set", Value: apply}})
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var b bucket
+	err := mongo.GetWriteCollection(consts.RateLimitsCollection).FindOneAndUpdate(c, bson.D{{Key: consts.IdField, Value: key}}, pipeline, opts).Decode(&b)
+	if db.IsDuplicateKeyError(err) {
+		//concurrent first requests of the bucket, the bucket exists now
+		err = mongo.GetWriteCollection(consts.RateLimitsCollection).FindOneAndUpdate(c, bson.D{{Key: consts.IdField, Value: key}}, pipeline, opts).Decode(&b)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// release removes the in-flight lease of the request
+func release(c *gin.Context, key, requestID string) {
+	//use a new context, the request context may be canceled
+	if _, err := mongo.GetWriteCollection(consts.RateLimitsCollection).UpdateOne(context.Background(),
+		bson.D{{Key: consts.IdField, Value: key}},
+		bson.D{{Key: "$pull", Value: bson.D{{Key: "inFlight", Value: bson.D{{Key: "id", Value: requestID}}}}}}); err != nil {
+		log.LogNTraceError("failed to release in-flight request", err, c)
+	}
+}
+
+// limitFor returns the limit of the customer route group and class, customer overrides take precedence and
+// route group limits take precedence over class limits
+func limitFor(config utils.RateLimitConfig, customerGUID, group, class string) (utils.RateLimit, bool) {
+	keys := []string{group + ":" + class, class}
+	if customerLimits, ok := config.Customers[customerGUID]; ok {
+		for _, key := range keys {
+			if limit, ok := customerLimits[key]; ok {
+				return limit, isLimited(limit)
+			}
+		}
+	}
+	for _, key := range keys {
+		if limit, ok := config.Classes[key]; ok {
+			return limit, isLimited(limit)
+		}
+	}
+	return utils.RateLimit{}, false
+}
+
+func isLimited(limit utils.RateLimit) bool {
+	return limit.RequestsPerSecond > 0 || limit.MaxInFlight > 0
+}
+
+func routeClass(method string) string {
+	if method == http.MethodGet || method == http.MethodHead {
+		return ClassRead
+	}
+	return ClassWrite
+}
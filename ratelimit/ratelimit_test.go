@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"config-service/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitFor(t *testing.T) {
+	config := utils.RateLimitConfig{
+		Classes: map[string]utils.RateLimit{
+			ClassRead:                        {RequestsPerSecond: 100},
+			ClassWrite:                       {RequestsPerSecond: 10, MaxInFlight: 5},
+			"v1_customer_configuration:read": {RequestsPerSecond: 20},
+		},
+		Customers: map[string]map[string]utils.RateLimit{
+			"big-customer":   {ClassRead: {RequestsPerSecond: 1000}},
+			"noisy-customer": {"v1_customer_configuration:read": {RequestsPerSecond: 1}},
+			"free-customer":  {ClassWrite: {}},
+		},
+	}
+	tests := []struct {
+		name         string
+		customerGUID string
+		group        string
+		class        string
+		want         float64
+		wantLimited  bool
+	}{
+		{name: "class limit", customerGUID: "c1", group: "cluster", class: ClassRead, want: 100, wantLimited: true},
+		{name: "group limit", customerGUID: "c1", group: "v1_customer_configuration", class: ClassRead, want: 20, wantLimited: true},
+		{name: "customer class override", customerGUID: "big-customer", group: "cluster", class: ClassRead, want: 1000, wantLimited: true},
+		{name: "customer group override", customerGUID: "noisy-customer", group: "v1_customer_configuration", class: ClassRead, want: 1, wantLimited: true},
+		{name: "customer other class", customerGUID: "noisy-customer", group: "cluster", class: ClassWrite, want: 10, wantLimited: true},
+		{name: "customer unlimited", customerGUID: "free-customer", group: "cluster", class: ClassWrite},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, limited := limitFor(config, tt.customerGUID, tt.group, tt.class)
+			assert.Equal(t, tt.wantLimited, limited)
+			assert.Equal(t, tt.want, limit.RequestsPerSecond)
+		})
+	}
+	_, limited := limitFor(utils.RateLimitConfig{}, "c1", "cluster", ClassRead)
+	assert.False(t, limited, "no limits configured")
+}
@@ -0,0 +1,426 @@
+package graphql
+
+import (
+	"bytes"
+	"config-service/auth"
+	"config-service/db"
+	"config-service/handlers"
+	"config-service/utils/consts"
+	"config-service/utils/log"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// execution of queries - the selections of all the objects of a type at the same path are resolved together,
+// so a relation is loaded with one db query for all the parent documents instead of a query per document
+
+var fieldPath = regexp.MustCompile(`^[_0-9A-Za-z]+(\.[_0-9A-Za-z]+)*$`)
+
+// Response - GraphQL response body
+type Response struct {
+	Data   interface{}   `json:"data,omitempty"`
+	Errors gqlerror.List `json:"errors,omitempty"`
+}
+
+// object - response object with the fields in the order of the selection
+type object []objectField
+
+type objectField struct {
+	name  string
+	value interface{}
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(field.name)
+		buf.Write(name)
+		buf.WriteByte(':')
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+type executor struct {
+	c         *gin.Context
+	schema    *Schema
+	vars      map[string]interface{}
+	fragments ast.FragmentDefinitionList
+	errors    gqlerror.List
+}
+
+// execute runs a validated query operation, errors of fields are returned with a null value of the field
+func (s *Schema) execute(c *gin.Context, doc *ast.QueryDocument, operation *ast.OperationDefinition, vars map[string]interface{}) *Response {
+	e := &executor{c: c, schema: s, vars: vars, fragments: doc.Fragments}
+	data := e.resolveQuery(operation.SelectionSet)
+	return &Response{Data: data, Errors: e.errors}
+}
+
+func (e *executor) resolveQuery(selectionSet ast.SelectionSet) object {
+	keys, fields := e.collectFields(queryType, selectionSet)
+	data := object{}
+	for _, key := range keys {
+		field := fields[key][0]
+		path := ast.Path{ast.PathName(key)}
+		var value interface{}
+		switch field.Name {
+		case "__typename":
+			value = queryType
+		case "__schema", "__type":
+			e.addError(path, "introspection is not supported, the schema is served at GET %s", consts.GraphQLSchemaPath)
+		default:
+			docType := e.schema.queries[field.Name]
+			docs, err := e.query(docType, field.ArgumentMap(e.vars))
+			if err != nil {
+				e.addError(path, "failed to query %s: %s", field.Name, err.Error())
+				break
+			}
+			value = e.completeValues(field.Definition.Type, []interface{}{docs}, []ast.Path{path}, mergedSelectionSet(fields[key]))[0]
+		}
+		data = append(data, objectField{name: key, value: value})
+	}
+	return data
+}
+
+// query finds the documents of the type that match the query field arguments
+func (e *executor) query(docType handlers.DocType, args map[string]interface{}) ([]interface{}, error) {
+	filterBuilder, err := queryFilter(args)
+	if err != nil {
+		return nil, err
+	}
+	return e.find(docType, filterBuilder)
+}
+
+func (e *executor) find(docType handlers.DocType, filterBuilder *db.FilterBuilder) ([]interface{}, error) {
+	if err := e.authorize(docType); err != nil {
+		return nil, err
+	}
+	docs, err := docType.Find(e.c, filterBuilder)
+	if err != nil {
+		log.LogNTraceError("failed to find "+docType.Name+" documents", err, e.c)
+		return nil, fmt.Errorf("failed to read documents")
+	}
+	values := make([]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		values = append(values, doc)
+	}
+	return values, nil
+}
+
+// authorize checks that the caller can read the documents of the type like the GET routes of the type path,
+// API keys need a read scope of the path and users need the role permission to read the path, admins and impersonators are not checked
+func (e *executor) authorize(docType handlers.DocType) error {
+	if e.c.GetBool(consts.AdminAccess) || e.c.GetString(consts.Impersonator) != "" {
+		return nil
+	}
+	if e.c.GetString(consts.AuthMethod) == auth.MethodAPIKey {
+		if requiredScope, ok := handlers.HasScope(e.c.GetStringSlice(consts.APIKeyScopes), strings.TrimPrefix(docType.Path, "/"), http.MethodGet); !ok {
+			log.LogNTrace("API key is missing scope "+requiredScope, e.c)
+			return fmt.Errorf("API key is missing scope %s", requiredScope)
+		}
+		return nil
+	}
+	//the role is set by the authorize middleware
+	if permission := auth.RequiredPermission(http.MethodGet, docType.Path); !auth.HasPermission(auth.Role(e.c.GetString(consts.UserRole)), permission) {
+		log.LogNTrace("missing permission "+string(permission), e.c)
+		return fmt.Errorf("missing permission %s", permission)
+	}
+	return nil
+}
+
+// completeValues returns the response values of field values of the type, the values of all the objects are resolved together
+func (e *executor) completeValues(typ *ast.Type, values []interface{}, paths []ast.Path, selectionSet ast.SelectionSet) []interface{} {
+	completed := make([]interface{}, len(values))
+	if typ.Elem != nil {
+		//flatten the items of all the lists
+		items, itemPaths, owners := []interface{}{}, []ast.Path{}, []int{}
+		for i, value := range values {
+			list, ok := value.([]interface{})
+			if !ok {
+				continue
+			}
+			completed[i] = []interface{}{}
+			for j, item := range list {
+				items = append(items, item)
+				itemPaths = append(itemPaths, appendPath(paths[i], ast.PathIndex(j)))
+				owners = append(owners, i)
+			}
+		}
+		for j, item := range e.completeValues(typ.Elem, items, itemPaths, selectionSet) {
+			completed[owners[j]] = append(completed[owners[j]].([]interface{}), item)
+		}
+		return completed
+	}
+	if def := e.schema.schema.Types[typ.NamedType]; def == nil || def.Kind != ast.Object {
+		//scalars are returned as they are in the documents json
+		copy(completed, values)
+		return completed
+	}
+	objects, objectPaths, owners := []map[string]interface{}{}, []ast.Path{}, []int{}
+	for i, value := range values {
+		if obj, ok := value.(map[string]interface{}); ok {
+			objects = append(objects, obj)
+			objectPaths = append(objectPaths, paths[i])
+			owners = append(owners, i)
+		}
+	}
+	for j, obj := range e.resolveObjects(typ.NamedType, objects, objectPaths, selectionSet) {
+		completed[owners[j]] = obj
+	}
+	return completed
+}
+
+// resolveObjects resolves the selection set of the objects of the type
+func (e *executor) resolveObjects(typeName string, objects []map[string]interface{}, paths []ast.Path, selectionSet ast.SelectionSet) []object {
+	resolved := make([]object, len(objects))
+	if len(objects) == 0 {
+		return resolved
+	}
+	keys, fields := e.collectFields(typeName, selectionSet)
+	for _, key := range keys {
+		field := fields[key][0]
+		fieldPaths := make([]ast.Path, len(objects))
+		for i := range objects {
+			fieldPaths[i] = appendPath(paths[i], ast.PathName(key))
+		}
+		values := make([]interface{}, len(objects))
+		if field.Name == "__typename" {
+			for i := range values {
+				values[i] = typeName
+			}
+		} else if rel, ok := e.schema.relations[typeName][field.Name]; ok {
+			var err error
+			if values, err = e.loadRelation(rel, objects); err != nil {
+				e.addError(fieldPaths[0], "failed to resolve %s: %s", field.Name, err.Error())
+				values = make([]interface{}, len(objects))
+			}
+		} else {
+			for i, obj := range objects {
+				values[i] = obj[field.Name]
+			}
+		}
+		for i, value := range e.completeValues(field.Definition.Type, values, fieldPaths, mergedSelectionSet(fields[key])) {
+			resolved[i] = append(resolved[i], objectField{name: key, value: value})
+		}
+	}
+	return resolved
+}
+
+// loadRelation loads the documents of the relation of all the objects with one db query and returns the field value of each object
+func (e *executor) loadRelation(rel relation, objects []map[string]interface{}) ([]interface{}, error) {
+	keys := []string{}
+	seen := map[string]bool{}
+	for _, obj := range objects {
+		for _, key := range fieldValues(obj, rel.parentField) {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	var docs []interface{}
+	if len(keys) > 0 {
+		var err error
+		docType := e.schema.docTypes[e.schema.objectNames[rel.target]]
+		if docs, err = e.find(docType, db.NewFilterBuilder().WithIn(rel.targetField, keys)); err != nil {
+			return nil, err
+		}
+	}
+	//indexes of the documents by matching value
+	matches := map[string][]int{}
+	for i, doc := range docs {
+		for _, key := range fieldValues(doc, rel.targetField) {
+			if indexes := matches[key]; len(indexes) == 0 || indexes[len(indexes)-1] != i {
+				matches[key] = append(indexes, i)
+			}
+		}
+	}
+	values := make([]interface{}, len(objects))
+	for i, obj := range objects {
+		indexes := []int{}
+		added := map[int]bool{}
+		for _, key := range fieldValues(obj, rel.parentField) {
+			for _, index := range matches[key] {
+				if !added[index] {
+					added[index] = true
+					indexes = append(indexes, index)
+				}
+			}
+		}
+		sort.Ints(indexes)
+		if !rel.list {
+			if len(indexes) > 0 {
+				values[i] = docs[indexes[0]]
+			}
+			continue
+		}
+		list := make([]interface{}, 0, len(indexes))
+		for _, index := range indexes {
+			list = append(list, docs[index])
+		}
+		values[i] = list
+	}
+	return values, nil
+}
+
+// collectFields returns the response keys of the selection set of the type in order and their fields,
+// fragments are expanded and fields with @skip or @include directives are skipped accordingly
+func (e *executor) collectFields(typeName string, selectionSet ast.SelectionSet) ([]string, map[string][]*ast.Field) {
+	keys := []string{}
+	fields := map[string][]*ast.Field{}
+	var collect func(selectionSet ast.SelectionSet, visited map[string]bool)
+	collect = func(selectionSet ast.SelectionSet, visited map[string]bool) {
+		for _, selection := range selectionSet {
+			switch selection := selection.(type) {
+			case *ast.Field:
+				if !e.included(selection.Directives) {
+					continue
+				}
+				if _, ok := fields[selection.Alias]; !ok {
+					keys = append(keys, selection.Alias)
+				}
+				fields[selection.Alias] = append(fields[selection.Alias], selection)
+			case *ast.InlineFragment:
+				if e.included(selection.Directives) && (selection.TypeCondition == "" || selection.TypeCondition == typeName) {
+					collect(selection.SelectionSet, visited)
+				}
+			case *ast.FragmentSpread:
+				if visited[selection.Name] || !e.included(selection.Directives) {
+					continue
+				}
+				visited[selection.Name] = true
+				if fragment := e.fragments.ForName(selection.Name); fragment != nil && fragment.TypeCondition == typeName {
+					collect(fragment.SelectionSet, visited)
+				}
+			}
+		}
+	}
+	collect(selectionSet, map[string]bool{})
+	return keys, fields
+}
+
+func (e *executor) included(directives ast.DirectiveList) bool {
+	if skip := directives.ForName("skip"); skip != nil && skip.ArgumentMap(e.vars)["if"] == true {
+		return false
+	}
+	if include := directives.ForName("include"); include != nil && include.ArgumentMap(e.vars)["if"] == false {
+		return false
+	}
+	return true
+}
+
+func (e *executor) addError(path ast.Path, format string, args ...interface{}) {
+	e.errors = append(e.errors, gqlerror.ErrorPathf(path, format, args...))
+}
+
+// mergedSelectionSet returns the selection sets of the fields of a response key
+func mergedSelectionSet(fields []*ast.Field) ast.SelectionSet {
+	if len(fields) == 1 {
+		return fields[0].SelectionSet
+	}
+	selectionSet := ast.SelectionSet{}
+	for _, field := range fields {
+		selectionSet = append(selectionSet, field.SelectionSet...)
+	}
+	return selectionSet
+}
+
+func appendPath(path ast.Path, element ast.PathElement) ast.Path {
+	return append(append(make(ast.Path, 0, len(path)+1), path...), element)
+}
+
+// queryFilter returns the db filter of the arguments of a query field
+func queryFilter(args map[string]interface{}) (*db.FilterBuilder, error) {
+	filterBuilder := db.NewFilterBuilder()
+	if guid, ok := args["guid"].(string); ok {
+		filterBuilder.WithGUID(guid)
+	}
+	if guids := listValue(args["guids"]); guids != nil {
+		filterBuilder.WithIn(consts.GUIDField, guids)
+	}
+	if name, ok := args["name"].(string); ok {
+		filterBuilder.WithName(name)
+	}
+	if names := listValue(args["names"]); names != nil {
+		filterBuilder.WithIn(consts.NameField, names)
+	}
+	for _, item := range listValue(args["where"]) {
+		filter, _ := item.(map[string]interface{})
+		field, _ := filter["field"].(string)
+		if !fieldPath.MatchString(field) {
+			return nil, fmt.Errorf("invalid filter field %q", field)
+		}
+		conditions := 0
+		for _, condition := range []string{"eq", "ne", "in"} {
+			value, ok := filter[condition]
+			if !ok {
+				continue
+			}
+			values := []interface{}{value}
+			if condition == "in" {
+				if values = listValue(value); values == nil {
+					continue
+				}
+			}
+			for _, value := range values {
+				if !isScalar(value) {
+					return nil, fmt.Errorf("filter of field %s: %s value must be a scalar", field, condition)
+				}
+			}
+			switch condition {
+			case "eq":
+				filterBuilder.WithValue(field, value)
+			case "ne":
+				filterBuilder.WithNotEqual(field, value)
+			case "in":
+				filterBuilder.WithIn(field, values)
+			}
+			conditions++
+		}
+		if exists, ok := filter["exists"].(bool); ok {
+			filterBuilder.WithExists(field, exists)
+			conditions++
+		}
+		if conditions == 0 {
+			return nil, fmt.Errorf("filter of field %s has no condition", field)
+		}
+	}
+	return filterBuilder, nil
+}
+
+// listValue returns the list of a list argument value, a single value is a list of one item (input coercion)
+func listValue(value interface{}) []interface{} {
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return value
+	}
+	return []interface{}{value}
+}
+
+// isScalar returns true for values that are compared as they are, objects could be db query operators
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case nil, string, bool, int, int64, float64, json.Number:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,259 @@
+package graphql
+
+import (
+	"config-service/auth"
+	"config-service/db"
+	"config-service/handlers"
+	"config-service/types"
+	"config-service/utils/consts"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/validator"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// fakeStore serves the documents of the test types and records the filters of the queries
+type fakeStore struct {
+	docs    map[string][]map[string]interface{}
+	filters map[string][]bson.D
+}
+
+func newDocType[T types.DocContent](store *fakeStore, path string) handlers.DocType {
+	schema := handlers.NewJSONSchema[T]()
+	return handlers.DocType{
+		Name:   schema.Title,
+		Path:   path,
+		Schema: schema,
+		Find: func(c *gin.Context, filterBuilder *db.FilterBuilder) ([]map[string]interface{}, error) {
+			store.filters[schema.Title] = append(store.filters[schema.Title], filterBuilder.Get())
+			return store.docs[schema.Title], nil
+		},
+	}
+}
+
+func newTestSchema(t *testing.T, store *fakeStore) *Schema {
+	schema, err := NewSchema([]handlers.DocType{
+		newDocType[*types.Cluster](store, consts.ClusterPath),
+		newDocType[*types.CustomerConfig](store, consts.CustomerConfigPath),
+		newDocType[*types.Framework](store, consts.FrameworkPath),
+		newDocType[*types.PostureExceptionPolicy](store, consts.PostureExceptionPolicyPath),
+		newDocType[*types.VulnerabilityExceptionPolicy](store, consts.VulnerabilityExceptionPolicyPath),
+	}, defaultRelations)
+	require.NoError(t, err)
+	return schema
+}
+
+// execute runs the query as a viewer user
+func execute(t *testing.T, schema *Schema, query string, variables map[string]interface{}) string {
+	return executeAs(t, schema, query, variables, map[string]interface{}{consts.UserRole: string(auth.RoleViewer)})
+}
+
+// executeAs runs the query with the identity values of the authentication middlewares
+func executeAs(t *testing.T, schema *Schema, query string, variables map[string]interface{}, identity map[string]interface{}) string {
+	doc, errs := gqlparser.LoadQuery(schema.schema, query)
+	require.Nil(t, errs)
+	operation := doc.Operations.ForName("")
+	vars, err := validator.VariableValues(schema.schema, operation, variables)
+	require.Nil(t, err)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, consts.GraphQLPath, nil)
+	for key, value := range identity {
+		c.Set(key, value)
+	}
+	response, jsonErr := json.Marshal(schema.execute(c, doc, operation, vars))
+	require.NoError(t, jsonErr)
+	return string(response)
+}
+
+func TestNewSchema(t *testing.T) {
+	schema := newTestSchema(t, &fakeStore{})
+	sdl := schema.SDL()
+	for _, expected := range []string{
+		"clusters(" + queryArguments + "): [Cluster!]",
+		"customerConfigs(" + queryArguments + "): [CustomerConfig!]",
+		"postureExceptionPolicies(" + queryArguments + "): [PostureExceptionPolicy!]",
+		"vulnerabilityExceptionPolicies(" + queryArguments + "): [VulnerabilityExceptionPolicy!]",
+		"frameworks(" + queryArguments + "): [Framework!]",
+		"customerConfig: CustomerConfig\n",
+		"postureExceptionPolicies: [PostureExceptionPolicy!]\n",
+		"vulnerabilityExceptionPolicies: [VulnerabilityExceptionPolicy!]\n",
+		"type PortalDesignator {",
+		"  attributes: JSON\n",
+		"  designators: [PortalDesignator]\n",
+	} {
+		assert.Contains(t, sdl, expected)
+	}
+	//same schema for the same types
+	assert.Equal(t, sdl, newTestSchema(t, &fakeStore{}).SDL())
+
+	_, err := NewSchema([]handlers.DocType{newDocType[*types.Cluster](&fakeStore{}, consts.ClusterPath)},
+		[]relation{{parent: "Cluster", name: "name", target: "Cluster", parentField: "name", targetField: "name"}})
+	assert.Error(t, err, "relation that conflicts with a field")
+}
+
+func TestExecuteBatchesRelations(t *testing.T) {
+	store := &fakeStore{
+		docs: map[string][]map[string]interface{}{
+			"Cluster": {
+				{"guid": "c1", "name": "cluster1", "attributes": map[string]interface{}{"kind": "k8s"}},
+				{"guid": "c2", "name": "cluster2"},
+				{"guid": "c3", "name": "cluster3"},
+			},
+			"CustomerConfig": {
+				{"guid": "cc1", "name": "cluster1"},
+				{"guid": "cc2", "name": "cluster2"},
+			},
+			"PostureExceptionPolicy": {
+				{"guid": "p1", "name": "policy1", "resources": []interface{}{
+					map[string]interface{}{"attributes": map[string]interface{}{"cluster": "cluster1"}},
+					map[string]interface{}{"attributes": map[string]interface{}{"cluster": "cluster2"}},
+				}},
+				{"guid": "p2", "name": "policy2", "resources": []interface{}{
+					map[string]interface{}{"attributes": map[string]interface{}{"cluster": "cluster2"}},
+				}},
+				//not a match
+				{"guid": "p3", "name": "policy3", "resources": []interface{}{
+					map[string]interface{}{"attributes": map[string]interface{}{"namespace": "cluster1"}},
+				}},
+			},
+		},
+		filters: map[string][]bson.D{},
+	}
+	schema := newTestSchema(t, store)
+	response := execute(t, schema, `query($kind: JSON) {
+		__typename
+		all: clusters(where: [{field: "attributes.kind", eq: $kind}]) {
+			...clusterFields
+			config: customerConfig { guid }
+			postureExceptionPolicies { name __typename }
+			vulnerabilityExceptionPolicies { name }
+			attributes @skip(if: true)
+		}
+	}
+	fragment clusterFields on Cluster { name attributes }`, map[string]interface{}{"kind": "k8s"})
+
+	assert.JSONEq(t, `{"data":{"__typename":"Query","all":[
+		{"name":"cluster1","attributes":{"kind":"k8s"},"config":{"guid":"cc1"},
+			"postureExceptionPolicies":[{"name":"policy1","__typename":"PostureExceptionPolicy"}],"vulnerabilityExceptionPolicies":[]},
+		{"name":"cluster2","attributes":null,"config":{"guid":"cc2"},
+			"postureExceptionPolicies":[{"name":"policy1","__typename":"PostureExceptionPolicy"},{"name":"policy2","__typename":"PostureExceptionPolicy"}],"vulnerabilityExceptionPolicies":[]},
+		{"name":"cluster3","attributes":null,"config":null,"postureExceptionPolicies":[],"vulnerabilityExceptionPolicies":[]}
+	]}}`, response)
+	assert.Regexp(t, `^\{"data":\{"__typename":"Query","all":\[\{"name":"cluster1","attributes"`, response, "fields in selection order")
+
+	//one query per type
+	clusterNames := bson.D{{Key: "$in", Value: []string{"cluster1", "cluster2", "cluster3"}}}
+	assert.Equal(t, []bson.D{{{Key: "attributes.kind", Value: "k8s"}}}, store.filters["Cluster"])
+	assert.Equal(t, []bson.D{{{Key: consts.NameField, Value: clusterNames}}}, store.filters["CustomerConfig"])
+	assert.Equal(t, []bson.D{{{Key: "resources.attributes.cluster", Value: clusterNames}}}, store.filters["PostureExceptionPolicy"])
+	assert.Equal(t, []bson.D{{{Key: "designators.attributes.cluster", Value: clusterNames}}}, store.filters["VulnerabilityExceptionPolicy"])
+}
+
+func TestExecuteErrors(t *testing.T) {
+	store := &fakeStore{filters: map[string][]bson.D{}}
+	schema := newTestSchema(t, store)
+	response := execute(t, schema, `{ clusters(where: [{field: "$where", eq: "1"}]) { name } frameworks { name } }`, nil)
+	assert.JSONEq(t, `{"data":{"clusters":null,"frameworks":[]},
+		"errors":[{"message":"failed to query clusters: invalid filter field \"$where\"","path":["clusters"]}]}`, response)
+	assert.Empty(t, store.filters["Cluster"])
+
+	response = execute(t, schema, `{ __schema { types { name } } }`, nil)
+	assert.Contains(t, response, "introspection is not supported")
+}
+
+func TestExecuteAuthorization(t *testing.T) {
+	store := &fakeStore{
+		docs: map[string][]map[string]interface{}{
+			"Cluster":        {{"guid": "c1", "name": "cluster1"}},
+			"CustomerConfig": {{"guid": "cc1", "name": "cluster1"}},
+		},
+		filters: map[string][]bson.D{},
+	}
+	schema := newTestSchema(t, store)
+	query := `{ clusters { name customerConfig { guid } } }`
+
+	//API keys need a read scope of each queried type, also of relations
+	response := executeAs(t, schema, query, nil, map[string]interface{}{consts.AuthMethod: auth.MethodAPIKey, consts.APIKeyScopes: []string{"cluster:read"}})
+	assert.JSONEq(t, `{"data":{"clusters":[{"name":"cluster1","customerConfig":null}]},
+		"errors":[{"message":"failed to resolve customerConfig: API key is missing scope v1_customer_configuration:read","path":["clusters",0,"customerConfig"]}]}`, response)
+	assert.Empty(t, store.filters["CustomerConfig"])
+	response = executeAs(t, schema, query, nil, map[string]interface{}{consts.AuthMethod: auth.MethodAPIKey, consts.APIKeyScopes: []string{"cluster:read", "v1_customer_configuration:write"}})
+	assert.JSONEq(t, `{"data":{"clusters":[{"name":"cluster1","customerConfig":{"guid":"cc1"}}]}}`, response)
+
+	//users need the role permission to read each queried type
+	response = executeAs(t, schema, query, nil, map[string]interface{}{})
+	assert.JSONEq(t, `{"data":{"clusters":null},"errors":[{"message":"failed to query clusters: missing permission config:read","path":["clusters"]}]}`, response)
+	//admins are not checked
+	response = executeAs(t, schema, query, nil, map[string]interface{}{consts.AdminAccess: true})
+	assert.JSONEq(t, `{"data":{"clusters":[{"name":"cluster1","customerConfig":{"guid":"cc1"}}]}}`, response)
+}
+
+func TestQueryFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     map[string]interface{}
+		expected bson.D
+		err      string
+	}{
+		{name: "no args", args: map[string]interface{}{}, expected: bson.D{}},
+		{name: "null args", args: map[string]interface{}{"guid": nil, "names": nil, "where": nil}, expected: bson.D{}},
+		{
+			name: "guid and names",
+			args: map[string]interface{}{"guid": "g1", "guids": "g2", "name": "n1", "names": []interface{}{"n1", "n2"}},
+			expected: bson.D{
+				{Key: consts.GUIDField, Value: "g1"},
+				{Key: consts.GUIDField, Value: bson.D{{Key: "$in", Value: []interface{}{"g2"}}}},
+				{Key: consts.NameField, Value: "n1"},
+				{Key: consts.NameField, Value: bson.D{{Key: "$in", Value: []interface{}{"n1", "n2"}}}},
+			},
+		},
+		{
+			name: "where",
+			args: map[string]interface{}{"where": []interface{}{
+				map[string]interface{}{"field": "attributes.kind", "eq": "k8s", "ne": nil},
+				map[string]interface{}{"field": "attributes.count", "in": []interface{}{int64(1), 2.5}, "exists": true},
+			}},
+			expected: bson.D{
+				{Key: "attributes.kind", Value: "k8s"},
+				{Key: "attributes.kind", Value: bson.D{{Key: "$ne", Value: nil}}},
+				{Key: "attributes.count", Value: bson.D{{Key: "$in", Value: []interface{}{int64(1), 2.5}}}},
+				{Key: "attributes.count", Value: bson.D{{Key: "$exists", Value: true}}},
+			},
+		},
+		{name: "operator field", args: map[string]interface{}{"where": map[string]interface{}{"field": "$where", "eq": "1"}}, err: `invalid filter field "$where"`},
+		{name: "operator value", args: map[string]interface{}{"where": map[string]interface{}{"field": "name", "eq": map[string]interface{}{"$ne": ""}}}, err: "filter of field name: eq value must be a scalar"},
+		{name: "operator in value", args: map[string]interface{}{"where": map[string]interface{}{"field": "name", "in": []interface{}{"a", []interface{}{}}}}, err: "filter of field name: in value must be a scalar"},
+		{name: "no condition", args: map[string]interface{}{"where": map[string]interface{}{"field": "name"}}, err: "filter of field name has no condition"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filterBuilder, err := queryFilter(tt.args)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, filterBuilder.Get())
+		})
+	}
+}
+
+func TestFieldValues(t *testing.T) {
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"name":"n1","designators":[
+		{"attributes":{"cluster":"c1"}},
+		{"attributes":{"cluster":["c2","c3"]}},
+		{"attributes":{"cluster":1}},
+		{"wlid":"w1"}]}`), &doc))
+	assert.Equal(t, []string{"n1"}, fieldValues(doc, "name"))
+	assert.Equal(t, []string{"c1", "c2", "c3"}, fieldValues(doc, "designators.attributes.cluster"))
+	assert.Empty(t, fieldValues(doc, "designators.attributes.namespace"))
+	assert.Empty(t, fieldValues(doc, "name.first"))
+}
@@ -0,0 +1,77 @@
+package graphql
+
+import (
+	"config-service/utils/consts"
+	"strings"
+
+	"github.com/armosec/armoapi-go/armotypes"
+)
+
+// relation - field of a document type that resolves the documents of another type with a field value that matches a value of the document
+type relation struct {
+	parent      string //document type name of the field
+	name        string //field name
+	target      string //document type name of the resolved documents
+	parentField string //json path of the document values e.g. name
+	targetField string //json and db path of the resolved documents values e.g. designators.attributes.cluster
+	list        bool   //resolve all the matching documents, otherwise the first one
+	description string
+}
+
+var defaultRelations = []relation{
+	{
+		parent:      "Cluster",
+		name:        "customerConfig",
+		target:      "CustomerConfig",
+		parentField: consts.NameField,
+		targetField: consts.NameField,
+		description: "configuration of the cluster",
+	},
+	{
+		parent:      "Cluster",
+		name:        "postureExceptionPolicies",
+		target:      "PostureExceptionPolicy",
+		parentField: consts.NameField,
+		targetField: "resources.attributes." + armotypes.AttributeCluster,
+		list:        true,
+		description: "posture exception policies with a resource designator of the cluster",
+	},
+	{
+		parent:      "Cluster",
+		name:        "vulnerabilityExceptionPolicies",
+		target:      "VulnerabilityExceptionPolicy",
+		parentField: consts.NameField,
+		targetField: "designators.attributes." + armotypes.AttributeCluster,
+		list:        true,
+		description: "vulnerability exception policies with a designator of the cluster",
+	},
+}
+
+// fieldValues returns the string values of a json path in a json value, arrays in the path are flattened
+func fieldValues(value interface{}, path string) []string {
+	if path == "" {
+		switch value := value.(type) {
+		case string:
+			return []string{value}
+		case []interface{}:
+			values := []string{}
+			for _, item := range value {
+				values = append(values, fieldValues(item, "")...)
+			}
+			return values
+		}
+		return nil
+	}
+	switch value := value.(type) {
+	case map[string]interface{}:
+		key, rest, _ := strings.Cut(path, ".")
+		return fieldValues(value[key], rest)
+	case []interface{}:
+		values := []string{}
+		for _, item := range value {
+			values = append(values, fieldValues(item, path)...)
+		}
+		return values
+	}
+	return nil
+}
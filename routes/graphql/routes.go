@@ -0,0 +1,82 @@
+package graphql
+
+import (
+	"config-service/handlers"
+	"config-service/utils/consts"
+	"config-service/utils/log"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+// GraphQL API - read only queries of the customer documents of all the types that are served by the generic handlers,
+// the schema is generated from the registered document types so it must be added after their routes
+
+// Request - GraphQL request, sent as a json body of POST requests or as query params of GET requests
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+func AddRoutes(g *gin.Engine) {
+	schema, err := NewSchema(handlers.DocTypes(), defaultRelations)
+	if err != nil {
+		panic(err)
+	}
+	g.GET(consts.GraphQLPath, handleQuery(schema))
+	g.POST(consts.GraphQLPath, handleQuery(schema))
+	g.GET(consts.GraphQLSchemaPath, func(c *gin.Context) {
+		c.String(http.StatusOK, schema.SDL())
+	})
+}
+
+func handleQuery(schema *Schema) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer log.LogNTraceEnterExit("handleGraphQLQuery", c)()
+		var req Request
+		if c.Request.Method == http.MethodGet {
+			req.Query = c.Query("query")
+			req.OperationName = c.Query("operationName")
+			if variables := c.Query("variables"); variables != "" {
+				if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+					handlers.ResponseBadRequest(c, "variables must be a json object")
+					return
+				}
+			}
+		} else if err := c.ShouldBindJSON(&req); err != nil {
+			handlers.ResponseFailedToBindJson(c, err)
+			return
+		}
+		if req.Query == "" {
+			handlers.ResponseBadRequest(c, "query is required")
+			return
+		}
+		doc, errs := gqlparser.LoadQuery(schema.schema, req.Query)
+		if errs != nil {
+			responseRequestErrors(c, errs...)
+			return
+		}
+		operation := doc.Operations.ForName(req.OperationName)
+		if operation == nil {
+			responseRequestErrors(c, gqlerror.Errorf("operation %q not found, operationName is required when the query has several operations", req.OperationName))
+			return
+		}
+		vars, err := validator.VariableValues(schema.schema, operation, req.Variables)
+		if err != nil {
+			responseRequestErrors(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, schema.execute(c, doc, operation, vars))
+	}
+}
+
+// responseRequestErrors responds with the errors of a request that cannot be executed
+func responseRequestErrors(c *gin.Context, errs ...*gqlerror.Error) {
+	log.LogNTrace("invalid GraphQL request: "+gqlerror.List(errs).Error(), c)
+	c.AbortWithStatusJSON(http.StatusBadRequest, Response{Errors: errs})
+}
@@ -0,0 +1,270 @@
+package graphql
+
+import (
+	"config-service/handlers"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	plural "github.com/gertd/go-pluralize"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// GraphQL schema generated from the json schemas of the document types:
+// - each document type has a query field with filter arguments e.g. clusters(name: "c1")
+// - struct types are object types, maps and values of any type are JSON scalars
+// - relations add fields that resolve documents of other types e.g. Cluster.customerConfig
+
+const (
+	queryType      = "Query"
+	jsonScalar     = "JSON"
+	fieldFilter    = "FieldFilter"
+	jsonDefsPrefix = "#/$defs/"
+)
+
+var (
+	validName = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+	nameChars = regexp.MustCompile(`[^_0-9A-Za-z]`)
+	pluralize = plural.NewClient()
+)
+
+const schemaPrelude = `"""Any json value"""
+scalar JSON
+
+"""Filter of a db field, the conditions of all the filters must match"""
+input FieldFilter {
+  """db field path e.g. attributes.kind"""
+  field: String!
+  """field value equals"""
+  eq: JSON
+  """field value not equals"""
+  ne: JSON
+  """field value is one of"""
+  in: [JSON!]
+  """field exists"""
+  exists: Boolean
+}
+`
+
+const queryArguments = `guid: String, guids: [String!], name: String, names: [String!], where: [FieldFilter!]`
+
+// Schema - GraphQL schema of the document types
+type Schema struct {
+	schema      *ast.Schema
+	sdl         string
+	queries     map[string]handlers.DocType    //query field name to document type
+	docTypes    map[string]handlers.DocType    //object type name to document type
+	objectNames map[string]string              //document type name to object type name
+	relations   map[string]map[string]relation //object type name to field name to relation
+}
+
+// NewSchema generates the schema of the document types and the relations between them
+func NewSchema(docTypes []handlers.DocType, relations []relation) (*Schema, error) {
+	b := &schemaBuilder{
+		defs:     map[string]*handlers.Schema{},
+		defTypes: map[string]string{},
+		taken:    map[string]bool{queryType: true, jsonScalar: true, fieldFilter: true, "String": true, "Int": true, "Float": true, "Boolean": true, "ID": true},
+		objects:  map[string]*objectDefinition{},
+	}
+	s := &Schema{
+		queries:     map[string]handlers.DocType{},
+		docTypes:    map[string]handlers.DocType{},
+		objectNames: map[string]string{},
+		relations:   map[string]map[string]relation{},
+	}
+	query := &objectDefinition{name: queryType}
+	for _, docType := range docTypes {
+		//the named types of all the document types are in the same namespace, a name is defined by the first type that uses it
+		for name, def := range docType.Schema.Defs {
+			if _, ok := b.defs[name]; !ok {
+				b.defs[name] = def
+			}
+		}
+		defName := strings.TrimPrefix(docType.Schema.Ref, jsonDefsPrefix)
+		objectName := b.objectType(defName, b.defs[defName])
+		if _, ok := b.objects[objectName]; !ok {
+			return nil, fmt.Errorf("document type %s is not an object", docType.Name)
+		}
+		s.docTypes[objectName] = docType
+		s.objectNames[docType.Name] = objectName
+		queryName := queryFieldName(docType.Name)
+		s.queries[queryName] = docType
+		query.fields = append(query.fields, fieldDefinition{
+			description: fmt.Sprintf("%s documents of the customer", docType.Name),
+			name:        queryName + "(" + queryArguments + ")",
+			typ:         "[" + objectName + "!]",
+		})
+	}
+	for _, rel := range relations {
+		parent, ok := s.objectNames[rel.parent]
+		target, targetOK := s.objectNames[rel.target]
+		if !ok || !targetOK {
+			continue
+		}
+		if b.objects[parent].hasField(rel.name) {
+			return nil, fmt.Errorf("relation %s.%s conflicts with a document field", parent, rel.name)
+		}
+		typ := target
+		if rel.list {
+			typ = "[" + target + "!]"
+		}
+		b.objects[parent].fields = append(b.objects[parent].fields, fieldDefinition{description: rel.description, name: rel.name, typ: typ})
+		if s.relations[parent] == nil {
+			s.relations[parent] = map[string]relation{}
+		}
+		s.relations[parent][rel.name] = rel
+	}
+
+	var sdl strings.Builder
+	sdl.WriteString(schemaPrelude)
+	query.write(&sdl)
+	names := make([]string, 0, len(b.objects))
+	for name := range b.objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.objects[name].write(&sdl)
+	}
+	schema, err := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: sdl.String()})
+	if err != nil {
+		return nil, err
+	}
+	s.schema = schema
+	s.sdl = sdl.String()
+	return s, nil
+}
+
+// SDL returns the schema definition language document of the schema
+func (s *Schema) SDL() string {
+	return s.sdl
+}
+
+// queryFieldName returns the plural lower camel case name of the document type e.g. postureExceptionPolicies
+func queryFieldName(typeName string) string {
+	name := pluralize.Plural(typeName)
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+type objectDefinition struct {
+	name   string
+	fields []fieldDefinition
+}
+
+func (o *objectDefinition) hasField(name string) bool {
+	for _, field := range o.fields {
+		if field.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+type fieldDefinition struct {
+	description string
+	name        string //name and arguments
+	typ         string
+}
+
+func (o *objectDefinition) write(sdl *strings.Builder) {
+	fmt.Fprintf(sdl, "\ntype %s {\n", o.name)
+	for _, field := range o.fields {
+		if field.description != "" {
+			fmt.Fprintf(sdl, "  \"\"\"%s\"\"\"\n", field.description)
+		}
+		fmt.Fprintf(sdl, "  %s: %s\n", field.name, field.typ)
+	}
+	sdl.WriteString("}\n")
+}
+
+// schemaBuilder maps json schemas to GraphQL types, the object fields have the json names of the properties
+type schemaBuilder struct {
+	defs     map[string]*handlers.Schema  //json schema definitions by name
+	defTypes map[string]string            //json schema definition name to object type name
+	taken    map[string]bool              //type names in use
+	objects  map[string]*objectDefinition //object types by name
+}
+
+// typeOf returns the GraphQL type of the json schema of a field
+func (b *schemaBuilder) typeOf(schema *handlers.Schema, parentName, fieldName string) string {
+	if schema.Ref != "" {
+		defName := strings.TrimPrefix(schema.Ref, jsonDefsPrefix)
+		def, ok := b.defs[defName]
+		if !ok {
+			return jsonScalar
+		}
+		if isObject(def) {
+			return b.objectType(defName, def)
+		}
+		return b.typeOf(def, parentName, fieldName)
+	}
+	switch schema.Type {
+	case "string":
+		return "String"
+	case "integer":
+		return "Int"
+	case "number":
+		return "Float"
+	case "boolean":
+		return "Boolean"
+	case "array":
+		if schema.Items == nil {
+			return "[" + jsonScalar + "]"
+		}
+		return "[" + b.typeOf(schema.Items, parentName, fieldName) + "]"
+	case "object":
+		if isObject(schema) {
+			//anonymous struct
+			return b.addObject(parentName+strings.ToUpper(fieldName[:1])+fieldName[1:], schema, "")
+		}
+	}
+	//maps and values of any type
+	return jsonScalar
+}
+
+// objectType returns the object type of a json schema definition
+func (b *schemaBuilder) objectType(defName string, def *handlers.Schema) string {
+	if name, ok := b.defTypes[defName]; ok {
+		return name
+	}
+	if !isObject(def) {
+		return b.typeOf(def, defName, defName)
+	}
+	return b.addObject(defName, def, defName)
+}
+
+// addObject adds an object type of the json schema properties, fields with names that are not valid in GraphQL are omitted.
+// defName is the name of the json schema definition, empty for anonymous structs.
+func (b *schemaBuilder) addObject(name string, schema *handlers.Schema, defName string) string {
+	baseName := nameChars.ReplaceAllString(name, "_")
+	name = baseName
+	for i := 2; b.taken[name]; i++ {
+		name = fmt.Sprintf("%s%d", baseName, i)
+	}
+	b.taken[name] = true
+	if defName != "" {
+		//before adding the fields, for recursive types
+		b.defTypes[defName] = name
+	}
+	object := &objectDefinition{name: name}
+	b.objects[name] = object
+	properties := make([]string, 0, len(schema.Properties))
+	for property := range schema.Properties {
+		properties = append(properties, property)
+	}
+	sort.Strings(properties)
+	for _, property := range properties {
+		if !validName.MatchString(property) || strings.HasPrefix(property, "__") {
+			continue
+		}
+		object.fields = append(object.fields, fieldDefinition{name: property, typ: b.typeOf(schema.Properties[property], name, property)})
+	}
+	return name
+}
+
+// isObject returns true for schemas of structs, maps have no properties
+func isObject(schema *handlers.Schema) bool {
+	return schema.Type == "object" && len(schema.Properties) > 0
+}
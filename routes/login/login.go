@@ -1,21 +1,30 @@
 package login
 
 import (
+	"config-service/auth"
 	"config-service/handlers"
+	"config-service/utils"
 	"config-service/utils/consts"
+	"config-service/utils/log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/exp/slices"
 )
 
-func AddRoutes(g *gin.Engine) {
-	login := g.Group("/login")
+// AddPublicRoutes adds the login route that is served without authentication
+func AddPublicRoutes(g *gin.Engine) {
+	login := g.Group(consts.LoginPath)
 
 	//login routes
 	login.POST("", func(c *gin.Context) {
+		if !auth.LoginSessionsAllowed() {
+			handlers.ResponseForbidden(c, "login sessions are disabled")
+			return
+		}
 		loginDetails := struct {
-			CustomerGUID string                 `json:"customerGUID" binding:"required"`
-			Attributes   map[string]interface{} `json:"attributes,omitempty"`
+			CustomerGUID string `json:"customerGUID" binding:"required"`
+			UserID       string `json:"userId,omitempty"`
 		}{
 			CustomerGUID: "",
 		}
@@ -28,14 +37,30 @@ func AddRoutes(g *gin.Engine) {
 			handlers.ResponseBadRequest(c, "customerGUID is required")
 			return
 		}
-		cookieValue := loginDetails.CustomerGUID
-		//check if admin access required
-		if loginDetails.Attributes != nil {
-			if admin, ok := loginDetails.Attributes["admin"]; ok && admin == true {
-				cookieValue += ";" + consts.AdminAccess
+		//admin access is granted only to the configuration admin users
+		admin := slices.Contains(utils.GetConfig().AdminUsers, loginDetails.CustomerGUID)
+		token, session, err := auth.NewSession(c, loginDetails.CustomerGUID, loginDetails.UserID, admin)
+		if err != nil {
+			handlers.ResponseInternalServerError(c, "failed to create session", err)
+			return
+		}
+		auth.SetSessionCookies(c, token, int(auth.SessionTTL().Seconds()))
+		c.JSON(http.StatusOK, gin.H{"expirationTime": session.ExpirationTime})
+	})
+}
+
+// AddRoutes adds the logout route, it is served to authenticated callers with the CSRF protection of state changing requests
+func AddRoutes(g *gin.Engine) {
+	//logout revokes the session of the request cookie
+	g.POST(consts.LogoutPath, func(c *gin.Context) {
+		if token, err := c.Cookie(consts.SessionCookie); err == nil && token != "" {
+			if err := auth.RevokeSession(c, token); err != nil {
+				handlers.ResponseInternalServerError(c, "failed to revoke session", err)
+				return
 			}
+			log.LogNTrace("session revoked", c)
 		}
-		c.SetCookie(consts.CustomerGUID, cookieValue, 2*60*60*24, "/", "", false, true)
+		auth.SetSessionCookies(c, "", -1)
 		c.JSON(http.StatusOK, nil)
 	})
 }
@@ -0,0 +1,24 @@
+package openapi
+
+import (
+	"config-service/handlers"
+	"config-service/utils"
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// swagger UI page that loads /openapi.json, the UI assets are loaded from the swagger-ui-dist CDN
+//
+//go:embed swagger.html
+var swaggerUIPage []byte
+
+func AddRoutes(g *gin.Engine) {
+	g.GET("/openapi.json", handlers.HandleOpenAPISpec)
+	if utils.GetConfig().OpenAPI.SwaggerUI {
+		g.GET("/docs", func(c *gin.Context) {
+			c.Data(http.StatusOK, "text/html; charset=utf-8", swaggerUIPage)
+		})
+	}
+}
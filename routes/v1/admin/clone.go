@@ -0,0 +1,172 @@
+package admin
+
+import (
+	"config-service/db"
+	"config-service/handlers"
+	"config-service/routes/v1/customer"
+	"config-service/types"
+	"config-service/utils/consts"
+	"config-service/utils/log"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	uuid "github.com/satori/go.uuid"
+)
+
+type cloneRequest struct {
+	GUID  string `json:"guid,omitempty"`  //optional, the new tenant GUID, generated when empty
+	Name  string `json:"name,omitempty"`  //optional, the new tenant name, defaults to the source name (or scrubbed name)
+	Scrub bool   `json:"scrub,omitempty"` //optional, when true names, emails and attribute values are anonymized
+}
+
+type cloneResponse struct {
+	CustomerGUID string           `json:"customerGUID"`
+	Copied       map[string]int64 `json:"copied"`
+}
+
+// cloneCustomer creates a new tenant and copies the source tenant documents to it with new GUIDs and short names
+func cloneCustomer(c *gin.Context) {
+	defer log.LogNTraceEnterExit("cloneCustomer", c)()
+	sourceGUID := c.Param(consts.GUIDField)
+	if sourceGUID == "" {
+		handlers.ResponseMissingGUID(c)
+		return
+	}
+	var req cloneRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+			handlers.ResponseFailedToBindJson(c, err)
+			return
+		}
+	}
+	if req.GUID == "" {
+		req.GUID = uuid.NewV4().String()
+	} else if guid, err := uuid.FromString(req.GUID); err != nil {
+		msg := fmt.Sprintf("guid %s is not a valid UUID", req.GUID)
+		log.LogNTrace(msg, c)
+		handlers.ResponseProblem(c, http.StatusBadRequest, handlers.ErrorCodeInvalidBody, msg, gin.H{"guid": req.GUID})
+		return
+	} else if req.GUID = guid.String(); req.GUID == sourceGUID {
+		handlers.ResponseBadRequest(c, "clone guid must be different from the source guid")
+		return
+	}
+	var scrubber *scrubber
+	if req.Scrub {
+		scrubber = newScrubber()
+	}
+
+	//read the source tenant
+	sourceCtx := tenantContext(c, sourceGUID, consts.CustomersCollection)
+	source, err := db.GetDoc[types.Customer](sourceCtx, db.NewFilterBuilder().WithGUID(sourceGUID))
+	if err != nil {
+		handlers.ResponseInternalServerError(c, "failed to read source customer", err)
+		return
+	} else if source == nil {
+		handlers.ResponseDocumentNotFound(c)
+		return
+	}
+	tenant := source
+	if scrubber != nil {
+		if tenant, err = scrubDoc(scrubber, source); err != nil {
+			handlers.ResponseInternalServerError(c, "failed to scrub customer", err)
+			return
+		}
+	}
+	tenant.GUID = req.GUID
+	if req.Name != "" {
+		tenant.Name = req.Name
+	}
+	tenant.NotificationsConfig = nil
+
+	//create the new tenant
+	targetCtx := tenantContext(c, req.GUID, consts.CustomersCollection)
+	if _, err := db.InsertDBDocument(targetCtx, customer.NewTenantDocument(tenant)); err != nil {
+		if db.IsDuplicateKeyError(err) {
+			handlers.ResponseConflict(c, consts.GUIDField, req.GUID)
+			return
+		}
+		handlers.ResponseInternalServerError(c, "failed to create customer", err)
+		return
+	}
+
+	//copy the tenant documents
+	response := cloneResponse{CustomerGUID: req.GUID, Copied: map[string]int64{}}
+	repoShortNameGetter := func(doc *types.Repository) string { return doc.RepoName }
+	copiers := []func() (string, int64, error){
+		cloneCollection(c, consts.ClustersCollection, sourceGUID, req.GUID, scrubber, handlers.NameValueGetter[*types.Cluster]),
+		cloneCollection[*types.CustomerConfig](c, consts.CustomerConfigCollection, sourceGUID, req.GUID, scrubber, nil),
+		cloneCollection[*types.PostureExceptionPolicy](c, consts.PostureExceptionPolicyCollection, sourceGUID, req.GUID, scrubber, nil),
+		cloneCollection[*types.VulnerabilityExceptionPolicy](c, consts.VulnerabilityExceptionPolicyCollection, sourceGUID, req.GUID, scrubber, nil),
+		cloneCollection[*types.Framework](c, consts.FrameworkCollection, sourceGUID, req.GUID, scrubber, nil),
+		cloneCollection(c, consts.RepositoryCollection, sourceGUID, req.GUID, scrubber, repoShortNameGetter),
+		cloneCollection[*types.RegistryCronJob](c, consts.RegistryCronJobCollection, sourceGUID, req.GUID, scrubber, nil),
+	}
+	for _, copyDocs := range copiers {
+		collection, copied, err := copyDocs()
+		response.Copied[collection] = copied
+		if err != nil {
+			//do not leave a partially cloned tenant
+			if _, deleteErr := db.AdminDeleteCustomersDocs(c, req.GUID); deleteErr != nil {
+				log.LogNTraceError(fmt.Sprintf("failed to delete partially cloned tenant %s", req.GUID), deleteErr, c)
+				handlers.ResponseInternalServerError(c, fmt.Sprintf("failed to clone %s, new tenant %s is partially cloned", collection, req.GUID), err)
+				return
+			}
+			handlers.ResponseInternalServerError(c, fmt.Sprintf("failed to clone %s, new tenant %s is deleted", collection, req.GUID), err)
+			return
+		}
+	}
+	log.LogNTrace(fmt.Sprintf("cloneCustomer completed successfully. customer %s cloned to %s by admin %s", sourceGUID, req.GUID, c.GetString(consts.CustomerGUID)), c)
+	c.JSON(http.StatusCreated, response)
+}
+
+// cloneCollection returns a function that copies all the source customer documents in the collection to the target customer
+// when shortNameGetter is set the documents get new unique short names in the target tenant
+func cloneCollection[T types.DocContent](c *gin.Context, collection, sourceGUID, targetGUID string, scrubber *scrubber, shortNameGetter func(T) string) func() (string, int64, error) {
+	return func() (string, int64, error) {
+		docs, err := db.GetAllForCustomer[T](tenantContext(c, sourceGUID, collection), false)
+		if err != nil || len(docs) == 0 {
+			return collection, 0, err
+		}
+		if scrubber != nil {
+			for i := range docs {
+				if docs[i], err = scrubDoc(scrubber, docs[i]); err != nil {
+					return collection, 0, err
+				}
+			}
+		}
+		targetCtx := tenantContext(c, targetGUID, collection)
+		if shortNameGetter == nil {
+			if _, err := db.InsertDocuments(targetCtx, docs); err != nil {
+				return collection, 0, err
+			}
+			return collection, int64(len(docs)), nil
+		}
+		//short names are unique per tenant so insert one by one to let each doc see the previous short names
+		setShortName := handlers.ValidatePostAttributeShortName(shortNameGetter)
+		var copied int64
+		for _, doc := range docs {
+			attributes := doc.GetAttributes()
+			if attributes == nil {
+				attributes = map[string]interface{}{}
+			}
+			delete(attributes, consts.ShortNameAttribute)
+			doc.SetAttributes(attributes)
+			withShortName, _ := setShortName(targetCtx, []T{doc})
+			if _, err := db.InsertDocuments(targetCtx, withShortName); err != nil {
+				return collection, copied, err
+			}
+			copied++
+		}
+		return collection, copied, nil
+	}
+}
+
+// tenantContext returns a copy of the request context with the given customer and collection
+func tenantContext(c *gin.Context, customerGUID, collection string) *gin.Context {
+	tenantCtx := c.Copy()
+	tenantCtx.Set(consts.CustomerGUID, customerGUID)
+	tenantCtx.Set(consts.Collection, collection)
+	return tenantCtx
+}
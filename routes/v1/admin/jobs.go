@@ -0,0 +1,124 @@
+package admin
+
+import (
+	"config-service/auth"
+	"config-service/db"
+	"config-service/db/mongo"
+	"config-service/handlers"
+	"config-service/jobs"
+	"config-service/utils/consts"
+	"config-service/utils/log"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/exp/slices"
+)
+
+const deleteCustomersJobType = "deleteCustomers"
+
+// collections that the delete customers job does not delete from
+var retainedCollections = []string{consts.JobsCollection, consts.SessionsCollection, consts.AuditLogCollection}
+
+func init() {
+	jobs.RegisterHandler(deleteCustomersJobType, deleteCustomersJob)
+}
+
+// deleteAllCustomerData submits a job to delete all the documents of the customers in the query params
+func deleteAllCustomerData(c *gin.Context) {
+	customersGUIDs := c.QueryArray(consts.CustomersParam)
+	if len(customersGUIDs) == 0 {
+		handlers.ResponseMissingQueryParam(c, consts.CustomersParam)
+		return
+	}
+	job, err := jobs.Submit(c, deleteCustomersJobType, map[string]interface{}{consts.CustomersParam: customersGUIDs}, c.GetString(consts.CustomerGUID))
+	if err != nil {
+		handlers.ResponseInternalServerError(c, "failed to submit delete customers job", err)
+		return
+	}
+	log.LogNTrace(fmt.Sprintf("deleteAllCustomerData job %s submitted to delete documents of %d users by admin %s", job.ID, len(customersGUIDs), c.GetString(consts.CustomerGUID)), c)
+	c.Header("Location", fmt.Sprintf("%s/jobs/%s", consts.AdminPath, job.ID))
+	c.JSON(http.StatusAccepted, job)
+}
+
+func getJob(c *gin.Context) {
+	defer log.LogNTraceEnterExit("getJob", c)()
+	jobId := c.Param(consts.JobIdParam)
+	if jobId == "" {
+		handlers.ResponseMissingKey(c, consts.JobIdParam)
+		return
+	}
+	if job, err := jobs.Get(c, jobId); err != nil {
+		handlers.ResponseInternalServerError(c, "failed to read job", err)
+	} else if job == nil {
+		handlers.ResponseDocumentNotFound(c)
+	} else {
+		c.JSON(http.StatusOK, job)
+	}
+}
+
+// deleteCustomersJob deletes the customers documents collection by collection, each deleted collection is a checkpoint
+// the customers collection is deleted last so a failed attempt is retried from the collections that are not done
+// the sessions of the customers are revoked first since the sessions are not deleted by the customers field,
+// the audit log is kept for compliance and the jobs are kept for the job status
+func deleteCustomersJob(ctx context.Context, job *jobs.Job, checkpoint jobs.Checkpointer) error {
+	customersGUIDs := job.StringsParam(consts.CustomersParam)
+	if len(customersGUIDs) == 0 {
+		return fmt.Errorf("no customers to delete")
+	}
+	collections, err := mongo.ListCollectionNames(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Slice(collections, func(i, j int) bool {
+		if collections[i] == consts.CustomersCollection || collections[j] == consts.CustomersCollection {
+			return collections[j] == consts.CustomersCollection && collections[i] != consts.CustomersCollection
+		}
+		return collections[i] < collections[j]
+	})
+	var failed []string
+	if !job.Progress[consts.SessionsCollection].Done {
+		progress := revokeCustomersSessions(ctx, customersGUIDs)
+		progress.Count += job.Progress[consts.SessionsCollection].Count
+		if !progress.Done {
+			failed = append(failed, consts.SessionsCollection)
+		}
+		if err := checkpoint(consts.SessionsCollection, progress); err != nil {
+			return err
+		}
+	}
+	for _, collection := range collections {
+		if slices.Contains(retainedCollections, collection) || job.Progress[collection].Done {
+			continue
+		}
+		deleted, err := db.AdminDeleteCustomersDocsInCollection(ctx, collection, customersGUIDs...)
+		progress := jobs.Progress{Done: err == nil, Count: job.Progress[collection].Count + deleted}
+		if err != nil {
+			progress.Error = err.Error()
+			failed = append(failed, collection)
+		}
+		if err := checkpoint(collection, progress); err != nil {
+			return err
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to delete documents in collections: %v", failed)
+	}
+	return nil
+}
+
+// revokeCustomersSessions revokes the sessions of each of the customers
+func revokeCustomersSessions(ctx context.Context, customersGUIDs []string) jobs.Progress {
+	progress := jobs.Progress{Done: true}
+	for _, customerGUID := range customersGUIDs {
+		revoked, err := auth.RevokeCustomerSessions(ctx, customerGUID)
+		progress.Count += revoked
+		if err != nil {
+			progress.Done = false
+			progress.Error = err.Error()
+		}
+	}
+	return progress
+}
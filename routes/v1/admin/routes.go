@@ -7,7 +7,6 @@ import (
 	"config-service/utils"
 	"config-service/utils/consts"
 	"config-service/utils/log"
-	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -22,6 +21,11 @@ func AddRoutes(g *gin.Engine) {
 	//add middleware to check if user is admin
 	adminUsers := utils.GetConfig().AdminUsers
 	adminAuthMiddleware := func(c *gin.Context) {
+		//impersonation sessions act as the impersonated customer and not as an admin
+		if c.GetString(consts.Impersonator) != "" {
+			handlers.ResponseError(c, http.StatusUnauthorized, "Unauthorized - not an admin user")
+			return
+		}
 		//check if admin access granted by auth middleware or if user is in the configuration admin users list
 		if c.GetBool(consts.AdminAccess) {
 			c.Next()
@@ -29,32 +33,23 @@ func AddRoutes(g *gin.Engine) {
 			c.Next()
 		} else {
 			//not admin
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized - not an admin user"})
+			handlers.ResponseError(c, http.StatusUnauthorized, "Unauthorized - not an admin user")
 		}
 	}
 
 	admin.Use(adminAuthMiddleware)
 
 	admin.GET("/activeCustomers", getActiveCustomers)
-	//add delete customers data route
+	//add delete customers data route, the deletion runs as a job
 	admin.DELETE("/customers", deleteAllCustomerData)
-}
-
-func deleteAllCustomerData(c *gin.Context) {
-	customersGUIDs := c.QueryArray(consts.CustomersParam)
-	if len(customersGUIDs) == 0 {
-		handlers.ResponseMissingQueryParam(c, consts.CustomersParam)
-		return
-	}
-	deleted, err := db.AdminDeleteCustomersDocs(c, customersGUIDs...)
-	if err != nil {
-		log.LogNTraceError(fmt.Sprintf("deleteAllCustomerData completed with errors. %d documents deleted", deleted), err, c)
-		handlers.ResponseInternalServerError(c, fmt.Sprintf("deleted: %d, errors: %v", deleted, err), err)
-		return
-	}
-	log.LogNTrace(fmt.Sprintf("deleteAllCustomerData completed successfully. %d documents of %d users deleted by admin %s ", deleted, len(customersGUIDs), c.GetString(consts.CustomerGUID)), c)
-	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
-
+	//add jobs status route, the admin jobs handlers are registered at init
+	admin.GET("/jobs/:"+consts.JobIdParam, getJob)
+	//add clone customer route
+	admin.POST("/customers/:"+consts.GUIDField+"/clone", cloneCustomer)
+	//add revoke customer sessions route
+	admin.DELETE("/customers/:"+consts.GUIDField+"/sessions", revokeCustomerSessions)
+	//add impersonate customer route
+	admin.POST("/impersonate/:"+consts.CustomerGUID, impersonate)
 }
 
 func getActiveCustomers(c *gin.Context) {
@@ -0,0 +1,79 @@
+package admin
+
+import (
+	"config-service/types"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+
+	rndStr "github.com/dchest/uniuri"
+)
+
+var emailRegex = regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`)
+
+// scrubber anonymizes names, emails and attribute values of cloned documents
+// the same value is always replaced with the same pseudonym so references between documents (e.g. cluster name in exception designators) are kept
+type scrubber struct {
+	salt string
+}
+
+func newScrubber() *scrubber {
+	return &scrubber{salt: rndStr.New()}
+}
+
+func (s *scrubber) pseudonym(value string) string {
+	if value == "" {
+		return value
+	}
+	hash := sha256.Sum256([]byte(s.salt + value))
+	return "anon-" + hex.EncodeToString(hash[:])[:10]
+}
+
+func (s *scrubber) email(value string) string {
+	return emailRegex.ReplaceAllStringFunc(value, func(email string) string {
+		return s.pseudonym(email) + "@example.com"
+	})
+}
+
+// scrubValue walks a decoded json value and anonymizes it, inAttributes is true when the value is an attributes map entry
+func (s *scrubber) scrubValue(key string, value interface{}, inAttributes bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = s.scrubValue(k, item, inAttributes || key == "attributes")
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = s.scrubValue(key, item, inAttributes)
+		}
+		return v
+	case string:
+		if key == "name" || inAttributes {
+			return s.pseudonym(v)
+		}
+		return s.email(v)
+	}
+	return value
+}
+
+// scrubDoc returns an anonymized copy of the document
+func scrubDoc[T types.DocContent](s *scrubber, doc T) (T, error) {
+	docBytes, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var docMap map[string]interface{}
+	if err := json.Unmarshal(docBytes, &docMap); err != nil {
+		return nil, err
+	}
+	if docBytes, err = json.Marshal(s.scrubValue("", docMap, false)); err != nil {
+		return nil, err
+	}
+	var scrubbed T
+	if err := json.Unmarshal(docBytes, &scrubbed); err != nil {
+		return nil, err
+	}
+	return scrubbed, nil
+}
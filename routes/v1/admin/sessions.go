@@ -0,0 +1,64 @@
+package admin
+
+import (
+	"config-service/auth"
+	"config-service/handlers"
+	"config-service/utils/consts"
+	"config-service/utils/log"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// revokeCustomerSessions revokes all the active login sessions of a customer
+func revokeCustomerSessions(c *gin.Context) {
+	defer log.LogNTraceEnterExit("revokeCustomerSessions", c)()
+	customerGUID := c.Param(consts.GUIDField)
+	if customerGUID == "" {
+		handlers.ResponseMissingGUID(c)
+		return
+	}
+	revoked, err := auth.RevokeCustomerSessions(c, customerGUID)
+	if err != nil {
+		handlers.ResponseInternalServerError(c, "failed to revoke sessions", err)
+		return
+	}
+	log.LogNTrace(fmt.Sprintf("revokeCustomerSessions revoked %d sessions of customer %s by admin %s", revoked, customerGUID, c.GetString(consts.CustomerGUID)), c)
+	c.JSON(http.StatusOK, gin.H{"revoked": revoked})
+}
+
+type impersonateRequest struct {
+	AllowWrite bool   `json:"allowWrite"`
+	Reason     string `json:"reason"`
+}
+
+// impersonate issues a time-boxed session of the admin in the customer tenant, the session is read only unless allowWrite is set
+func impersonate(c *gin.Context) {
+	defer log.LogNTraceEnterExit("impersonate", c)()
+	customerGUID := c.Param(consts.CustomerGUID)
+	if customerGUID == "" {
+		handlers.ResponseMissingKey(c, consts.CustomerGUID)
+		return
+	}
+	var req impersonateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			handlers.ResponseFailedToBindJson(c, err)
+			return
+		}
+	}
+	impersonator := auth.Impersonator{
+		CustomerGUID: c.GetString(consts.CustomerGUID),
+		UserID:       c.GetString(consts.UserID),
+	}
+	token, session, err := auth.NewImpersonationSession(c, customerGUID, impersonator, req.AllowWrite, req.Reason)
+	if err != nil {
+		handlers.ResponseInternalServerError(c, "failed to create impersonation session", err)
+		return
+	}
+	log.LogNTrace(fmt.Sprintf("impersonate admin %s impersonates customer %s, session %s, allowWrite %t, reason: %s",
+		impersonator.CustomerGUID, customerGUID, session.ID, req.AllowWrite, req.Reason), c)
+	auth.SetSessionCookies(c, token, int(auth.ImpersonationTTL().Seconds()))
+	c.JSON(http.StatusCreated, session)
+}
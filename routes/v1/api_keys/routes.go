@@ -0,0 +1,93 @@
+package api_keys
+
+import (
+	"config-service/auth"
+	"config-service/handlers"
+	"config-service/utils/consts"
+	"config-service/utils/log"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AddRoutes(g *gin.Engine) {
+	apiKeys := g.Group(consts.APIKeysPath)
+
+	apiKeys.GET("", getAPIKeys)
+	apiKeys.POST("", postAPIKey)
+	apiKeys.DELETE("/:"+consts.GUIDField, deleteAPIKey)
+}
+
+type apiKeyRequest struct {
+	Name           string     `json:"name"`
+	Scopes         []string   `json:"scopes"`
+	ExpirationTime *time.Time `json:"expirationTime,omitempty"`
+}
+
+// apiKeyResponse - the created API key with the key value, the key value is returned only on creation
+type apiKeyResponse struct {
+	*auth.APIKey
+	Key string `json:"key"`
+}
+
+func postAPIKey(c *gin.Context) {
+	defer log.LogNTraceEnterExit("postAPIKey", c)()
+	var req apiKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.ResponseFailedToBindJson(c, err)
+		return
+	}
+	if req.Name == "" {
+		handlers.ResponseMissingName(c)
+		return
+	}
+	if err := handlers.ValidateAPIKeyScopes(req.Scopes); err != nil {
+		handlers.ResponseBadRequest(c, err.Error())
+		return
+	}
+	if req.ExpirationTime != nil {
+		if !req.ExpirationTime.After(time.Now()) {
+			handlers.ResponseBadRequest(c, "expirationTime must be in the future")
+			return
+		}
+		expirationTime := req.ExpirationTime.UTC()
+		req.ExpirationTime = &expirationTime
+	}
+	customerGUID := c.GetString(consts.CustomerGUID)
+	key, apiKey, err := auth.NewAPIKey(c, customerGUID, req.Name, req.Scopes, req.ExpirationTime, c.GetString(consts.UserID))
+	if err != nil {
+		handlers.ResponseInternalServerError(c, "failed to create API key", err)
+		return
+	}
+	log.LogNTrace(fmt.Sprintf("API key %s created for customer %s", apiKey.GUID, customerGUID), c)
+	c.JSON(http.StatusCreated, apiKeyResponse{APIKey: apiKey, Key: key})
+}
+
+func getAPIKeys(c *gin.Context) {
+	defer log.LogNTraceEnterExit("getAPIKeys", c)()
+	apiKeys, err := auth.ListAPIKeys(c, c.GetString(consts.CustomerGUID))
+	if err != nil {
+		handlers.ResponseInternalServerError(c, "failed to list API keys", err)
+		return
+	}
+	c.JSON(http.StatusOK, apiKeys)
+}
+
+func deleteAPIKey(c *gin.Context) {
+	defer log.LogNTraceEnterExit("deleteAPIKey", c)()
+	guid := c.Param(consts.GUIDField)
+	if guid == "" {
+		handlers.ResponseMissingGUID(c)
+		return
+	}
+	if revoked, err := auth.RevokeAPIKey(c, c.GetString(consts.CustomerGUID), guid); err != nil {
+		handlers.ResponseInternalServerError(c, "failed to revoke API key", err)
+	} else if !revoked {
+		handlers.ResponseDocumentNotFound(c)
+	} else {
+		log.LogNTrace(fmt.Sprintf("API key %s revoked", guid), c)
+		c.JSON(http.StatusOK, gin.H{"deletedCount": 1})
+	}
+}
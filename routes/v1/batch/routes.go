@@ -0,0 +1,221 @@
+package batch
+
+import (
+	"bytes"
+	"config-service/audit"
+	"config-service/db/mongo"
+	"config-service/handlers"
+	"config-service/utils"
+	"config-service/utils/consts"
+	"config-service/utils/log"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+	mongoDB "go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/exp/slices"
+)
+
+// batch of operations on the service routes
+// each operation is dispatched through the router as a request with the identity of the batch request,
+// atomic batches run in one db transaction that is rolled back when an operation fails
+
+const defaultMaxOperations = 100
+
+var operationMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete}
+
+// identity keys of the batch request that are set in its operations requests, the operations are authorized with the full identity
+// of the batch request (API key scopes and user role included) and are not rate limited or CSRF checked apart from the batch request
+var identityKeys = []string{consts.CustomerGUID, consts.AuthMethod, consts.UserID, consts.AdminAccess, consts.APIKeyScopes,
+	consts.UserRole, consts.ReadOnly, consts.Impersonator, consts.ImpersonatorID}
+
+type identityContextKey struct{}
+
+// Operation - operation of a batch request
+type Operation struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"` //path and query of the route e.g. /v1_posture_exception_policy?policyName=p1
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// Request - batch request body
+type Request struct {
+	Atomic     bool        `json:"atomic"` //run all the operations in one transaction, stop and roll back on the first failed operation
+	Operations []Operation `json:"operations"`
+}
+
+// OperationResult - response of an operation, operations after a failed operation of an atomic batch have no result
+type OperationResult struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// Response - batch response body
+type Response struct {
+	BatchID          string            `json:"batchId"`
+	Atomic           bool              `json:"atomic"`
+	Committed        bool              `json:"committed"` //the changes of the succeeded operations are kept, false when an atomic batch is rolled back
+	FailedOperations int               `json:"failedOperations"`
+	Results          []OperationResult `json:"results"`
+}
+
+func AddRoutes(g *gin.Engine) {
+	maxOperations := utils.GetConfig().Batch.MaxOperations
+	if maxOperations <= 0 {
+		maxOperations = defaultMaxOperations
+	}
+	g.POST(consts.BatchPath, handleBatch(g, maxOperations))
+}
+
+// Identity returns the identity context keys of the batch request of an operation request, nil when the request is not a batch operation
+func Identity(c *gin.Context) map[string]interface{} {
+	identity, _ := c.Request.Context().Value(identityContextKey{}).(map[string]interface{})
+	return identity
+}
+
+func handleBatch(router http.Handler, maxOperations int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer log.LogNTraceEnterExit("handleBatch", c)()
+		var req Request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			handlers.ResponseFailedToBindJson(c, err)
+			return
+		}
+		if len(req.Operations) == 0 {
+			handlers.ResponseBadRequest(c, "no operations in request")
+			return
+		}
+		if len(req.Operations) > maxOperations {
+			msg := fmt.Sprintf("too many operations, max %d operations are allowed", maxOperations)
+			log.LogNTrace(msg, c)
+			handlers.ResponseProblem(c, http.StatusBadRequest, handlers.ErrorCodeBadRequest, msg, gin.H{"maxOperations": maxOperations})
+			return
+		}
+		for i := range req.Operations {
+			if err := req.Operations[i].validate(); err != nil {
+				msg := fmt.Sprintf("operations[%d]: %s", i, err.Error())
+				log.LogNTrace(msg, c)
+				handlers.ResponseProblem(c, http.StatusBadRequest, handlers.ErrorCodeInvalidBody, msg, nil)
+				return
+			}
+		}
+
+		identity := map[string]interface{}{}
+		for _, key := range identityKeys {
+			if value, ok := c.Get(key); ok {
+				identity[key] = value
+			}
+		}
+		ctx := context.WithValue(c.Request.Context(), identityContextKey{}, identity)
+		var session mongoDB.Session
+		if req.Atomic {
+			var err error
+			if session, err = mongo.StartSession(c); errors.Is(err, mongo.ErrTransactionsNotSupported) {
+				log.LogNTrace(err.Error(), c)
+				handlers.ResponseProblem(c, http.StatusNotImplemented, handlers.ErrorCodeNotSupported, "atomic batches are not supported: "+err.Error(), nil)
+				return
+			} else if err != nil {
+				handlers.ResponseInternalServerError(c, "failed to start db session", err)
+				return
+			}
+			defer session.EndSession(context.Background())
+			if err := session.StartTransaction(); err != nil {
+				handlers.ResponseInternalServerError(c, "failed to start db transaction", err)
+				return
+			}
+			ctx = mongoDB.NewSessionContext(ctx, session)
+		}
+
+		response := Response{BatchID: uuid.NewV4().String(), Atomic: req.Atomic, Committed: true}
+		for _, operation := range req.Operations {
+			result := dispatch(ctx, router, operation)
+			response.Results = append(response.Results, result)
+			if result.Status >= http.StatusBadRequest {
+				response.FailedOperations++
+				if req.Atomic {
+					break
+				}
+			}
+		}
+		var commitErr error
+		if req.Atomic {
+			//use a new context, the request context may be canceled
+			if response.FailedOperations > 0 {
+				response.Committed = false
+				if err := session.AbortTransaction(context.Background()); err != nil {
+					log.LogNTraceError("failed to abort batch transaction", err, c)
+				}
+			} else if commitErr = session.CommitTransaction(context.Background()); commitErr != nil {
+				response.Committed = false
+			}
+		}
+
+		entries := make([]audit.Entry, 0, len(response.Results))
+		for i, result := range response.Results {
+			entry := audit.NewEntry(c, req.Operations[i].Method, req.Operations[i].Path, result.Status)
+			entry.BatchID = response.BatchID
+			entry.Operation = i
+			entry.Committed = response.Committed && result.Status < http.StatusBadRequest
+			entries = append(entries, entry)
+		}
+		if err := audit.Record(context.Background(), entries...); err != nil {
+			log.LogNTraceError("failed to record batch operations in audit log", err, c)
+		}
+
+		if commitErr != nil {
+			handlers.ResponseInternalServerError(c, "failed to commit batch transaction", commitErr)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// dispatch serves the operation with the router and returns its response
+func dispatch(ctx context.Context, router http.Handler, operation Operation) OperationResult {
+	var body io.Reader
+	if len(operation.Body) > 0 {
+		body = bytes.NewReader(operation.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, operation.Method, operation.Path, body)
+	if err != nil {
+		//not expected for validated operations
+		problem, _ := json.Marshal(gin.H{"error": err.Error()})
+		return OperationResult{Status: http.StatusBadRequest, Body: problem}
+	}
+	//operations responses are embedded in the batch response
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	result := OperationResult{Status: w.Code}
+	if w.Body.Len() > 0 && json.Valid(w.Body.Bytes()) {
+		result.Body = w.Body.Bytes()
+	}
+	return result
+}
+
+func (operation *Operation) validate() error {
+	operation.Method = strings.ToUpper(operation.Method)
+	if !slices.Contains(operationMethods, operation.Method) {
+		return fmt.Errorf("method %s is not supported", operation.Method)
+	}
+	path, err := url.Parse(operation.Path)
+	if err != nil {
+		return fmt.Errorf("invalid path: %w", err)
+	}
+	if path.Scheme != "" || path.Host != "" || !strings.HasPrefix(path.Path, "/") {
+		return fmt.Errorf("path must be a service path e.g. %s", consts.ClusterPath)
+	}
+	if path.Path == consts.BatchPath || strings.HasPrefix(path.Path, consts.BatchPath+"/") {
+		return fmt.Errorf("nested batch requests are not allowed")
+	}
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 func AddRoutes(g *gin.Engine) {
 	handlers.AddRoutes(g, handlers.NewRouterOptionsBuilder[*types.Cluster]().
 		WithPath(consts.ClusterPath).
+		WithV2Path(consts.V2ClustersPath).
 		WithDBCollection(consts.ClustersCollection).
 		WithValidatePostUniqueName(true).
 		WithValidatePutGUID(true).
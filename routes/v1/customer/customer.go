@@ -91,11 +91,15 @@ func postCustomerTenant(c *gin.Context) {
 		handlers.ResponseMissingGUID(c)
 		return
 	}
+	handlers.PostDBDocumentHandler(c, NewTenantDocument(customer))
+}
+
+// NewTenantDocument initializes a new customer tenant and returns its db document, the tenant owns itself
+func NewTenantDocument(customer *types.Customer) types.Document[*types.Customer] {
 	customer.InitNew()
-	dbDoc := types.Document[*types.Customer]{
+	return types.Document[*types.Customer]{
 		ID:        customer.GUID,
 		Content:   customer,
 		Customers: []string{customer.GUID},
 	}
-	handlers.PostDBDocumentHandler(c, dbDoc)
 }
@@ -6,6 +6,8 @@ import (
 	"config-service/types"
 	"config-service/utils/consts"
 	"config-service/utils/log"
+	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/imdario/mergo"
@@ -38,6 +40,9 @@ func getCustomerConfigByNameHandler(c *gin.Context) bool {
 
 	//case default config is requested - return it
 	if configName == consts.GlobalConfigName {
+		if configNotModified(c, defaultConfig) {
+			return true
+		}
 		c.JSON(http.StatusOK, defaultConfig)
 		return true
 	}
@@ -51,6 +56,8 @@ func getCustomerConfigByNameHandler(c *gin.Context) bool {
 		if doc == nil {
 			handlers.ResponseDocumentNotFound(c)
 			return true
+		} else if configNotModified(c, doc) {
+			return true
 		} else {
 			c.JSON(http.StatusOK, doc)
 			return true
@@ -58,6 +65,10 @@ func getCustomerConfigByNameHandler(c *gin.Context) bool {
 	}
 	//case customer config is requested - return it merged with default config
 	if configName == consts.CustomerConfigName {
+		//the ETag covers the layers before they are merged
+		if configNotModified(c, defaultConfig, doc) {
+			return true
+		}
 		if doc != nil {
 			if err := mergo.Merge(doc, *defaultConfig); err != nil {
 				handlers.ResponseInternalServerError(c, "failed to merge configuration", err)
@@ -78,6 +89,9 @@ func getCustomerConfigByNameHandler(c *gin.Context) bool {
 		handlers.ResponseInternalServerError(c, "failed to get document by name", err)
 		return true
 	}
+	if configNotModified(c, defaultConfig, customerConfig, doc) {
+		return true
+	}
 	customerConfig, err = mergeConfigurations(customerConfig, defaultConfig)
 	if err != nil {
 		handlers.ResponseInternalServerError(c, "failed to merge configuration", err)
@@ -93,6 +107,36 @@ func getCustomerConfigByNameHandler(c *gin.Context) bool {
 	return true
 }
 
+// configNotModified responds 304 Not Modified when the configuration layers (default, customer and cluster configurations) are not modified
+func configNotModified(c *gin.Context, layers ...*types.CustomerConfig) bool {
+	docs := make([]interface{}, 0, len(layers))
+	for _, layer := range layers {
+		docs = append(docs, layer)
+	}
+	return handlers.NotModified(c, handlers.ETag(c, docs...), handlers.LastModified(docs...))
+}
+
+// ResolveClusterConfig returns the configuration of the cluster merged over the customer and default configurations,
+// the context must have the customer and the customer configuration collection
+func ResolveClusterConfig(c context.Context, clusterName string) (*types.CustomerConfig, error) {
+	defaultConfig, err := db.GetCachedDocument[*types.CustomerConfig](consts.DefaultCustomerConfigKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get default config: %w", err)
+	}
+	customerConfig, err := db.GetDocByName[types.CustomerConfig](c, consts.CustomerConfigName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer config: %w", err)
+	}
+	clusterConfig, err := db.GetDocByName[types.CustomerConfig](c, clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster config: %w", err)
+	}
+	if customerConfig, err = mergeConfigurations(customerConfig, defaultConfig); err != nil {
+		return nil, err
+	}
+	return mergeConfigurations(clusterConfig, customerConfig)
+}
+
 func mergeConfigurations(dest, src *types.CustomerConfig) (*types.CustomerConfig, error) {
 	if dest == nil {
 		return src, nil
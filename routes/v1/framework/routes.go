@@ -11,6 +11,7 @@ import (
 func AddRoutes(g *gin.Engine) {
 	handlers.AddRoutes(g, handlers.NewRouterOptionsBuilder[*types.Framework]().
 		WithPath(consts.FrameworkPath).
+		WithV2Path(consts.V2FrameworksPath).
 		WithDBCollection(consts.FrameworkCollection).
 		WithNameQuery(consts.FrameworkNameParam).
 		WithDeleteByName(true).
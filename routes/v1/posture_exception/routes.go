@@ -5,6 +5,7 @@ import (
 	"config-service/types"
 	"config-service/utils/consts"
 
+	"github.com/armosec/armoapi-go/armotypes"
 	"github.com/gin-gonic/gin"
 )
 
@@ -27,5 +28,14 @@ func AddRoutes(g *gin.Engine) {
 	}
 	handlers.AddPolicyRoutes[*types.PostureExceptionPolicy](g,
 		consts.PostureExceptionPolicyPath,
-		consts.PostureExceptionPolicyCollection, queryParamsConfig)
+		consts.V2PostureExceptionPoliciesPath,
+		consts.PostureExceptionPolicyCollection, queryParamsConfig,
+		handlers.NewRouterOptionsBuilder[*types.PostureExceptionPolicy]().
+			WithSchemaRefiner(refineSchema).
+			Get()...)
+}
+
+func refineSchema(schema *handlers.JSONSchema) {
+	schema.Property("policyType").Enum = []interface{}{"postureExceptionPolicy"}
+	schema.Property("actions").Items.Enum = []interface{}{armotypes.AlertOnly, armotypes.Disable}
 }
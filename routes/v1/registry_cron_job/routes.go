@@ -11,6 +11,7 @@ import (
 func AddRoutes(g *gin.Engine) {
 	handlers.AddRoutes(g, handlers.NewRouterOptionsBuilder[*types.RegistryCronJob]().
 		WithPath(consts.RegistryCronJobPath).
+		WithV2Path(consts.V2RegistryCronJobsPath).
 		WithDBCollection(consts.RegistryCronJobCollection).
 		WithValidatePostUniqueName(true).
 		WithValidatePutGUID(true).
@@ -16,6 +16,7 @@ func AddRoutes(g *gin.Engine) {
 
 	handlers.AddRoutes(g, handlers.NewRouterOptionsBuilder[*types.Repository]().
 		WithPath(consts.RepositoryPath).
+		WithV2Path(consts.V2RepositoriesPath).
 		WithDBCollection(consts.RepositoryCollection).
 		WithValidatePostUniqueName(true).
 		WithValidatePutGUID(true).
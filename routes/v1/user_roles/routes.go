@@ -0,0 +1,79 @@
+package user_roles
+
+import (
+	"config-service/auth"
+	"config-service/handlers"
+	"config-service/utils/consts"
+	"config-service/utils/log"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AddRoutes(g *gin.Engine) {
+	userRoles := g.Group(consts.UserRolesPath)
+
+	userRoles.GET("", getUserRoles)
+	userRoles.GET("/:"+consts.UserIdParam, getUserRole)
+	userRoles.PUT("/:"+consts.UserIdParam, putUserRole)
+	userRoles.DELETE("/:"+consts.UserIdParam, deleteUserRole)
+}
+
+func getUserRoles(c *gin.Context) {
+	defer log.LogNTraceEnterExit("getUserRoles", c)()
+	userRoles, err := auth.ListUserRoles(c, c.GetString(consts.CustomerGUID))
+	if err != nil {
+		handlers.ResponseInternalServerError(c, "failed to list user roles", err)
+		return
+	}
+	c.JSON(http.StatusOK, userRoles)
+}
+
+// getUserRole returns the effective role of the user (the default role if the user has no stored role)
+func getUserRole(c *gin.Context) {
+	defer log.LogNTraceEnterExit("getUserRole", c)()
+	userID := c.Param(consts.UserIdParam)
+	role, err := auth.GetUserRole(c, c.GetString(consts.CustomerGUID), userID)
+	if err != nil {
+		handlers.ResponseInternalServerError(c, "failed to get user role", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{consts.UserIdParam: userID, "role": role})
+}
+
+func putUserRole(c *gin.Context) {
+	defer log.LogNTraceEnterExit("putUserRole", c)()
+	userID := c.Param(consts.UserIdParam)
+	var req struct {
+		Role auth.Role `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.ResponseFailedToBindJson(c, err)
+		return
+	}
+	if !auth.IsValidRole(req.Role) {
+		handlers.ResponseBadRequest(c, fmt.Sprintf("invalid role %s", req.Role))
+		return
+	}
+	userRole, err := auth.SetUserRole(c, c.GetString(consts.CustomerGUID), userID, req.Role, c.GetString(consts.UserID))
+	if err != nil {
+		handlers.ResponseInternalServerError(c, "failed to set user role", err)
+		return
+	}
+	log.LogNTrace(fmt.Sprintf("user %s role set to %s", userID, req.Role), c)
+	c.JSON(http.StatusOK, userRole)
+}
+
+func deleteUserRole(c *gin.Context) {
+	defer log.LogNTraceEnterExit("deleteUserRole", c)()
+	userID := c.Param(consts.UserIdParam)
+	if deleted, err := auth.DeleteUserRole(c, c.GetString(consts.CustomerGUID), userID); err != nil {
+		handlers.ResponseInternalServerError(c, "failed to delete user role", err)
+	} else if !deleted {
+		handlers.ResponseDocumentNotFound(c)
+	} else {
+		log.LogNTrace(fmt.Sprintf("user %s role deleted", userID), c)
+		c.JSON(http.StatusOK, gin.H{"deletedCount": 1})
+	}
+}
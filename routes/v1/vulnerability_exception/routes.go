@@ -5,6 +5,7 @@ import (
 	"config-service/types"
 	"config-service/utils/consts"
 
+	"github.com/armosec/armoapi-go/armotypes"
 	"github.com/gin-gonic/gin"
 )
 
@@ -29,5 +30,14 @@ func AddRoutes(g *gin.Engine) {
 
 	handlers.AddPolicyRoutes[*types.VulnerabilityExceptionPolicy](g,
 		consts.VulnerabilityExceptionPolicyPath,
-		consts.VulnerabilityExceptionPolicyCollection, queryParamsConfig)
+		consts.V2VulnerabilityExceptionPoliciesPath,
+		consts.VulnerabilityExceptionPolicyCollection, queryParamsConfig,
+		handlers.NewRouterOptionsBuilder[*types.VulnerabilityExceptionPolicy]().
+			WithSchemaRefiner(refineSchema).
+			Get()...)
+}
+
+func refineSchema(schema *handlers.JSONSchema) {
+	schema.Property("policyType").Enum = []interface{}{"vulnerabilityExceptionPolicy"}
+	schema.Property("actions").Items.Enum = []interface{}{armotypes.Ignore}
 }
@@ -0,0 +1,134 @@
+package webhook
+
+import (
+	"config-service/handlers"
+	"config-service/types"
+	"config-service/utils/consts"
+	"config-service/utils/log"
+	"config-service/webhooks"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	minSecretLength      = 16
+	defaultDeliveriesMax = 100
+	maxDeliveries        = 1000
+)
+
+// AddRoutes adds the webhook subscriptions routes and the delivery log routes,
+// it must be called after the routes of the subscribed document types
+func AddRoutes(g *gin.Engine) {
+	group := handlers.AddRoutes(g, handlers.NewRouterOptionsBuilder[*types.WebhookSubscription]().
+		WithPath(consts.WebhookPath).
+		WithDBCollection(consts.WebhookCollection).
+		WithValidatePostUniqueName(true).
+		WithValidatePutGUID(true).
+		WithPostValidators(validateSubscriptions(true)).
+		WithPutValidators(validateSubscriptions(false)).
+		WithSchemaRefiner(refineSchema).
+		Get()...)
+	group.GET("/deliveries", getDeliveries)
+	group.POST("/deliveries/:"+consts.GUIDField+"/redeliver", redeliver)
+}
+
+func refineSchema(schema *handlers.JSONSchema) {
+	schema.Definition().Required = append(schema.Definition().Required, "url", "collections", "secret")
+	eventTypes := []interface{}{}
+	for _, eventType := range webhooks.EventTypes {
+		eventTypes = append(eventTypes, string(eventType))
+	}
+	schema.Property("eventTypes").Items.Enum = eventTypes
+}
+
+// validateSubscriptions validates the target URL, the collections and the secret of the subscriptions, the fields of new subscriptions are mandatory
+func validateSubscriptions(newSubscriptions bool) handlers.MutatorValidator[*types.WebhookSubscription] {
+	return func(c *gin.Context, docs []*types.WebhookSubscription) ([]*types.WebhookSubscription, bool) {
+		defer log.LogNTraceEnterExit("validateSubscriptions", c)()
+		collections := subscribableCollections()
+		for _, doc := range docs {
+			if doc.URL != "" || newSubscriptions {
+				if target, err := url.Parse(doc.URL); err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+					handlers.ResponseBadRequest(c, "url must be an absolute http or https URL")
+					return nil, false
+				}
+			}
+			if len(doc.Collections) == 0 && newSubscriptions {
+				handlers.ResponseMissingKey(c, "collections")
+				return nil, false
+			}
+			for _, collection := range doc.Collections {
+				if !slices.Contains(collections, collection) {
+					msg := fmt.Sprintf("collection %s cannot be subscribed", collection)
+					handlers.ResponseProblem(c, http.StatusBadRequest, handlers.ErrorCodeInvalidBody, msg, gin.H{"collections": collections})
+					return nil, false
+				}
+			}
+			if (doc.Secret != "" || newSubscriptions) && len(doc.Secret) < minSecretLength {
+				handlers.ResponseBadRequest(c, fmt.Sprintf("secret must have at least %d characters", minSecretLength))
+				return nil, false
+			}
+		}
+		return docs, true
+	}
+}
+
+// subscribableCollections returns the collections of the document types routes except the subscriptions collection
+func subscribableCollections() []string {
+	collections := []string{}
+	for _, docType := range handlers.DocTypes() {
+		if docType.Collection != consts.WebhookCollection {
+			collections = append(collections, docType.Collection)
+		}
+	}
+	return collections
+}
+
+// getDeliveries responds with the delivery log of the customer, latest first
+func getDeliveries(c *gin.Context) {
+	defer log.LogNTraceEnterExit("getDeliveries", c)()
+	var err error
+	var limit, skip = defaultDeliveriesMax, 0
+	if limitStr := c.Query(consts.LimitParam); limitStr != "" {
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit <= 0 || limit > maxDeliveries {
+			handlers.ResponseBadRequest(c, fmt.Sprintf("%s must be a number between 1 and %d", consts.LimitParam, maxDeliveries))
+			return
+		}
+	}
+	if skipStr := c.Query(consts.SkipParam); skipStr != "" {
+		if skip, err = strconv.Atoi(skipStr); err != nil || skip < 0 {
+			handlers.ResponseBadRequest(c, consts.SkipParam+" must be a positive number")
+			return
+		}
+	}
+	status := webhooks.Status(c.Query(consts.StatusParam))
+	if status != "" && !slices.Contains([]webhooks.Status{webhooks.StatusPending, webhooks.StatusDelivering, webhooks.StatusDelivered, webhooks.StatusDead}, status) {
+		handlers.ResponseBadRequest(c, fmt.Sprintf("unknown %s %s", consts.StatusParam, status))
+		return
+	}
+	deliveries, err := webhooks.ListDeliveries(c, c.GetString(consts.CustomerGUID), c.Query(consts.SubscriptionParam), status, int64(limit), int64(skip))
+	if err != nil {
+		handlers.ResponseInternalServerError(c, "failed to list webhook deliveries", err)
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// redeliver moves a dead delivery of the customer back to the outbox
+func redeliver(c *gin.Context) {
+	defer log.LogNTraceEnterExit("redeliver", c)()
+	guid := c.Param(consts.GUIDField)
+	if delivery, err := webhooks.Redeliver(c, c.GetString(consts.CustomerGUID), guid); err != nil {
+		handlers.ResponseInternalServerError(c, "failed to redeliver webhook delivery", err)
+	} else if delivery == nil {
+		handlers.ResponseDocumentNotFound(c)
+	} else {
+		log.LogNTrace(fmt.Sprintf("webhook delivery %s redelivered", guid), c)
+		c.JSON(http.StatusOK, delivery)
+	}
+}
@@ -1,11 +1,27 @@
 package main
 
 import (
+	"bufio"
+	configservicev1 "config-service/api/configservice/v1"
+	"config-service/audit"
+	"config-service/client"
+	"config-service/db/mongo"
+	"config-service/grpcserver"
+	"config-service/handlers"
+	"config-service/ratelimit"
 	"config-service/types"
 	"config-service/utils"
 	"config-service/utils/consts"
+	"config-service/webhooks"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"time"
 
 	_ "embed"
@@ -13,7 +29,18 @@ import (
 	"github.com/armosec/armoapi-go/armotypes"
 	rndStr "github.com/dchest/uniuri"
 
+	"github.com/gin-gonic/gin"
 	"github.com/google/go-cmp/cmp"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+	"sigs.k8s.io/yaml"
 )
 
 //go:embed test_data/clusters.json
@@ -783,3 +810,1118 @@ func (suite *MainTestSuite) TestCustomerState() {
 	state.Onboarding.Completed = utils.BoolPointer(false)
 	testPutDoc(suite, statePath, prevState, state, nil)
 }
+
+func (suite *MainTestSuite) TestAPIKeys() {
+	const customerGUID = "api-keys-customer-guid"
+	suite.login(customerGUID)
+	clusters, _ := loadJson[*types.Cluster](clustersJson)
+	cluster := testPostDoc(suite, consts.ClusterPath, clusters[0], newClusterCompareFilter)
+
+	//bad requests
+	testBadRequest(suite, http.MethodPost, consts.APIKeysPath, errorMissingName, map[string]interface{}{"scopes": []string{"cluster:read"}}, http.StatusBadRequest)
+	testBadRequest(suite, http.MethodPost, consts.APIKeysPath, `{"error":"scopes are required"}`, map[string]interface{}{"name": "ci"}, http.StatusBadRequest)
+	testBadRequest(suite, http.MethodPost, consts.APIKeysPath, `{"error":"invalid scope no_path:read, unknown path no_path"}`,
+		map[string]interface{}{"name": "ci", "scopes": []string{"no_path:read"}}, http.StatusBadRequest)
+
+	//create read only key
+	w := suite.doRequest(http.MethodPost, consts.APIKeysPath, map[string]interface{}{"name": "ci", "scopes": []string{"cluster:read", "v1_repository:write"}})
+	suite.Equal(http.StatusCreated, w.Code)
+	created := decode[map[string]interface{}](suite, w.Body.Bytes())
+	key, _ := created["key"].(string)
+	suite.NotEmpty(key, "key should be returned on creation")
+	suite.Nil(created["keyHash"], "key hash should not be returned")
+	keyGUID, _ := created["guid"].(string)
+
+	//list keys - key value is not returned
+	w = suite.doRequest(http.MethodGet, consts.APIKeysPath, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	keys := decodeArray[map[string]interface{}](suite, w.Body.Bytes())
+	suite.Len(keys, 1)
+	suite.Equal(keyGUID, keys[0]["guid"])
+	suite.Nil(keys[0]["key"])
+	suite.Nil(keys[0]["lastUsedTime"])
+
+	//use the key
+	suite.authCookie = ""
+	suite.apiKey = key
+	w = suite.doRequest(http.MethodGet, consts.ClusterPath, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Len(decodeArray[*types.Cluster](suite, w.Body.Bytes()), 1)
+	testBadRequest(suite, http.MethodDelete, consts.ClusterPath+"/"+cluster.GUID, `{"error":"API key is missing scope cluster:write"}`, nil, http.StatusForbidden)
+	testBadRequest(suite, http.MethodGet, consts.FrameworkPath, `{"error":"API key is missing scope v1_opa_framework:read"}`, nil, http.StatusForbidden)
+	w = suite.doRequest(http.MethodGet, consts.RepositoryPath, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	//key cannot be used for not scoped routes
+	testBadRequest(suite, http.MethodGet, consts.APIKeysPath, `{"error":"API keys are not allowed for this route"}`, nil, http.StatusForbidden)
+	testBadRequest(suite, http.MethodGet, "/customer", `{"error":"API keys are not allowed for this route"}`, nil, http.StatusForbidden)
+
+	//last used time is updated
+	suite.apiKey = ""
+	suite.login(customerGUID)
+	w = suite.doRequest(http.MethodGet, consts.APIKeysPath, nil)
+	keys = decodeArray[map[string]interface{}](suite, w.Body.Bytes())
+	suite.NotNil(keys[0]["lastUsedTime"])
+
+	//other customer cannot revoke the key
+	suite.login(defaultUserGUID)
+	testBadRequest(suite, http.MethodDelete, consts.APIKeysPath+"/"+keyGUID, errorDocumentNotFound, nil, http.StatusNotFound)
+	//revoke key
+	suite.login(customerGUID)
+	w = suite.doRequest(http.MethodDelete, consts.APIKeysPath+"/"+keyGUID, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.authCookie = ""
+	suite.apiKey = key
+	testBadRequest(suite, http.MethodGet, consts.ClusterPath, errorUnauthorized, nil, http.StatusUnauthorized)
+
+	//expired key
+	suite.apiKey = ""
+	suite.login(customerGUID)
+	testBadRequest(suite, http.MethodPost, consts.APIKeysPath, `{"error":"expirationTime must be in the future"}`,
+		map[string]interface{}{"name": "expired", "scopes": []string{"cluster:read"}, "expirationTime": time.Now().Add(-time.Hour)}, http.StatusBadRequest)
+}
+
+func (suite *MainTestSuite) TestRateLimit() {
+	const (
+		limitedCustomer = "rate-limited-customer-guid"
+		otherCustomer   = "not-limited-customer-guid"
+	)
+	ratelimit.Init(utils.RateLimitConfig{
+		Customers: map[string]map[string]utils.RateLimit{
+			limitedCustomer: {"cluster:" + ratelimit.ClassRead: {RequestsPerSecond: 1, Burst: 3}},
+		},
+	})
+	defer ratelimit.Init(utils.GetConfig().RateLimit)
+
+	suite.login(limitedCustomer)
+	for i := 0; i < 3; i++ {
+		w := suite.doRequest(http.MethodGet, consts.ClusterPath, nil)
+		suite.Equal(http.StatusOK, w.Code)
+	}
+	w := suite.doRequest(http.MethodGet, consts.ClusterPath, nil)
+	suite.Equal(http.StatusTooManyRequests, w.Code)
+	problem := decode[handlers.Problem](suite, w.Body.Bytes())
+	suite.Equal(handlers.ErrorCodeRateLimited, problem.Code)
+	suite.Equal("rate limit exceeded", problem.Error)
+	suite.Equal("1", w.Header().Get("Retry-After"))
+	//other route groups and customers are not limited
+	w = suite.doRequest(http.MethodGet, consts.FrameworkPath, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.login(otherCustomer)
+	w = suite.doRequest(http.MethodGet, consts.ClusterPath, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	//tokens are refilled
+	time.Sleep(time.Second)
+	suite.login(limitedCustomer)
+	w = suite.doRequest(http.MethodGet, consts.ClusterPath, nil)
+	suite.Equal(http.StatusOK, w.Code)
+
+	//in-flight requests limit
+	ratelimit.Init(utils.RateLimitConfig{
+		Customers: map[string]map[string]utils.RateLimit{
+			limitedCustomer: {ratelimit.ClassRead: {MaxInFlight: 1}},
+		},
+	})
+	key := limitedCustomer + "|cluster|" + ratelimit.ClassRead
+	//simulate a running request
+	_, err := mongo.GetWriteCollection(consts.RateLimitsCollection).UpdateOne(context.Background(), bson.M{"_id": key},
+		bson.M{"$set": bson.M{"inFlight": bson.A{bson.M{"id": "running", "expiration": time.Now().Add(time.Minute)}}}})
+	suite.NoError(err)
+	w = suite.doRequest(http.MethodGet, consts.ClusterPath, nil)
+	suite.Equal(http.StatusTooManyRequests, w.Code)
+	suite.Equal("too many concurrent requests", decode[handlers.Problem](suite, w.Body.Bytes()).Error)
+	//expired in-flight leases are dropped
+	_, err = mongo.GetWriteCollection(consts.RateLimitsCollection).UpdateOne(context.Background(), bson.M{"_id": key},
+		bson.M{"$set": bson.M{"inFlight": bson.A{bson.M{"id": "crashed", "expiration": time.Now().Add(-time.Minute)}}}})
+	suite.NoError(err)
+	w = suite.doRequest(http.MethodGet, consts.ClusterPath, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	w = suite.doRequest(http.MethodGet, consts.ClusterPath, nil)
+	suite.Equal(http.StatusOK, w.Code, "in-flight lease should be released after the request")
+}
+
+func (suite *MainTestSuite) TestCORS() {
+	router := gin.New()
+	router.Use(corsMiddleware(utils.CORSConfig{
+		AllowedOrigins:   []string{"https://portal.example.com"},
+		AllowCredentials: true,
+		MaxAgeSeconds:    600,
+	}))
+	router.Any(consts.ClusterPath, func(c *gin.Context) { c.Status(http.StatusOK) })
+	request := func(method, origin string, header map[string]string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, consts.ClusterPath, nil)
+		suite.NoError(err)
+		req.Header.Set("Origin", origin)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	//preflight of allowed origin
+	w := request(http.MethodOptions, "https://portal.example.com", map[string]string{"Access-Control-Request-Method": http.MethodPut})
+	suite.Equal(http.StatusNoContent, w.Code)
+	suite.Equal("https://portal.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	suite.Equal("true", w.Header().Get("Access-Control-Allow-Credentials"))
+	suite.Equal("GET, POST, PUT, DELETE, HEAD", w.Header().Get("Access-Control-Allow-Methods"))
+	suite.Contains(w.Header().Get("Access-Control-Allow-Headers"), consts.CSRFHeader)
+	suite.Equal("600", w.Header().Get("Access-Control-Max-Age"))
+	//actual request
+	w = request(http.MethodGet, "https://portal.example.com", nil)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal("https://portal.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	suite.Equal("Origin", w.Header().Get("Vary"))
+	//not allowed origin
+	w = request(http.MethodOptions, "https://evil.example.com", map[string]string{"Access-Control-Request-Method": http.MethodPut})
+	suite.Equal(http.StatusForbidden, w.Code)
+	w = request(http.MethodGet, "https://evil.example.com", nil)
+	suite.Empty(w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func (suite *MainTestSuite) TestV2API() {
+	const customerGUID = "v2-customer-guid"
+	suite.login(customerGUID)
+	clusters, _ := loadJson[*types.Cluster](clustersJson)
+
+	//empty list
+	w := suite.doRequest(http.MethodGet, consts.V2ClustersPath, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal(`{"items":[],"metadata":{"count":0}}`, w.Body.String())
+
+	//post a single document responds with a list
+	w = suite.doRequest(http.MethodPost, consts.V2ClustersPath, clusters[0])
+	suite.Equal(http.StatusCreated, w.Code)
+	created := decode[handlers.ListResponse[*types.Cluster]](suite, w.Body.Bytes())
+	suite.Equal(1, created.Metadata.Count)
+	suite.Len(created.Items, 1)
+	cluster := created.Items[0]
+	suite.Equal("", cmp.Diff(clusters[0], cluster, newClusterCompareFilter))
+
+	//list and get by GUID
+	w = suite.doRequest(http.MethodGet, consts.V2ClustersPath, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	list := decode[handlers.ListResponse[*types.Cluster]](suite, w.Body.Bytes())
+	suite.Equal(1, list.Metadata.Count)
+	suite.Equal(cluster.GUID, list.Items[0].GUID)
+	testGetDoc(suite, consts.V2ClustersPath+"/"+cluster.GUID, cluster)
+	//same document in v1
+	testGetDoc(suite, consts.ClusterPath+"/"+cluster.GUID, cluster)
+	w = suite.doRequest(http.MethodGet, consts.V2ClustersPath+"?list", nil)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal(`{"items":["`+cluster.Name+`"],"metadata":{"count":1}}`, w.Body.String())
+
+	//put responds with the updated document
+	cluster.Attributes["v2"] = "updated"
+	w = suite.doRequest(http.MethodPut, consts.V2ClustersPath+"/"+cluster.GUID, cluster)
+	suite.Equal(http.StatusOK, w.Code)
+	updated := decode[*types.Cluster](suite, w.Body.Bytes())
+	suite.Equal(cluster.GUID, updated.GUID)
+	suite.Equal("updated", updated.Attributes["v2"])
+
+	//errors include the status code
+	testBadRequest(suite, http.MethodGet, consts.V2ClustersPath+"/no-such-guid", `{"error":"document not found","status":404}`, nil, http.StatusNotFound)
+	testBadRequest(suite, http.MethodPost, consts.V2ClustersPath, `{"error":"name `+cluster.Name+` already exists","status":400}`, clusters[0], http.StatusBadRequest)
+	testBadRequest(suite, http.MethodGet, consts.V2Path+"/no-such-resource", `{"error":"route not found","status":404}`, nil, http.StatusNotFound)
+	suite.authCookie = ""
+	testBadRequest(suite, http.MethodGet, consts.V2ClustersPath, `{"error":"Unauthorized","status":401}`, nil, http.StatusUnauthorized)
+	//v1 errors are not changed
+	suite.login(customerGUID)
+	testBadRequest(suite, http.MethodGet, consts.ClusterPath+"/no-such-guid", errorDocumentNotFound, nil, http.StatusNotFound)
+
+	//query by name responds with a list
+	w = suite.doRequest(http.MethodGet, consts.V2FrameworksPath+"?"+consts.FrameworkNameParam+"=no-such-framework", nil)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal(`{"items":[],"metadata":{"count":0}}`, w.Body.String())
+
+	//delete by name responds with the deleted count
+	policies, _ := loadJson[*types.PostureExceptionPolicy](posturePoliciesJson)
+	w = suite.doRequest(http.MethodPost, consts.V2PostureExceptionPoliciesPath, policies[0])
+	suite.Equal(http.StatusCreated, w.Code)
+	w = suite.doRequest(http.MethodDelete, consts.V2PostureExceptionPoliciesPath+"?"+consts.PolicyNameParam+"="+policies[0].Name, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal(`{"deletedCount":1}`, w.Body.String())
+
+	//delete by GUID responds with the deleted document
+	w = suite.doRequest(http.MethodDelete, consts.V2ClustersPath+"/"+cluster.GUID, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal(cluster.GUID, decode[*types.Cluster](suite, w.Body.Bytes()).GUID)
+}
+
+func (suite *MainTestSuite) TestProblemResponses() {
+	suite.login("problem-customer-guid")
+	clusters, _ := loadJson[*types.Cluster](clustersJson)
+	cluster := testPostDoc(suite, consts.ClusterPath, clusters[0], newClusterCompareFilter)
+
+	//duplicate key with the conflicting values
+	w := suite.doRequest(http.MethodPost, consts.ClusterPath, clusters[0])
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Equal(handlers.ProblemContentType, w.Header().Get("Content-Type"))
+	problem := decode[handlers.Problem](suite, w.Body.Bytes())
+	suite.Equal("urn:config-service:problem:duplicate_key", problem.Type)
+	suite.Equal("Bad Request", problem.Title)
+	suite.Equal(http.StatusBadRequest, problem.Status)
+	suite.Equal(handlers.ErrorCodeDuplicateKey, problem.Code)
+	suite.Equal(map[string]interface{}{"name": []interface{}{cluster.Name}}, problem.Details)
+	suite.Equal(consts.ClusterPath, problem.Instance)
+	suite.Equal("name "+cluster.Name+" already exists", problem.Detail)
+	suite.Equal(problem.Detail, problem.Error, "error field should be kept for compatibility")
+
+	//not admin
+	w = suite.doRequest(http.MethodGet, consts.AdminPath+"/activeCustomers", nil)
+	problem = decode[handlers.Problem](suite, w.Body.Bytes())
+	suite.Equal(handlers.ErrorCodeUnauthorized, problem.Code)
+
+	//missing param
+	w = suite.doRequest(http.MethodPost, consts.ClusterPath, &types.Cluster{})
+	problem = decode[handlers.Problem](suite, w.Body.Bytes())
+	suite.Equal(handlers.ErrorCodeMissingParam, problem.Code)
+	suite.Equal(map[string]interface{}{"param": "name"}, problem.Details)
+
+	//not found
+	w = suite.doRequest(http.MethodGet, consts.ClusterPath+"/no-such-guid", nil)
+	suite.Equal(http.StatusNotFound, w.Code)
+	problem = decode[handlers.Problem](suite, w.Body.Bytes())
+	suite.Equal(handlers.ErrorCodeNotFound, problem.Code)
+	suite.Equal(consts.ClusterPath+"/no-such-guid", problem.Instance)
+}
+
+func (suite *MainTestSuite) TestSchemaValidation() {
+	suite.login("schema-customer-guid")
+	//served schema
+	for _, path := range []string{consts.PostureExceptionPolicyPath, consts.V2PostureExceptionPoliciesPath} {
+		w := suite.doRequest(http.MethodGet, path+"/schema", nil)
+		suite.Equal(http.StatusOK, w.Code)
+		suite.Equal("application/schema+json", w.Header().Get("Content-Type"))
+		schema := decode[handlers.JSONSchema](suite, w.Body.Bytes())
+		suite.Equal("PostureExceptionPolicy", schema.Title)
+		suite.Equal([]interface{}{"postureExceptionPolicy"}, schema.Property("policyType").Enum)
+		suite.Equal("array", schema.Property("posturePolicies").Type)
+	}
+
+	//field path errors
+	w := suite.doRequest(http.MethodPost, consts.PostureExceptionPolicyPath, map[string]interface{}{
+		"name":            "schema-policy",
+		"policyType":      "postureExceptionPolicy",
+		"actions":         []string{"ignore"},
+		"posturePolicies": []interface{}{map[string]interface{}{"frameworkName": 1}},
+	})
+	suite.Equal(http.StatusBadRequest, w.Code)
+	problem := decode[handlers.Problem](suite, w.Body.Bytes())
+	suite.Equal(handlers.ErrorCodeSchemaViolation, problem.Code)
+	suite.Equal(map[string]interface{}{"errors": []interface{}{
+		map[string]interface{}{"path": "actions[0]", "message": `must be one of "alertOnly", "disable"`},
+		map[string]interface{}{"path": "posturePolicies[0].frameworkName", "message": "expected string, got number"},
+	}}, problem.Details)
+
+	//bulk post errors are prefixed with the document index
+	policies, _ := loadJson[*types.PostureExceptionPolicy](posturePoliciesJson)
+	w = suite.doRequest(http.MethodPost, consts.PostureExceptionPolicyPath, []interface{}{policies[0], map[string]interface{}{"name": true}})
+	suite.Equal(http.StatusBadRequest, w.Code)
+	problem = decode[handlers.Problem](suite, w.Body.Bytes())
+	suite.Equal("[1].name: expected string, got boolean", problem.Detail)
+
+	//put is validated
+	policy := testPostDoc(suite, consts.PostureExceptionPolicyPath, policies[0], commonCmpFilter)
+	w = suite.doRequest(http.MethodPut, consts.PostureExceptionPolicyPath+"/"+policy.GUID, map[string]interface{}{"policyType": "other"})
+	suite.Equal(http.StatusBadRequest, w.Code)
+	problem = decode[handlers.Problem](suite, w.Body.Bytes())
+	suite.Equal(`policyType: must be one of "postureExceptionPolicy"`, problem.Detail)
+
+	//unknown fields are ignored when not in strict mode
+	w = suite.doRequest(http.MethodPut, consts.PostureExceptionPolicyPath+"/"+policy.GUID, map[string]interface{}{"name": policy.Name, "posturePolicy": []interface{}{}})
+	suite.Equal(http.StatusOK, w.Code)
+	//unknown fields are rejected in requests with strict=true, e.g. a typo of posturePolicies
+	w = suite.doRequest(http.MethodPut, consts.PostureExceptionPolicyPath+"/"+policy.GUID+"?strict=true", map[string]interface{}{"name": policy.Name, "posturePolicy": []interface{}{}})
+	suite.Equal(http.StatusBadRequest, w.Code)
+	problem = decode[handlers.Problem](suite, w.Body.Bytes())
+	suite.Equal(handlers.ErrorCodeSchemaViolation, problem.Code)
+	suite.Equal(map[string]interface{}{"errors": []interface{}{
+		map[string]interface{}{"path": "posturePolicy", "message": "unknown field"},
+	}}, problem.Details)
+}
+
+func (suite *MainTestSuite) TestYAML() {
+	suite.login("yaml-customer-guid")
+	yamlHeaders := map[string]string{"Content-Type": "application/yaml", "Accept": "application/yaml"}
+
+	//multi document stream is a bulk request
+	stream := []byte(`name: yaml-policy-1
+policyType: postureExceptionPolicy
+actions:
+- alertOnly
+posturePolicies:
+- frameworkName: MITRE
+---
+name: yaml-policy-2
+policyType: postureExceptionPolicy
+actions: [disable]
+`)
+	w := suite.doRawRequest(http.MethodPost, consts.PostureExceptionPolicyPath, stream, yamlHeaders)
+	suite.Equal(http.StatusCreated, w.Code)
+	suite.Equal("application/yaml; charset=utf-8", w.Header().Get("Content-Type"))
+	var created []*types.PostureExceptionPolicy
+	suite.NoError(yaml.Unmarshal(w.Body.Bytes(), &created))
+	suite.Len(created, 2)
+	suite.Equal("yaml-policy-1", created[0].Name)
+	suite.Equal("MITRE", created[0].PosturePolicies[0].FrameworkName)
+	suite.NotEmpty(created[1].GUID)
+
+	//yaml put and json response
+	w = suite.doRawRequest(http.MethodPut, consts.PostureExceptionPolicyPath+"/"+created[1].GUID,
+		[]byte("actions: [alertOnly]\n"), map[string]string{"Content-Type": "application/x-yaml"})
+	suite.Equal(http.StatusOK, w.Code)
+	updated := decodeArray[*types.PostureExceptionPolicy](suite, w.Body.Bytes())
+	suite.Equal(armotypes.AlertOnly, updated[1].Actions[0])
+
+	//yaml get by GUID and list
+	w = suite.doRawRequest(http.MethodGet, consts.PostureExceptionPolicyPath+"/"+created[0].GUID, nil, yamlHeaders)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Contains(w.Body.String(), "name: yaml-policy-1\n")
+	w = suite.doRawRequest(http.MethodGet, consts.V2PostureExceptionPoliciesPath, nil, yamlHeaders)
+	suite.Equal(http.StatusOK, w.Code)
+	var list handlers.ListResponse[*types.PostureExceptionPolicy]
+	suite.NoError(yaml.Unmarshal(w.Body.Bytes(), &list))
+	suite.Equal(2, list.Metadata.Count)
+
+	//errors are problem json
+	w = suite.doRawRequest(http.MethodPost, consts.PostureExceptionPolicyPath, []byte("name: [yaml\n"), yamlHeaders)
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Equal(handlers.ProblemContentType, w.Header().Get("Content-Type"))
+}
+
+func (suite *MainTestSuite) TestNDJSONStream() {
+	suite.login("ndjson-customer-guid")
+	ndjsonHeaders := map[string]string{"Accept": handlers.NDJSONContentType}
+
+	//empty stream
+	w := suite.doRawRequest(http.MethodGet, consts.VulnerabilityExceptionPolicyPath, nil, ndjsonHeaders)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal(handlers.NDJSONContentType, w.Header().Get("Content-Type"))
+	suite.Empty(w.Body.String())
+
+	//more documents than the flush size
+	policies, _ := loadJson[*types.VulnerabilityExceptionPolicy](vulnerabilityPoliciesJson)
+	var bulk []*types.VulnerabilityExceptionPolicy
+	for i := 0; i < 250; i++ {
+		policy := *policies[i%len(policies)]
+		policy.Name = fmt.Sprintf("ndjson-policy-%03d", i)
+		bulk = append(bulk, &policy)
+	}
+	w = suite.doRequest(http.MethodPost, consts.VulnerabilityExceptionPolicyPath, bulk)
+	suite.Equal(http.StatusCreated, w.Code)
+
+	for _, path := range []string{consts.VulnerabilityExceptionPolicyPath, consts.V2VulnerabilityExceptionPoliciesPath} {
+		w = suite.doRawRequest(http.MethodGet, path, nil, ndjsonHeaders)
+		suite.Equal(http.StatusOK, w.Code)
+		suite.Equal(handlers.NDJSONContentType, w.Header().Get("Content-Type"))
+		suite.True(w.Flushed)
+		names := map[string]bool{}
+		scanner := bufio.NewScanner(w.Body)
+		for scanner.Scan() {
+			policy := decode[*types.VulnerabilityExceptionPolicy](suite, scanner.Bytes())
+			names[policy.Name] = true
+		}
+		suite.Len(names, len(bulk))
+		suite.True(names["ndjson-policy-249"])
+	}
+
+	//json is not streamed
+	w = suite.doRequest(http.MethodGet, consts.VulnerabilityExceptionPolicyPath, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Len(decodeArray[*types.VulnerabilityExceptionPolicy](suite, w.Body.Bytes()), len(bulk))
+}
+
+func (suite *MainTestSuite) TestConditionalGet() {
+	suite.login("conditional-customer-guid")
+	clusters, _ := loadJson[*types.Cluster](clustersJson)
+	cluster := testPostDoc(suite, consts.ClusterPath, clusters[0], newClusterCompareFilter)
+
+	//get by GUID
+	path := consts.ClusterPath + "/" + cluster.GUID
+	w := suite.doRequest(http.MethodGet, path, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	lastModified := w.Header().Get("Last-Modified")
+	suite.NotEmpty(etag)
+	suite.NotEmpty(lastModified)
+	w = suite.doRawRequest(http.MethodGet, path, nil, map[string]string{"If-None-Match": etag})
+	suite.Equal(http.StatusNotModified, w.Code)
+	suite.Empty(w.Body.String())
+	suite.Equal(etag, w.Header().Get("ETag"))
+	w = suite.doRawRequest(http.MethodGet, path, nil, map[string]string{"If-Modified-Since": lastModified})
+	suite.Equal(http.StatusNotModified, w.Code)
+	//yaml representation has its own ETag
+	w = suite.doRawRequest(http.MethodGet, path, nil, map[string]string{"If-None-Match": etag, "Accept": "application/yaml"})
+	suite.Equal(http.StatusOK, w.Code)
+	suite.NotEqual(etag, w.Header().Get("ETag"))
+
+	//get all
+	w = suite.doRequest(http.MethodGet, consts.ClusterPath, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	listETag := w.Header().Get("ETag")
+	suite.NotEmpty(listETag)
+	w = suite.doRawRequest(http.MethodGet, consts.ClusterPath, nil, map[string]string{"If-None-Match": listETag})
+	suite.Equal(http.StatusNotModified, w.Code)
+
+	//update changes the ETags
+	cluster.Attributes["test"] = "conditional"
+	w = suite.doRequest(http.MethodPut, consts.ClusterPath, cluster)
+	suite.Equal(http.StatusOK, w.Code)
+	w = suite.doRawRequest(http.MethodGet, path, nil, map[string]string{"If-None-Match": etag})
+	suite.Equal(http.StatusOK, w.Code)
+	suite.NotEqual(etag, w.Header().Get("ETag"))
+	w = suite.doRawRequest(http.MethodGet, consts.ClusterPath, nil, map[string]string{"If-None-Match": listETag})
+	suite.Equal(http.StatusOK, w.Code)
+
+	//merged cluster config ETag covers the customer config layer
+	customerConfig := decode[*types.CustomerConfig](suite, customerConfigJson)
+	w = suite.doRequest(http.MethodPost, consts.CustomerConfigPath, customerConfig)
+	suite.Equal(http.StatusCreated, w.Code)
+	clusterConfig := decode[*types.CustomerConfig](suite, cluster1ConfigJson)
+	clusterConfig.CreationTime = ""
+	w = suite.doRequest(http.MethodPost, consts.CustomerConfigPath, clusterConfig)
+	suite.Equal(http.StatusCreated, w.Code)
+	path = fmt.Sprintf("%s?%s=%s", consts.CustomerConfigPath, consts.ClusterNameParam, clusterConfig.GetName())
+	w = suite.doRequest(http.MethodGet, path, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	etag = w.Header().Get("ETag")
+	w = suite.doRawRequest(http.MethodGet, path, nil, map[string]string{"If-None-Match": etag})
+	suite.Equal(http.StatusNotModified, w.Code)
+	customerConfig.Settings.PostureScanConfig.ScanFrequency = "12h"
+	customerPath := fmt.Sprintf("%s?%s=%s", consts.CustomerConfigPath, consts.ScopeParam, consts.CustomerScope)
+	w = suite.doRequest(http.MethodPut, customerPath, customerConfig)
+	suite.Equal(http.StatusOK, w.Code)
+	w = suite.doRawRequest(http.MethodGet, path, nil, map[string]string{"If-None-Match": etag})
+	suite.Equal(http.StatusOK, w.Code)
+	suite.NotEqual(etag, w.Header().Get("ETag"))
+}
+
+func (suite *MainTestSuite) TestIdempotencyKey() {
+	suite.login("idempotency-customer-guid")
+	clusters, _ := loadJson[*types.Cluster](clustersJson)
+	body, _ := json.Marshal(clusters[0])
+	headers := map[string]string{consts.IdempotencyKeyHeader: "create-cluster-1"}
+
+	//first request creates the cluster
+	w := suite.doRawRequest(http.MethodPost, consts.ClusterPath, body, headers)
+	suite.Equal(http.StatusCreated, w.Code)
+	suite.Empty(w.Header().Get(consts.IdempotentReplayedHeader))
+	created := decode[*types.Cluster](suite, w.Body.Bytes())
+
+	//retry gets the same response
+	w = suite.doRawRequest(http.MethodPost, consts.ClusterPath, body, headers)
+	suite.Equal(http.StatusCreated, w.Code)
+	suite.Equal("true", w.Header().Get(consts.IdempotentReplayedHeader))
+	replayed := decode[*types.Cluster](suite, w.Body.Bytes())
+	suite.Equal(created.GUID, replayed.GUID)
+	suite.Equal(created.Attributes["alias"], replayed.Attributes["alias"])
+	w = suite.doRequest(http.MethodGet, consts.ClusterPath, nil)
+	suite.Len(decodeArray[*types.Cluster](suite, w.Body.Bytes()), 1)
+
+	//same key with a different request
+	w = suite.doRawRequest(http.MethodPost, consts.ClusterPath, []byte(`{"name":"other-cluster"}`), headers)
+	suite.Equal(http.StatusUnprocessableEntity, w.Code)
+	suite.Contains(w.Body.String(), `"code":"idempotency_key_reused"`)
+
+	//keys are per customer
+	suite.login("idempotency-customer-guid-2")
+	w = suite.doRawRequest(http.MethodPost, consts.ClusterPath, body, headers)
+	suite.Equal(http.StatusCreated, w.Code)
+	suite.Empty(w.Header().Get(consts.IdempotentReplayedHeader))
+	suite.NotEqual(created.GUID, decode[*types.Cluster](suite, w.Body.Bytes()).GUID)
+}
+
+func (suite *MainTestSuite) TestBatch() {
+	suite.login("batch-customer-guid")
+	policies, _ := loadJson[*types.PostureExceptionPolicy](posturePoliciesJson)
+	policyBody, _ := json.Marshal(policies[0])
+	request := gin.H{"operations": []gin.H{
+		{"method": http.MethodPost, "path": consts.PostureExceptionPolicyPath, "body": json.RawMessage(policyBody)},
+		{"method": http.MethodPut, "path": consts.PostureExceptionPolicyPath + "/not-exist", "body": json.RawMessage(policyBody)},
+		{"method": http.MethodGet, "path": consts.PostureExceptionPolicyPath + "?list"},
+	}}
+	w := suite.doRequest(http.MethodPost, consts.BatchPath, request)
+	suite.Equal(http.StatusOK, w.Code)
+	var response struct {
+		BatchID          string `json:"batchId"`
+		Committed        bool   `json:"committed"`
+		FailedOperations int    `json:"failedOperations"`
+		Results          []struct {
+			Status int             `json:"status"`
+			Body   json.RawMessage `json:"body"`
+		} `json:"results"`
+	}
+	suite.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	suite.True(response.Committed)
+	suite.Equal(1, response.FailedOperations)
+	suite.Len(response.Results, 3)
+	suite.Equal(http.StatusCreated, response.Results[0].Status)
+	suite.Equal(http.StatusNotFound, response.Results[1].Status)
+	suite.Contains(string(response.Results[1].Body), `"code":"not_found"`)
+	suite.Equal(http.StatusOK, response.Results[2].Status)
+	suite.JSONEq(fmt.Sprintf(`["%s"]`, policies[0].Name), string(response.Results[2].Body))
+	//operations are recorded in the audit log
+	count, err := mongo.GetReadCollection(consts.AuditLogCollection).CountDocuments(context.Background(), bson.D{{Key: "batchId", Value: response.BatchID}})
+	suite.NoError(err)
+	suite.Equal(int64(3), count)
+	count, err = mongo.GetReadCollection(consts.AuditLogCollection).CountDocuments(context.Background(),
+		bson.D{{Key: "batchId", Value: response.BatchID}, {Key: "committed", Value: true}, {Key: "customerGUID", Value: "batch-customer-guid"}})
+	suite.NoError(err)
+	suite.Equal(int64(2), count)
+
+	//atomic batches need a replica set, the test db is a single node
+	request["atomic"] = true
+	w = suite.doRequest(http.MethodPost, consts.BatchPath, request)
+	suite.Equal(http.StatusNotImplemented, w.Code)
+	suite.Contains(w.Body.String(), `"code":"not_supported"`)
+
+	//invalid batches
+	testBadRequest(suite, http.MethodPost, consts.BatchPath, `{"error":"no operations in request"}`, gin.H{"operations": []gin.H{}}, http.StatusBadRequest)
+	nested := gin.H{"operations": []gin.H{{"method": http.MethodPost, "path": consts.BatchPath}}}
+	testBadRequest(suite, http.MethodPost, consts.BatchPath, `{"error":"operations[0]: nested batch requests are not allowed"}`, nested, http.StatusBadRequest)
+	tooMany := []gin.H{}
+	for i := 0; i <= 100; i++ {
+		tooMany = append(tooMany, gin.H{"method": http.MethodGet, "path": consts.ClusterPath})
+	}
+	testBadRequest(suite, http.MethodPost, consts.BatchPath, `{"error":"too many operations, max 100 operations are allowed","code":"bad_request"}`, gin.H{"operations": tooMany}, http.StatusBadRequest)
+}
+
+func (suite *MainTestSuite) TestBatchIdentity() {
+	const (
+		customerGUID = "batch-identity-customer-guid"
+		viewer       = "batch-viewer"
+	)
+	type batchResponse struct {
+		BatchID          string `json:"batchId"`
+		FailedOperations int    `json:"failedOperations"`
+		Results          []struct {
+			Status int             `json:"status"`
+			Body   json.RawMessage `json:"body"`
+		} `json:"results"`
+	}
+	policies, _ := loadJson[*types.PostureExceptionPolicy](posturePoliciesJson)
+	policyBody, _ := json.Marshal(policies[0])
+	request := gin.H{"operations": []gin.H{
+		{"method": http.MethodGet, "path": consts.PostureExceptionPolicyPath},
+		{"method": http.MethodPost, "path": consts.PostureExceptionPolicyPath, "body": json.RawMessage(policyBody)},
+		{"method": http.MethodDelete, "path": consts.PostureExceptionPolicyPath + "/not-existing-guid"},
+	}}
+	suite.login(customerGUID)
+	w := suite.doRequest(http.MethodPut, consts.UserRolesPath+"/"+viewer, map[string]string{"role": "viewer"})
+	suite.Equal(http.StatusOK, w.Code)
+
+	//the batch request is CSRF checked, its operations are not
+	validCSRFToken := suite.csrfToken
+	suite.csrfToken = ""
+	testBadRequest(suite, http.MethodPost, consts.BatchPath, errorInvalidCSRF, request, http.StatusForbidden)
+	suite.csrfToken = validCSRFToken
+
+	//the operations are authorized with the role of the batch request user
+	suite.loginAsUser(customerGUID, viewer)
+	w = suite.doRequest(http.MethodPost, consts.BatchPath, request)
+	suite.Equal(http.StatusOK, w.Code)
+	response := decode[batchResponse](suite, w.Body.Bytes())
+	suite.Equal(2, response.FailedOperations)
+	suite.Equal(http.StatusOK, response.Results[0].Status)
+	for _, result := range response.Results[1:] {
+		suite.Equal(http.StatusForbidden, result.Status)
+		suite.Contains(string(result.Body), "missing permission exceptions:write")
+	}
+	//each operation is recorded in the audit log with the user of the batch request
+	cur, err := mongo.GetReadCollection(consts.AuditLogCollection).Find(context.Background(), bson.D{{Key: "batchId", Value: response.BatchID}})
+	suite.NoError(err)
+	var entries []audit.Entry
+	suite.NoError(cur.All(context.Background(), &entries))
+	suite.Len(entries, len(response.Results))
+	for _, entry := range entries {
+		suite.Equal(customerGUID, entry.CustomerGUID)
+		suite.Equal(viewer, entry.UserID)
+		suite.Equal(response.Results[entry.Operation].Status, entry.Status)
+		suite.Equal(entry.Status == http.StatusOK, entry.Committed)
+	}
+
+	//the operations are rate limited as the batch request and not one by one
+	ratelimit.Init(utils.RateLimitConfig{
+		Customers: map[string]map[string]utils.RateLimit{
+			customerGUID: {strings.TrimPrefix(consts.PostureExceptionPolicyPath, "/") + ":" + ratelimit.ClassRead: {RequestsPerSecond: 0.01, Burst: 1}},
+		},
+	})
+	defer ratelimit.Init(utils.GetConfig().RateLimit)
+	reads := gin.H{"operations": []gin.H{
+		{"method": http.MethodGet, "path": consts.PostureExceptionPolicyPath},
+		{"method": http.MethodGet, "path": consts.PostureExceptionPolicyPath},
+	}}
+	w = suite.doRequest(http.MethodPost, consts.BatchPath, reads)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal(0, decode[batchResponse](suite, w.Body.Bytes()).FailedOperations)
+	suite.Equal(http.StatusOK, suite.doRequest(http.MethodGet, consts.PostureExceptionPolicyPath, nil).Code)
+	suite.Equal(http.StatusTooManyRequests, suite.doRequest(http.MethodGet, consts.PostureExceptionPolicyPath, nil).Code)
+
+	//API keys are not allowed in batch requests
+	suite.login(customerGUID)
+	w = suite.doRequest(http.MethodPost, consts.APIKeysPath, map[string]interface{}{"name": "batch", "scopes": []string{"v1_posture_exception_policy:write"}})
+	suite.Equal(http.StatusCreated, w.Code)
+	key, _ := decode[map[string]interface{}](suite, w.Body.Bytes())["key"].(string)
+	suite.authCookie, suite.csrfToken, suite.apiKey = "", "", key
+	defer func() { suite.apiKey = "" }()
+	testBadRequest(suite, http.MethodPost, consts.BatchPath, `{"error":"API keys are not allowed for this route"}`, reads, http.StatusForbidden)
+}
+
+func (suite *MainTestSuite) TestGraphQL() {
+	suite.login("graphql-customer-guid")
+	for _, name := range []string{"gql-cluster1", "gql-cluster2"} {
+		w := suite.doRequest(http.MethodPost, consts.ClusterPath, &types.Cluster{PortalBase: armotypes.PortalBase{Name: name}})
+		suite.Equal(http.StatusCreated, w.Code)
+	}
+	w := suite.doRequest(http.MethodPost, consts.CustomerConfigPath, &types.CustomerConfig{CustomerConfig: armotypes.CustomerConfig{Name: "gql-cluster1"}})
+	suite.Equal(http.StatusCreated, w.Code)
+	policies, _ := loadJson[*types.PostureExceptionPolicy](posturePoliciesJson)
+	policy := policies[0]
+	policy.Name = "gql-policy"
+	policy.Resources = []armotypes.PortalDesignator{{DesignatorType: armotypes.DesignatorAttributes, Attributes: map[string]string{armotypes.AttributeCluster: "gql-cluster2"}}}
+	w = suite.doRequest(http.MethodPost, consts.PostureExceptionPolicyPath, policy)
+	suite.Equal(http.StatusCreated, w.Code)
+
+	query := gin.H{"query": `query($names: [String!]) {
+		clusters(names: $names) { name customerConfig { name } postureExceptionPolicies { name } }
+	}`, "variables": gin.H{"names": []string{"gql-cluster1", "gql-cluster2"}}}
+	w = suite.doRequest(http.MethodPost, consts.GraphQLPath, query)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.JSONEq(`{"data":{"clusters":[
+		{"name":"gql-cluster1","customerConfig":{"name":"gql-cluster1"},"postureExceptionPolicies":[]},
+		{"name":"gql-cluster2","customerConfig":null,"postureExceptionPolicies":[{"name":"gql-policy"}]}
+	]}}`, w.Body.String())
+
+	//documents of other customers are not returned
+	suite.login("graphql-other-customer-guid")
+	w = suite.doRequest(http.MethodGet, consts.GraphQLPath+"?query="+url.QueryEscape(`{ clusters(name: "gql-cluster1") { name } }`), nil)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.JSONEq(`{"data":{"clusters":[]}}`, w.Body.String())
+
+	//invalid queries
+	w = suite.doRequest(http.MethodPost, consts.GraphQLPath, gin.H{"query": `{ clusters { notAField } }`})
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Contains(w.Body.String(), `Cannot query field \"notAField\" on type \"Cluster\"`)
+	w = suite.doRequest(http.MethodPost, consts.GraphQLPath, gin.H{"query": `mutation { clusters { name } }`})
+	suite.Equal(http.StatusBadRequest, w.Code)
+
+	w = suite.doRequest(http.MethodGet, consts.GraphQLSchemaPath, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Contains(w.Body.String(), "postureExceptionPolicies: [PostureExceptionPolicy!]")
+
+	//each queried document type is authorized like its routes
+	suite.login("graphql-customer-guid")
+	w = suite.doRequest(http.MethodPut, consts.UserRolesPath+"/graphql-viewer", map[string]string{"role": "viewer"})
+	suite.Equal(http.StatusOK, w.Code)
+	w = suite.doRequest(http.MethodPost, consts.APIKeysPath, map[string]interface{}{"name": "graphql", "scopes": []string{"cluster:read"}})
+	suite.Equal(http.StatusCreated, w.Code)
+	key, _ := decode[map[string]interface{}](suite, w.Body.Bytes())["key"].(string)
+	query = gin.H{"query": `{ clusters(name: "gql-cluster1") { name } frameworks { name } }`}
+	suite.authCookie, suite.csrfToken, suite.apiKey = "", "", key
+	w = suite.doRequest(http.MethodPost, consts.GraphQLPath, query)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.JSONEq(`{"data":{"clusters":[{"name":"gql-cluster1"}],"frameworks":null},
+		"errors":[{"message":"failed to query frameworks: API key is missing scope v1_opa_framework:read","path":["frameworks"]}]}`, w.Body.String())
+	suite.apiKey = ""
+	suite.loginAsUser("graphql-customer-guid", "graphql-viewer")
+	w = suite.doRequest(http.MethodPost, consts.GraphQLPath, gin.H{"query": `{ webhookSubscriptions { name } }`})
+	suite.Equal(http.StatusOK, w.Code)
+	suite.JSONEq(`{"data":{"webhookSubscriptions":null},
+		"errors":[{"message":"failed to query webhookSubscriptions: missing permission webhooks:manage","path":["webhookSubscriptions"]}]}`, w.Body.String())
+}
+
+func (suite *MainTestSuite) TestGRPC() {
+	suite.login("grpc-customer-guid")
+	clusterConfig := &types.CustomerConfig{CustomerConfig: armotypes.CustomerConfig{Name: "grpc-cluster1"}}
+	clusterConfig.Settings.PostureScanConfig.ScanFrequency = "1h"
+	w := suite.doRequest(http.MethodPost, consts.CustomerConfigPath, clusterConfig)
+	suite.Equal(http.StatusCreated, w.Code)
+	policies, _ := loadJson[*types.PostureExceptionPolicy](posturePoliciesJson)
+	policy := policies[0]
+	policy.Name = "grpc-policy"
+	policy.PosturePolicies = nil
+	policy.Resources = []armotypes.PortalDesignator{{DesignatorType: armotypes.DesignatorAttributes,
+		Attributes: map[string]string{armotypes.AttributeCluster: "grpc-cluster1", armotypes.AttributeNamespace: "kube-system"}}}
+	w = suite.doRequest(http.MethodPost, consts.PostureExceptionPolicyPath, policy)
+	suite.Equal(http.StatusCreated, w.Code)
+	w = suite.doRequest(http.MethodPost, consts.APIKeysPath, map[string]interface{}{"name": "grpc",
+		"scopes": []string{"v1_customer_configuration:read", "v1_posture_exception_policy:read"}})
+	suite.Equal(http.StatusCreated, w.Code)
+	apiKey, _ := decode[map[string]interface{}](suite, w.Body.Bytes())["key"].(string)
+
+	//gRPC server on an in memory listener
+	server, err := grpcserver.NewServer(utils.Configuration{GRPC: utils.GRPCConfig{WatchIntervalSeconds: 1}})
+	suite.NoError(err)
+	listener := bufconn.Listen(1024 * 1024)
+	go server.Serve(listener)
+	defer server.Stop()
+	conn, err := grpc.Dial("bufnet", grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	suite.NoError(err)
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", apiKey)
+	decodeConfig := func(doc *configservicev1.Document) *types.CustomerConfig {
+		data, err := protojson.Marshal(doc.Content)
+		suite.NoError(err)
+		return decode[*types.CustomerConfig](suite, data)
+	}
+
+	//cluster config merged with the default config
+	configClient := configservicev1.NewConfigServiceClient(conn)
+	doc, err := configClient.GetClusterConfig(ctx, &configservicev1.GetClusterConfigRequest{ClusterName: "grpc-cluster1"})
+	suite.NoError(err)
+	suite.Equal("grpc-cluster1", doc.Name)
+	config := decodeConfig(doc)
+	suite.Equal(armotypes.ScanFrequency("1h"), config.Settings.PostureScanConfig.ScanFrequency)
+	suite.Equal(armotypes.ScanFrequency("120h"), config.Settings.VulnerabilityScanConfig.ScanFrequency)
+
+	//watch sends the updated config
+	watchStream, err := configClient.WatchClusterConfig(ctx, &configservicev1.GetClusterConfigRequest{ClusterName: "grpc-cluster1"})
+	suite.NoError(err)
+	doc, err = watchStream.Recv()
+	suite.NoError(err)
+	suite.Equal(armotypes.ScanFrequency("1h"), decodeConfig(doc).Settings.PostureScanConfig.ScanFrequency)
+	clusterConfig.Settings.PostureScanConfig.ScanFrequency = "2h"
+	w = suite.doRequest(http.MethodPut, consts.CustomerConfigPath, clusterConfig)
+	suite.Equal(http.StatusOK, w.Code)
+	doc, err = watchStream.Recv()
+	suite.NoError(err)
+	suite.Equal(armotypes.ScanFrequency("2h"), decodeConfig(doc).Settings.PostureScanConfig.ScanFrequency)
+
+	//exception policies
+	exceptionsClient := configservicev1.NewExceptionPolicyServiceClient(conn)
+	list, err := exceptionsClient.ListPostureExceptionPolicies(ctx, &configservicev1.ListExceptionPoliciesRequest{ClusterName: "grpc-cluster1"})
+	suite.NoError(err)
+	suite.Len(list.Documents, 1)
+	suite.Equal("grpc-policy", list.Documents[0].Name)
+	resource, _ := structpb.NewStruct(map[string]interface{}{"apiVersion": "v1", "kind": "Pod",
+		"metadata": map[string]interface{}{"name": "coredns", "namespace": "kube-system"}})
+	matched, err := exceptionsClient.MatchPostureExceptionPolicies(ctx, &configservicev1.MatchPostureExceptionPoliciesRequest{
+		ClusterName: "grpc-cluster1", FrameworkName: "NSA", ControlId: "C-0001", Resource: resource})
+	suite.NoError(err)
+	suite.Len(matched.Documents, 1)
+	matched, err = exceptionsClient.MatchPostureExceptionPolicies(ctx, &configservicev1.MatchPostureExceptionPoliciesRequest{
+		ClusterName: "grpc-cluster2", FrameworkName: "NSA", ControlId: "C-0001", Resource: resource})
+	suite.NoError(err)
+	suite.Empty(matched.Documents)
+
+	//API key scopes are checked
+	_, err = configservicev1.NewFrameworkServiceClient(conn).ListFrameworks(ctx, &configservicev1.ListFrameworksRequest{})
+	suite.Equal(codes.PermissionDenied, status.Code(err))
+	_, err = configClient.GetClusterConfig(context.Background(), &configservicev1.GetClusterConfigRequest{ClusterName: "grpc-cluster1"})
+	suite.Equal(codes.Unauthenticated, status.Code(err))
+}
+
+func (suite *MainTestSuite) TestWebhooks() {
+	suite.login("webhooks-customer-guid")
+	//local receivers of the deliveries
+	received := make(chan *http.Request, 10)
+	receivedBodies := make(chan []byte, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		receivedBodies <- body
+	}))
+	defer receiver.Close()
+	failingReceiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failingReceiver.Close()
+
+	secret := "secret-of-the-subscription"
+	w := suite.doRequest(http.MethodPost, consts.WebhookPath, gin.H{"name": "clusters", "url": receiver.URL,
+		"collections": []string{consts.ClustersCollection}, "secret": secret})
+	suite.Equal(http.StatusCreated, w.Code)
+	suite.NotContains(w.Body.String(), secret, "secret is not returned")
+	subscription := decode[*types.WebhookSubscription](suite, w.Body.Bytes())
+	w = suite.doRequest(http.MethodPost, consts.WebhookPath, gin.H{"name": "failing", "url": failingReceiver.URL,
+		"collections": []string{consts.ClustersCollection}, "eventTypes": []string{"created"}, "secret": secret})
+	suite.Equal(http.StatusCreated, w.Code)
+	failingSubscription := decode[*types.WebhookSubscription](suite, w.Body.Bytes())
+	w = suite.doRequest(http.MethodGet, consts.WebhookPath+"/"+subscription.GUID, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Contains(w.Body.String(), `"secret":null`)
+
+	//invalid subscriptions
+	testBadRequest(suite, http.MethodPost, consts.WebhookPath, `{"error":"url must be an absolute http or https URL"}`,
+		gin.H{"name": "ftp", "url": "ftp://hooks.example.com", "collections": []string{consts.ClustersCollection}, "secret": secret}, http.StatusBadRequest)
+	w = suite.doRequest(http.MethodPost, consts.WebhookPath, gin.H{"name": "webhooks", "url": receiver.URL,
+		"collections": []string{consts.WebhookCollection}, "secret": secret})
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Contains(w.Body.String(), "collection v1_webhooks cannot be subscribed")
+	testBadRequest(suite, http.MethodPost, consts.WebhookPath, `{"error":"secret must have at least 16 characters"}`,
+		gin.H{"name": "short", "url": receiver.URL, "collections": []string{consts.ClustersCollection}, "secret": "short"}, http.StatusBadRequest)
+
+	receive := func() (*http.Request, map[string]interface{}, []byte) {
+		select {
+		case r := <-received:
+			body := <-receivedBodies
+			return r, decode[map[string]interface{}](suite, body), body
+		case <-time.After(10 * time.Second):
+			suite.FailNow("delivery not received")
+		}
+		return nil, nil, nil
+	}
+	//created and deleted events
+	w = suite.doRequest(http.MethodPost, consts.ClusterPath, &types.Cluster{PortalBase: armotypes.PortalBase{Name: "webhook-cluster"}})
+	suite.Equal(http.StatusCreated, w.Code)
+	cluster := decode[*types.Cluster](suite, w.Body.Bytes())
+	r, event, body := receive()
+	suite.Equal("created", r.Header.Get(consts.WebhookEventHeader))
+	suite.Equal(webhooks.Sign(types.Secret(secret), r.Header.Get(consts.WebhookTimestampHeader), body), r.Header.Get(consts.WebhookSignatureHeader))
+	suite.Equal("created", event["type"])
+	suite.Equal(consts.ClustersCollection, event["collection"])
+	suite.Equal("webhooks-customer-guid", event["customerGUID"])
+	suite.Equal("webhook-cluster", event["documents"].([]interface{})[0].(map[string]interface{})["name"])
+	w = suite.doRequest(http.MethodDelete, consts.ClusterPath+"/"+cluster.GUID, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	r, event, _ = receive()
+	suite.Equal("deleted", r.Header.Get(consts.WebhookEventHeader))
+	suite.Equal(cluster.GUID, event["documents"].([]interface{})[0].(map[string]interface{})["guid"])
+
+	//delivery log
+	type deliveryLog []struct {
+		ID       string                 `json:"id"`
+		Status   string                 `json:"status"`
+		Payload  map[string]interface{} `json:"payload"`
+		Attempts []map[string]interface{}
+	}
+	suite.NoError(retry(20, 500*time.Millisecond, func() error {
+		w = suite.doRequest(http.MethodGet, consts.WebhookPath+"/deliveries?status=delivered&subscriptionGUID="+subscription.GUID, nil)
+		if deliveries := decode[deliveryLog](suite, w.Body.Bytes()); len(deliveries) != 2 {
+			return fmt.Errorf("expected 2 delivered deliveries, got %d", len(deliveries))
+		}
+		return nil
+	}))
+	deliveries := decode[deliveryLog](suite, w.Body.Bytes())
+	suite.Equal("deleted", deliveries[0].Payload["type"], "latest first")
+	suite.Len(deliveries[0].Attempts, 1)
+
+	//failed deliveries are retried and moved to the dead letters after max attempts (2 in the test config)
+	suite.NoError(retry(20, 500*time.Millisecond, func() error {
+		w = suite.doRequest(http.MethodGet, consts.WebhookPath+"/deliveries?status=dead&subscriptionGUID="+failingSubscription.GUID, nil)
+		if deliveries := decode[deliveryLog](suite, w.Body.Bytes()); len(deliveries) != 1 {
+			return fmt.Errorf("expected 1 dead delivery, got %d", len(deliveries))
+		}
+		return nil
+	}))
+	deadLetters := decode[deliveryLog](suite, w.Body.Bytes())
+	suite.Len(deadLetters[0].Attempts, 2)
+	suite.Equal("created", deadLetters[0].Payload["type"], "only created events are subscribed")
+	suite.Equal(float64(http.StatusInternalServerError), deadLetters[0].Attempts[0]["statusCode"])
+	w = suite.doRequest(http.MethodPost, consts.WebhookPath+"/deliveries/"+deadLetters[0].ID+"/redeliver", nil)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal("pending", decode[map[string]interface{}](suite, w.Body.Bytes())["status"])
+	w = suite.doRequest(http.MethodPost, consts.WebhookPath+"/deliveries/"+deadLetters[0].ID+"/redeliver", nil)
+	suite.Equal(http.StatusNotFound, w.Code, "only dead deliveries are redelivered")
+
+	//deliveries are of the customer subscriptions
+	suite.login("other-webhooks-customer-guid")
+	w = suite.doRequest(http.MethodGet, consts.WebhookPath+"/deliveries", nil)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.JSONEq("[]", w.Body.String())
+}
+
+func (suite *MainTestSuite) TestClient() {
+	server := httptest.NewServer(suite.router)
+	defer server.Close()
+	ctx := context.Background()
+	c, err := client.New(server.URL, client.WithRetries(2, 10*time.Millisecond, time.Second))
+	suite.NoError(err)
+	suite.NoError(c.Login(ctx, "client-customer-guid", ""))
+
+	//clusters CRUD
+	clusters := c.Clusters()
+	docs, err := clusters.List(ctx)
+	suite.NoError(err)
+	suite.Empty(docs, "no clusters is an empty list")
+	created, err := clusters.Create(ctx, &types.Cluster{PortalBase: armotypes.PortalBase{Name: "client-cluster1"}})
+	suite.NoError(err)
+	suite.Len(created, 1)
+	suite.NotEmpty(created[0].GUID)
+	_, err = clusters.Create(ctx, &types.Cluster{PortalBase: armotypes.PortalBase{Name: "client-cluster1"}})
+	suite.True(client.IsConflict(err), "duplicate name")
+	bulk, err := clusters.Create(ctx, &types.Cluster{PortalBase: armotypes.PortalBase{Name: "client-cluster2"}},
+		&types.Cluster{PortalBase: armotypes.PortalBase{Name: "client-cluster3"}})
+	suite.NoError(err)
+	suite.Len(bulk, 2)
+	names, err := clusters.Names(ctx)
+	suite.NoError(err)
+	suite.ElementsMatch([]string{"client-cluster1", "client-cluster2", "client-cluster3"}, names)
+	cluster := created[0]
+	cluster.Attributes = map[string]interface{}{"alias": "CLIENT"}
+	updated, err := clusters.Update(ctx, cluster)
+	suite.NoError(err)
+	suite.Equal("CLIENT", updated.Attributes["alias"])
+	got, err := clusters.Get(ctx, cluster.GUID)
+	suite.NoError(err)
+	suite.Equal("CLIENT", got.Attributes["alias"])
+	deleted, err := clusters.Delete(ctx, cluster.GUID)
+	suite.NoError(err)
+	suite.Equal(cluster.GUID, deleted.GUID)
+	_, err = clusters.Get(ctx, cluster.GUID)
+	suite.True(client.IsNotFound(err))
+
+	//posture exception policies scope query, get and delete by name
+	policies := c.PostureExceptionPolicies()
+	newPolicy := func(name, cluster string) *types.PostureExceptionPolicy {
+		policy := &types.PostureExceptionPolicy{}
+		policy.Name = name
+		policy.PolicyType = "postureExceptionPolicy"
+		policy.Actions = []armotypes.PostureExceptionPolicyActions{armotypes.AlertOnly}
+		policy.Resources = []armotypes.PortalDesignator{{DesignatorType: armotypes.DesignatorAttributes, Attributes: map[string]string{"cluster": cluster}}}
+		policy.PosturePolicies = []armotypes.PosturePolicy{{FrameworkName: "NSA"}}
+		return policy
+	}
+	_, err = policies.Create(ctx, newPolicy("client-policy1", "client-cluster2"), newPolicy("client-policy2", "client-cluster3"), newPolicy("client-policy3", "client-cluster3"))
+	suite.NoError(err)
+	found, err := policies.Query(ctx, client.NewQuery().Scope("cluster", "client-cluster3"))
+	suite.NoError(err)
+	suite.Len(found, 2)
+	found, err = policies.Query(ctx, client.NewQuery().Scope("cluster", "client-cluster2", "client-cluster3").Field("posturePolicies", "frameworkName", "NSA"))
+	suite.NoError(err)
+	suite.Len(found, 3, "values of a param are ORed")
+	found, err = policies.Query(ctx, client.NewQuery().Scope("cluster", "no-such-cluster"))
+	suite.NoError(err)
+	suite.Empty(found)
+	policy, err := policies.GetByName(ctx, "client-policy1")
+	suite.NoError(err)
+	suite.Equal("client-cluster2", policy.Resources[0].Attributes["cluster"])
+	count, err := policies.DeleteByName(ctx, "client-policy2", "client-policy3")
+	suite.NoError(err)
+	suite.Equal(2, count)
+	count, err = policies.DeleteByName(ctx, "client-policy1")
+	suite.NoError(err)
+	suite.Equal(1, count)
+
+	//merged customer configurations
+	compareFilter := cmp.FilterPath(func(p cmp.Path) bool {
+		return p.String() == "CreationTime" || p.String() == "GUID" || p.String() == "UpdatedTime" || p.String() == "PortalBase.UpdatedTime"
+	}, cmp.Ignore())
+	defaultConfig, err := c.DefaultConfig(ctx)
+	suite.NoError(err)
+	clusterConfig, err := c.ClusterConfig(ctx, "test-cluster1")
+	suite.NoError(err)
+	suite.Equal("", cmp.Diff(defaultConfig, clusterConfig, compareFilter), "no customer and cluster configurations")
+	cluster1Config := decode[*types.CustomerConfig](suite, cluster1ConfigJson)
+	cluster1Config.CreationTime = ""
+	_, err = c.CustomerConfigs().Create(ctx, decode[*types.CustomerConfig](suite, customerConfigJson), cluster1Config)
+	suite.NoError(err)
+	clusterConfig, err = c.ClusterConfig(ctx, "test-cluster1")
+	suite.NoError(err)
+	suite.Equal("", cmp.Diff(decode[*types.CustomerConfig](suite, cluster1ConfigMergedJson), clusterConfig, compareFilter))
+	customerConfig, err := c.CustomerConfig(ctx)
+	suite.NoError(err)
+	suite.Equal("", cmp.Diff(decode[*types.CustomerConfig](suite, customerConfigMergedJson), customerConfig, compareFilter))
+	unmerged, err := c.UnmergedConfig(ctx, "test-cluster1")
+	suite.NoError(err)
+	suite.Equal("", cmp.Diff(cluster1Config, unmerged, compareFilter))
+	deletedConfig, err := c.DeleteConfig(ctx, "test-cluster1")
+	suite.NoError(err)
+	suite.Equal("test-cluster1", deletedConfig.Name)
+
+	//API key authentication
+	apiKeyClient, err := client.New(server.URL, client.WithAPIKey("csk_not-a-key"))
+	suite.NoError(err)
+	_, err = apiKeyClient.Clusters().List(ctx)
+	var clientErr *client.Error
+	suite.ErrorAs(err, &clientErr)
+	suite.Equal(http.StatusUnauthorized, clientErr.StatusCode)
+	suite.NoError(c.Logout(ctx))
+}
+
+func (suite *MainTestSuite) TestSync() {
+	suite.login("sync-customer-guid")
+	//a cluster created in the UI
+	w := suite.doRequest(http.MethodPost, consts.ClusterPath, &types.Cluster{PortalBase: armotypes.PortalBase{Name: "ui-cluster"}})
+	suite.Equal(http.StatusCreated, w.Code)
+	syncClusters := func(query string, expectedSummary handlers.SyncSummary, clusters ...*types.Cluster) handlers.SyncResponse {
+		if clusters == nil {
+			clusters = []*types.Cluster{}
+		}
+		w := suite.doRequest(http.MethodPut, consts.ClusterPath+"/sync"+query, clusters)
+		suite.Equal(http.StatusOK, w.Code)
+		response := decode[handlers.SyncResponse](suite, w.Body.Bytes())
+		suite.Equal(expectedSummary, response.Summary)
+		suite.Equal("name", response.Key)
+		suite.False(response.Atomic, "the test db is a single node without transactions")
+		return response
+	}
+	clusterNames := func() []string {
+		w := suite.doRequest(http.MethodGet, consts.ClusterPath+"?list", nil)
+		suite.Equal(http.StatusOK, w.Code)
+		return decodeArray[string](suite, w.Body.Bytes())
+	}
+	newCluster := func(name, env string) *types.Cluster {
+		return &types.Cluster{PortalBase: armotypes.PortalBase{Name: name, Attributes: map[string]interface{}{"env": env}}}
+	}
+
+	//dry run responds with the plan without applying it
+	response := syncClusters("?dryRun=true", handlers.SyncSummary{Created: 2}, newCluster("sync-cluster1", "dev"), newCluster("sync-cluster2", "dev"))
+	suite.True(response.DryRun)
+	suite.Equal(handlers.DefaultManager, response.ManagedBy)
+	suite.ElementsMatch([]string{"ui-cluster"}, clusterNames())
+
+	//created documents are marked with the manager
+	syncClusters("", handlers.SyncSummary{Created: 2}, newCluster("sync-cluster1", "dev"), newCluster("sync-cluster2", "dev"))
+	suite.ElementsMatch([]string{"ui-cluster", "sync-cluster1", "sync-cluster2"}, clusterNames())
+	w = suite.doRequest(http.MethodGet, consts.ClusterPath, nil)
+	clusters := decodeArray[*types.Cluster](suite, w.Body.Bytes())
+	for _, cluster := range clusters {
+		if cluster.Name != "ui-cluster" {
+			suite.Equal(handlers.DefaultManager, cluster.Attributes[consts.ManagedByAttribute])
+			suite.NotEmpty(cluster.Attributes[consts.ShortNameAttribute], "synced clusters get an alias as posted clusters")
+		} else {
+			suite.Nil(cluster.Attributes[consts.ManagedByAttribute])
+		}
+	}
+
+	//changed documents are updated, documents missing in the desired set are deleted and documents of the UI are skipped
+	response = syncClusters("", handlers.SyncSummary{Updated: 1, Deleted: 1, Skipped: 1}, newCluster("sync-cluster1", "prod"), newCluster("ui-cluster", "prod"))
+	suite.Equal(handlers.SyncActionUpdate, response.Changes[0].Action)
+	suite.Equal([]string{consts.AttributesField}, response.Changes[0].Fields)
+	suite.Equal(handlers.SyncChange{Action: handlers.SyncActionSkip, Key: "ui-cluster", GUID: response.Changes[1].GUID, Reason: "the document is not managed by the sync"}, response.Changes[1])
+	suite.Equal(handlers.SyncActionDelete, response.Changes[2].Action)
+	suite.Equal("sync-cluster2", response.Changes[2].Key)
+	suite.ElementsMatch([]string{"ui-cluster", "sync-cluster1"}, clusterNames())
+	w = suite.doRequest(http.MethodGet, consts.ClusterPath+"/"+response.Changes[0].GUID, nil)
+	cluster := decode[*types.Cluster](suite, w.Body.Bytes())
+	suite.Equal("prod", cluster.Attributes["env"])
+	suite.NotEmpty(cluster.Attributes[consts.ShortNameAttribute], "the alias is kept in updates")
+
+	//fields that are not in the desired document are removed
+	withLoginDate := newCluster("sync-cluster1", "prod")
+	withLoginDate.LastLoginDate = "2023-02-01T00:00:00Z"
+	response = syncClusters("", handlers.SyncSummary{Updated: 1, Skipped: 1}, withLoginDate, newCluster("ui-cluster", "prod"))
+	suite.Equal([]string{"last_login_date"}, response.Changes[0].Fields)
+	w = suite.doRequest(http.MethodGet, consts.ClusterPath+"/"+response.Changes[0].GUID, nil)
+	suite.Equal("2023-02-01T00:00:00Z", decode[*types.Cluster](suite, w.Body.Bytes()).LastLoginDate)
+	response = syncClusters("", handlers.SyncSummary{Updated: 1, Skipped: 1}, newCluster("sync-cluster1", "prod"), newCluster("ui-cluster", "prod"))
+	suite.Equal([]string{"last_login_date"}, response.Changes[0].Fields)
+	w = suite.doRequest(http.MethodGet, consts.ClusterPath+"/"+response.Changes[0].GUID, nil)
+	cluster = decode[*types.Cluster](suite, w.Body.Bytes())
+	suite.Empty(cluster.LastLoginDate, "the removed field is unset")
+	suite.NotEmpty(cluster.SubscriptionDate, "read only fields are kept")
+
+	//the same desired set has no changes
+	syncClusters("", handlers.SyncSummary{Unchanged: 1, Skipped: 1}, newCluster("sync-cluster1", "prod"), newCluster("ui-cluster", "prod"))
+	//adopt takes over the documents of the UI
+	syncClusters("?adopt=true", handlers.SyncSummary{Updated: 1, Unchanged: 1}, newCluster("sync-cluster1", "prod"), newCluster("ui-cluster", "prod"))
+	//other managers do not delete the documents
+	syncClusters("?managedBy=gitops", handlers.SyncSummary{})
+	suite.ElementsMatch([]string{"ui-cluster", "sync-cluster1"}, clusterNames())
+	syncClusters("", handlers.SyncSummary{Deleted: 2})
+	w = suite.doRequest(http.MethodGet, consts.ClusterPath+"?list", nil)
+	suite.Equal(http.StatusNotFound, w.Code)
+
+	//invalid requests
+	testBadRequest(suite, http.MethodPut, consts.ClusterPath+"/sync", `{"error":"name sync-cluster1 already exists","code":"duplicate_key"}`,
+		[]*types.Cluster{newCluster("sync-cluster1", "dev"), newCluster("sync-cluster1", "prod")}, http.StatusBadRequest)
+	testBadRequest(suite, http.MethodPut, consts.ClusterPath+"/sync?dryRun=maybe", `{"error":"dryRun must be true or false"}`, []*types.Cluster{}, http.StatusBadRequest)
+	w = suite.doRequest(http.MethodPut, consts.ClusterPath+"/sync", newCluster("sync-cluster1", "dev"))
+	suite.Equal(http.StatusBadRequest, w.Code, "the desired set is an array")
+}
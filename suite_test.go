@@ -9,9 +9,11 @@ import (
 	_ "embed"
 	"fmt"
 	"sort"
+	"strings"
 
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"os/exec"
 	"testing"
 	"time"
@@ -33,11 +35,20 @@ const (
 	mongoDockerCommand = `docker run --name=mongo -d -p 27017:27017 -e  "MONGO_INITDB_ROOT_USERNAME=admin" -e "MONGO_INITDB_ROOT_PASSWORD=admin" mongo`
 	mongoStopCommand   = "docker stop mongo && docker rm mongo"
 	defaultUserGUID    = "test-customer-guid"
+	defaultUserID      = "test-user"
 )
 
 //go:embed test_data/customer_config/defaultConfig.json
 var defaultCustomerConfigJson []byte
 
+func TestMain(m *testing.M) {
+	//the service is tested in development mode with login sessions and with the admins of the test configuration
+	if os.Getenv("CONFIG_PATH") == "" {
+		os.Setenv("CONFIG_PATH", "test_data/config.json")
+	}
+	os.Exit(m.Run())
+}
+
 func TestConfigServiceWithMongoImage(t *testing.T) {
 	suite.Run(t, new(MainTestSuite))
 }
@@ -48,6 +59,8 @@ type MainTestSuite struct {
 	shutdownFunc     func()
 	authCookie       string
 	authCustomerGUID string
+	apiKey           string
+	csrfToken        string
 }
 
 func (suite *MainTestSuite) SetupSuite() {
@@ -84,39 +97,48 @@ func (suite *MainTestSuite) SetupSuite() {
 
 func (suite *MainTestSuite) SetupTest() {
 	//login with default user
+	suite.apiKey = ""
+	suite.csrfToken = ""
 	suite.login(defaultUserGUID)
 }
 
+// login logins as the default user of the customer, users without a stored role get the default role of the test configuration
 func (suite *MainTestSuite) login(customerGUID string) {
+	suite.loginAsUser(customerGUID, defaultUserID)
+}
+
+func (suite *MainTestSuite) loginAsUser(customerGUID, userID string) {
 	loginDetails := struct {
 		CustomerGUID string `json:"customerGUID"`
+		UserID       string `json:"userId"`
 	}{
 		CustomerGUID: customerGUID,
+		UserID:       userID,
 	}
 	w := suite.doRequest(http.MethodPost, "/login", loginDetails)
 	if w.Code != http.StatusOK {
 		suite.FailNow("failed to login")
 	}
-	suite.authCookie = w.Header().Get("Set-Cookie")
+	suite.setSessionCookies(w)
 	suite.authCustomerGUID = customerGUID
 }
 
-func (suite *MainTestSuite) loginAsAdmin(customerGUID string) {
-	loginDetails := struct {
-		CustomerGUID string                 `json:"customerGUID"`
-		Attributes   map[string]interface{} `json:"attributes"`
-	}{
-		CustomerGUID: customerGUID,
-		Attributes: map[string]interface{}{
-			"admin": true,
-		},
-	}
-	w := suite.doRequest(http.MethodPost, "/login", loginDetails)
-	if w.Code != http.StatusOK {
-		suite.FailNow("failed to login")
+// setSessionCookies authenticates the next requests with the session and CSRF cookies of the response and sends the CSRF token in state changing requests
+func (suite *MainTestSuite) setSessionCookies(w *httptest.ResponseRecorder) {
+	cookies := []string{}
+	suite.csrfToken = ""
+	for _, cookie := range w.Result().Cookies() {
+		cookies = append(cookies, cookie.Name+"="+cookie.Value)
+		if cookie.Name == consts.CSRFCookie {
+			suite.csrfToken = cookie.Value
+		}
 	}
-	suite.authCookie = w.Header().Get("Set-Cookie")
-	suite.authCustomerGUID = customerGUID
+	suite.authCookie = strings.Join(cookies, "; ")
+}
+
+// loginAsAdmin logins as a customer of the admins list of the test configuration
+func (suite *MainTestSuite) loginAsAdmin(customerGUID string) {
+	suite.login(customerGUID)
 }
 
 func (suite *MainTestSuite) TearDownSuite() {
@@ -125,16 +147,23 @@ func (suite *MainTestSuite) TearDownSuite() {
 }
 
 func (suite *MainTestSuite) doRequest(method, path string, body interface{}) *httptest.ResponseRecorder {
+	var bodyBytes []byte
+	if body != nil {
+		var err error
+		if bodyBytes, err = json.Marshal(body); err != nil {
+			suite.FailNow("failed to marshal body", err.Error())
+		}
+	}
+	return suite.doRawRequest(method, path, bodyBytes, nil)
+}
+
+// doRawRequest sends the body as is with the given headers (e.g. Content-Type and Accept)
+func (suite *MainTestSuite) doRawRequest(method, path string, body []byte, headers map[string]string) *httptest.ResponseRecorder {
 	w := httptest.NewRecorder()
 	var req *http.Request
 	var reqErr error
 	if body != nil {
-		bodyBytes, err := json.Marshal(body)
-		if err != nil {
-			suite.FailNow("failed to marshal body", err.Error())
-		}
-		bodyReader := bytes.NewReader(bodyBytes)
-		req, reqErr = http.NewRequest(method, path, bodyReader)
+		req, reqErr = http.NewRequest(method, path, bytes.NewReader(body))
 	} else {
 		req, reqErr = http.NewRequest(method, path, nil)
 	}
@@ -145,6 +174,15 @@ func (suite *MainTestSuite) doRequest(method, path string, body interface{}) *ht
 	if suite.authCookie != "" {
 		req.Header.Set("Cookie", suite.authCookie)
 	}
+	if suite.apiKey != "" {
+		req.Header.Set(consts.APIKeyHeader, suite.apiKey)
+	}
+	if suite.csrfToken != "" {
+		req.Header.Set(consts.CSRFHeader, suite.csrfToken)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
 	suite.router.ServeHTTP(w, req)
 
 	return w
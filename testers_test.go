@@ -1,6 +1,7 @@
 package main
 
 import (
+	"config-service/handlers"
 	"config-service/types"
 	"encoding/json"
 	"fmt"
@@ -205,6 +206,9 @@ const (
 	errorGUIDExists       = `{"error":"guid already exists"}`
 	errorDocumentNotFound = `{"error":"document not found"}`
 	errorNotAdminUser     = `{"error":"Unauthorized - not an admin user"}`
+	errorUnauthorized     = `{"error":"Unauthorized"}`
+	errorLoginDisabled    = `{"error":"login sessions are disabled"}`
+	errorInvalidCSRF      = `{"error":"invalid CSRF token"}`
 )
 
 func errorBadTimeParam(paramName string) string {
@@ -236,10 +240,16 @@ func errorNameExist(name ...string) string {
 	return `{"error":"` + msg + `"}`
 }
 
+// testBadRequest checks the response status and that the problem response includes the fields of the expected response
 func testBadRequest(suite *MainTestSuite, method, path, expectedResponse string, body interface{}, expectedCode int) {
 	w := suite.doRequest(method, path, body)
 	suite.Equal(expectedCode, w.Code)
-	suite.Equal(expectedResponse, w.Body.String())
+	suite.Equal(handlers.ProblemContentType, w.Header().Get("Content-Type"))
+	expected := decode[map[string]interface{}](suite, []byte(expectedResponse))
+	actual := decode[map[string]interface{}](suite, w.Body.Bytes())
+	for key, value := range expected {
+		suite.Equal(value, actual[key], "response field %s", key)
+	}
 }
 
 // //////////////////////////////////////// GET //////////////////////////////////////////
@@ -31,10 +31,20 @@ func NewDocument[T DocContent](content T, customerGUID string) Document[T] {
 	return doc
 }
 
+// NewDocumentWithGUID - create new document per doc content T with a client supplied GUID, a new GUID is generated when empty
+func NewDocumentWithGUID[T DocContent](content T, customerGUID, guid string) Document[T] {
+	doc := NewDocument(content, customerGUID)
+	if guid != "" {
+		content.SetGUID(guid)
+		doc.ID = guid
+	}
+	return doc
+}
+
 // Doc Content interface for data types embedded in DB documents
 type DocContent interface {
 	*CustomerConfig | *Cluster | *PostureExceptionPolicy | *VulnerabilityExceptionPolicy | *Customer |
-		*Framework | *Repository | *RegistryCronJob
+		*Framework | *Repository | *RegistryCronJob | *WebhookSubscription
 	InitNew()
 	GetReadOnlyFields() []string
 	//default implementation exist in portal base
@@ -273,9 +283,47 @@ func (r *RegistryCronJob) GetCreationTime() *time.Time {
 	return &creationTime
 }
 
+// Secret - write only string, it is stored in db but it is never encoded in responses
+type Secret string
+
+// MarshalJSON encodes the secret as null so documents with a secret can be sent back in PUT requests without changing it
+func (Secret) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+// WebhookSubscription - subscription of a customer to the changes of documents, the changes are posted to the URL
+type WebhookSubscription struct {
+	armotypes.PortalBase `json:",inline" bson:"inline"`
+	URL                  string   `json:"url,omitempty" bson:"url,omitempty"`
+	Collections          []string `json:"collections,omitempty" bson:"collections,omitempty"` //db collections of the documents
+	EventTypes           []string `json:"eventTypes,omitempty" bson:"eventTypes,omitempty"`   //created, updated or deleted, all the events when empty
+	Secret               Secret   `json:"secret,omitempty" bson:"secret,omitempty"`           //HMAC key of the deliveries signature
+	CreationTime         string   `json:"creationTime" bson:"creationTime"`
+}
+
+func (*WebhookSubscription) GetReadOnlyFields() []string {
+	return webhookSubscriptionReadOnlyFields
+}
+
+func (w *WebhookSubscription) InitNew() {
+	w.CreationTime = time.Now().UTC().Format(time.RFC3339)
+}
+
+func (w *WebhookSubscription) GetCreationTime() *time.Time {
+	if w.CreationTime == "" {
+		return nil
+	}
+	creationTime, err := time.Parse(time.RFC3339, w.CreationTime)
+	if err != nil {
+		return nil
+	}
+	return &creationTime
+}
+
 var commonReadOnlyFields = []string{consts.IdField, consts.NameField, consts.GUIDField}
 var clusterReadOnlyFields = append([]string{"subscription_date"}, commonReadOnlyFields...)
 var exceptionPolicyReadOnlyFields = append([]string{"creationTime"}, commonReadOnlyFields...)
 var customerConfigReadOnlyFields = append([]string{"creationTime"}, commonReadOnlyFields...)
 var repositoryReadOnlyFields = append([]string{"creationDate", "provider", "owner", "repoName", "branchName"}, commonReadOnlyFields...)
 var croneJobReadOnlyFields = append([]string{"creationTime", "clusterName", "registryName"}, commonReadOnlyFields...)
+var webhookSubscriptionReadOnlyFields = append([]string{"creationTime"}, commonReadOnlyFields...)
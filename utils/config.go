@@ -9,11 +9,74 @@ import (
 )
 
 type Configuration struct {
-	Port         string          `json:"port"`
-	Telemetry    TelemetryConfig `json:"telemetry"`
-	Mongo        MongoConfig     `json:"mongo"`
-	LoggerConfig LoggerConfig    `json:"logger"`
-	AdminUsers   []string        `json:"admins"`
+	Port         string            `json:"port"`
+	Server       ServerConfig      `json:"server"`
+	Telemetry    TelemetryConfig   `json:"telemetry"`
+	Mongo        MongoConfig       `json:"mongo"`
+	LoggerConfig LoggerConfig      `json:"logger"`
+	AdminUsers   []string          `json:"admins"`
+	Jobs         JobsConfig        `json:"jobs"`
+	Auth         AuthConfig        `json:"auth"`
+	RateLimit    RateLimitConfig   `json:"rateLimit"`
+	CORS         CORSConfig        `json:"cors"`
+	OpenAPI      OpenAPIConfig     `json:"openAPI"`
+	Idempotency  IdempotencyConfig `json:"idempotency"`
+	Batch        BatchConfig       `json:"batch"`
+	GRPC         GRPCConfig        `json:"grpc"`
+	Webhooks     WebhooksConfig    `json:"webhooks"`
+}
+
+type ServerConfig struct {
+	ReadTimeoutSeconds       int       `json:"readTimeoutSeconds"`       //max duration for reading the entire request, default 60 seconds
+	ReadHeaderTimeoutSeconds int       `json:"readHeaderTimeoutSeconds"` //max duration for reading the request headers, default 10 seconds
+	WriteTimeoutSeconds      int       `json:"writeTimeoutSeconds"`      //max duration before timing out writes of the response, default 120 seconds
+	IdleTimeoutSeconds       int       `json:"idleTimeoutSeconds"`       //max time to wait for the next request on keep-alive connections, default 120 seconds
+	MaxHeaderBytes           int       `json:"maxHeaderBytes"`           //max size of request headers, default 1MB
+	TLS                      TLSConfig `json:"tls"`
+}
+
+type CORSConfig struct {
+	AllowedOrigins   []string `json:"allowedOrigins"`   //allowed origins or "*", CORS is disabled when empty
+	AllowedMethods   []string `json:"allowedMethods"`   //default GET, POST, PUT, DELETE, HEAD
+	AllowedHeaders   []string `json:"allowedHeaders"`   //default Content-Type, Authorization, X-API-Key, X-CSRF-Token, Idempotency-Key
+	ExposedHeaders   []string `json:"exposedHeaders"`   //response headers that the browser can read
+	AllowCredentials bool     `json:"allowCredentials"` //allow cookies in cross-origin requests, cannot be used with "*" origin
+	MaxAgeSeconds    int      `json:"maxAgeSeconds"`    //preflight response cache duration
+}
+
+type OpenAPIConfig struct {
+	SwaggerUI bool `json:"swaggerUI"` //serve swagger UI of the OpenAPI document at /docs
+}
+
+type IdempotencyConfig struct {
+	TTLSeconds int `json:"ttlSeconds"` //how long responses of POST requests with an Idempotency-Key are kept for retries, default 24 hours
+}
+
+type BatchConfig struct {
+	MaxOperations int `json:"maxOperations"` //max operations in a batch request, default 100
+}
+
+type GRPCConfig struct {
+	Port                 string `json:"port"`                 //gRPC server port, the gRPC server is not started when empty, uses the TLS configuration of the server
+	WatchIntervalSeconds int    `json:"watchIntervalSeconds"` //interval to check for changes of the documents of watch calls, default 10 seconds
+	Reflection           bool   `json:"reflection"`           //register the gRPC reflection service for debugging with grpcurl
+}
+
+type WebhooksConfig struct {
+	PollIntervalSeconds   int  `json:"pollIntervalSeconds"`   //interval to check for due deliveries, default 5 seconds
+	TimeoutSeconds        int  `json:"timeoutSeconds"`        //timeout of a delivery request, default 10 seconds
+	MaxAttempts           int  `json:"maxAttempts"`           //max delivery attempts before the delivery is moved to the dead letters, default 8
+	InitialBackoffSeconds int  `json:"initialBackoffSeconds"` //delay of the first retry, doubled in each retry, default 10 seconds
+	MaxBackoffSeconds     int  `json:"maxBackoffSeconds"`     //max delay between retries, default 1 hour
+	RetentionHours        int  `json:"retentionHours"`        //how long delivered deliveries are kept in the delivery log, default 7 days, dead letters are kept until they are redelivered
+	AllowPrivateTargets   bool `json:"allowPrivateTargets"`   //development only, allow subscription URLs of loopback and private network hosts
+}
+
+type TLSConfig struct {
+	CertFile          string `json:"certFile"`          //server certificate file, TLS is enabled when set, the certificate is reloaded when the files change
+	KeyFile           string `json:"keyFile"`           //server private key file
+	ClientCAFile      string `json:"clientCAFile"`      //CA bundle to verify client certificates (mTLS), client certificates are not requested when empty
+	RequireClientCert bool   `json:"requireClientCert"` //reject connections without a verified client certificate
 }
 
 type TelemetryConfig struct {
@@ -26,6 +89,53 @@ type LoggerConfig struct {
 	LogFileName string `json:"logFileName"`
 }
 
+type AuthConfig struct {
+	AllowCookieAuth       bool          `json:"allowCookieAuth"` //development only, when true the customerGUID cookie or query param is trusted without validation
+	JWT                   JWTConfig     `json:"jwt"`
+	Session               SessionConfig `json:"session"`
+	DefaultRole           string        `json:"defaultRole"`           //role of users without a stored role (viewer, editor, security-approver or owner), default viewer
+	DisableCSRFProtection bool          `json:"disableCSRFProtection"` //development only, do not require a double submit CSRF token in state changing requests authenticated by cookies
+	TrustedServices       []string      `json:"trustedServices"`       //common names of verified client certificates that can act for the customer in the X-Customer-GUID header
+	ServiceRole           string        `json:"serviceRole"`           //role of the trusted services in the customer (viewer, editor, security-approver or owner), default editor
+}
+
+type SessionConfig struct {
+	Enabled                 bool   `json:"enabled"`                 //issue signed sessions from POST /login and accept them, not used when JWT authentication is configured
+	Secret                  string `json:"secret"`                  //HMAC secret for signing session tokens, a random secret is generated when empty (sessions are then valid only in this replica)
+	TTLSeconds              int    `json:"ttlSeconds"`              //session expiry, default 2 days
+	Secure                  bool   `json:"secure"`                  //set the Secure flag of the session cookie
+	ImpersonationTTLSeconds int    `json:"impersonationTTLSeconds"` //admin impersonation session expiry, default 1 hour
+}
+
+type JWTConfig struct {
+	JWKSFile          string `json:"jwksFile"`          //path to a JWKS file with the token validation keys
+	Key               string `json:"key"`               //HS256 secret or PEM encoded RS256/ES256 public key, used when jwksFile is not set
+	Issuer            string `json:"issuer"`            //expected "iss" claim, not checked when empty
+	Audience          string `json:"audience"`          //expected "aud" claim, not checked when empty
+	CustomerGUIDClaim string `json:"customerGUIDClaim"` //claim with the customer GUID, default "customerGUID"
+	UserIDClaim       string `json:"userIdClaim"`       //claim with the user id, default "sub"
+	AdminClaim        string `json:"adminClaim"`        //claim that grants admin access, not checked when empty
+	AdminClaimValue   string `json:"adminClaimValue"`   //value (or array item) of the admin claim that grants admin access, when empty the claim must be true
+	LeewaySeconds     int    `json:"leewaySeconds"`     //allowed clock skew for expiry validation
+}
+
+type RateLimitConfig struct {
+	Classes   map[string]RateLimit            `json:"classes"`   //limits by route class ("read", "write") or by route group and class (e.g. "v1_customer_configuration:read"), classes without limits are not limited
+	Customers map[string]map[string]RateLimit `json:"customers"` //limits overrides by customer GUID and class
+}
+
+type RateLimit struct {
+	RequestsPerSecond float64 `json:"requestsPerSecond"` //token bucket refill rate, 0 for no rate limit
+	Burst             int     `json:"burst"`             //token bucket size, default requestsPerSecond
+	MaxInFlight       int     `json:"maxInFlight"`       //max concurrent requests, 0 for no limit
+}
+
+type JobsConfig struct {
+	LeaseSeconds        int `json:"leaseSeconds"`        //job lease duration, default 60 seconds
+	PollIntervalSeconds int `json:"pollIntervalSeconds"` //interval to check for pending jobs, default 5 seconds
+	MaxAttempts         int `json:"maxAttempts"`         //max times a job is claimed before it is failed, failed attempts are retried from the last checkpoint, default 5
+}
+
 type MongoConfig struct {
 	Host       string `json:"host,omitempty"`
 	Port       string `json:"port,omitempty"`
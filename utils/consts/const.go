@@ -11,6 +11,30 @@ const (
 	BodyDecoder    = "customBodyDecoder"    //key for custom body decoder
 	ResponseSender = "customResponseSender" //key for custom response sender
 	PutDocFields   = "customPutDocFields"   //key for string list of fields name to update in PUT requests, only these fields will be updated
+	UserID         = "userId"               //key for the authenticated user id (when known)
+	AuthMethod     = "authMethod"           //key for the authentication method of the request
+	APIKeyScopes   = "apiKeyScopes"         //key for the scopes of the API key of the request
+	UserRole       = "userRole"             //key for the role of the authenticated user
+	Impersonator   = "impersonator"         //key for the admin customer GUID of an impersonation session
+	ImpersonatorID = "impersonatorUserId"   //key for the admin user id of an impersonation session
+	ReadOnly       = "readOnly"             //key for read only session flag
+	ClientGUIDs    = "clientGUIDs"          //key for flag to keep the client supplied GUIDs of POST requests
+	BatchOperation = "batchOperation"       //key for flag of operation requests that are dispatched by a batch request
+
+	//Cookies
+	SessionCookie = "session"    //signed session token issued by login
+	CSRFCookie    = "csrf_token" //CSRF token of the session, readable by the browser client
+
+	//Headers
+	APIKeyHeader             = "X-API-Key"
+	CustomerGUIDHeader       = "X-Customer-GUID" //customer of a trusted service request
+	CSRFHeader               = "X-CSRF-Token"
+	IdempotencyKeyHeader     = "Idempotency-Key"     //key of a retried POST request
+	IdempotentReplayedHeader = "Idempotent-Replayed" //set in responses that are replayed for a retried request
+	WebhookEventHeader       = "X-Webhook-Event"     //event type of a webhook delivery
+	WebhookDeliveryHeader    = "X-Webhook-Delivery"  //id of a webhook delivery, the same in its retries
+	WebhookTimestampHeader   = "X-Webhook-Timestamp" //unix time of a webhook delivery attempt
+	WebhookSignatureHeader   = "X-Webhook-Signature" //HMAC-SHA256 of the timestamp and the body of a webhook delivery
 
 	//PATHS
 	ClusterPath                      = "/cluster"
@@ -25,6 +49,23 @@ const (
 	RegistryCronJobPath              = "/v1_registry_cron_job"
 	NotificationConfigPath           = "/v1_notification_config"
 	CustomerStatePath                = "/v1_customer_state"
+	APIKeysPath                      = "/v1_api_keys"
+	UserRolesPath                    = "/v1_user_roles"
+	BatchPath                        = "/batch"
+	GraphQLPath                      = "/graphql"
+	GraphQLSchemaPath                = GraphQLPath + "/schema"
+	WebhookPath                      = "/v1_webhook"
+	LoginPath                        = "/login"
+	LogoutPath                       = "/logout"
+
+	//v2 PATHS
+	V2Path                               = "/v2"
+	V2ClustersPath                       = V2Path + "/clusters"
+	V2PostureExceptionPoliciesPath       = V2Path + "/posture-exception-policies"
+	V2VulnerabilityExceptionPoliciesPath = V2Path + "/vulnerability-exception-policies"
+	V2FrameworksPath                     = V2Path + "/frameworks"
+	V2RepositoriesPath                   = V2Path + "/repositories"
+	V2RegistryCronJobsPath               = V2Path + "/registry-cron-jobs"
 
 	//DB collections
 	ClustersCollection                     = "clusters"
@@ -35,6 +76,15 @@ const (
 	FrameworkCollection                    = "v1_opa_frameworks"
 	RepositoryCollection                   = "v1_repositories"
 	RegistryCronJobCollection              = "v1_registry_cron_jobs"
+	JobsCollection                         = "jobs"
+	SessionsCollection                     = "sessions"
+	APIKeysCollection                      = "v1_api_keys"
+	UserRolesCollection                    = "v1_user_roles"
+	RateLimitsCollection                   = "rate_limits"
+	IdempotencyKeysCollection              = "idempotency_keys"
+	AuditLogCollection                     = "audit_log"
+	WebhookCollection                      = "v1_webhooks"
+	WebhookDeliveriesCollection            = "webhook_deliveries"
 
 	//Common document fields
 	IdField          = "_id"
@@ -47,6 +97,9 @@ const (
 	//cluster fields
 	ShortNameAttribute = "alias"
 	ShortNameField     = AttributesField + "." + ShortNameAttribute
+	//marker attribute of the documents created by a desired state sync
+	ManagedByAttribute = "managedBy"
+	ManagedByField     = AttributesField + "." + ManagedByAttribute
 
 	//Query params
 	ListParam          = "list"
@@ -57,6 +110,14 @@ const (
 	SkipParam          = "skip"
 	FromDateParam      = "fromDate"
 	ToDateParam        = "toDate"
+	JobIdParam         = "jobId"
+	UserIdParam        = "userId"
+	StatusParam        = "status"
+	SubscriptionParam  = "subscriptionGUID"
+	DryRunParam        = "dryRun"
+	ManagedByParam     = "managedBy"
+	AdoptParam         = "adopt"
+	StrictParam        = "strict"
 
 	//Cached documents keys
 	DefaultCustomerConfigKey = "defaultCustomerConfig"
@@ -0,0 +1,131 @@
+package tlsconfig
+
+import (
+	"config-service/utils"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// minimal interval between checks of the certificate files modification time
+const defaultReloadCheckInterval = 10 * time.Second
+
+// New returns the server TLS configuration with a certificate that is reloaded when the files change
+// and with client certificates verification when a client CA file is configured
+func New(config utils.TLSConfig) (*tls.Config, error) {
+	reloader, err := NewCertReloader(config.CertFile, config.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+	if config.ClientCAFile != "" {
+		caBytes, err := os.ReadFile(config.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caBytes) {
+			return nil, fmt.Errorf("no certificates found in client CA file %s", config.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		if config.RequireClientCert {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return tlsConfig, nil
+}
+
+// CertReloader loads a key pair and reloads it when the cert or key file modification time changes
+type CertReloader struct {
+	certFile      string
+	keyFile       string
+	checkInterval time.Duration
+
+	mu          sync.RWMutex
+	cert        *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+	lastCheck   time.Time
+}
+
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile, checkInterval: defaultReloadCheckInterval}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate returns the current certificate, it can be used as tls.Config.GetCertificate
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	cert, lastCheck := r.cert, r.lastCheck
+	r.mu.RUnlock()
+	if time.Since(lastCheck) < r.checkInterval {
+		return cert, nil
+	}
+	if changed, err := r.changed(); err != nil {
+		zap.L().Error("failed to check tls certificate files", zap.Error(err))
+	} else if changed {
+		if err := r.reload(); err != nil {
+			//keep serving with the previous certificate
+			zap.L().Error("failed to reload tls certificate", zap.Error(err))
+		} else {
+			zap.L().Info("tls certificate reloaded", zap.String("certFile", r.certFile))
+		}
+	}
+	r.mu.Lock()
+	r.lastCheck = time.Now()
+	cert = r.cert
+	r.mu.Unlock()
+	return cert, nil
+}
+
+func (r *CertReloader) changed() (bool, error) {
+	certModTime, keyModTime, err := r.modTimes()
+	if err != nil {
+		return false, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return !certModTime.Equal(r.certModTime) || !keyModTime.Equal(r.keyModTime), nil
+}
+
+func (r *CertReloader) reload() error {
+	certModTime, keyModTime, err := r.modTimes()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load tls key pair: %w", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.certModTime = certModTime
+	r.keyModTime = keyModTime
+	r.lastCheck = time.Now()
+	return nil
+}
+
+func (r *CertReloader) modTimes() (certModTime, keyModTime time.Time, err error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return certModTime, keyModTime, err
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return certModTime, keyModTime, err
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
@@ -0,0 +1,148 @@
+package tlsconfig
+
+import (
+	"config-service/utils"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newTestCert(t *testing.T, commonName string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signerCert, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}),
+	}
+}
+
+func writeFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	first := newTestCert(t, "first", nil)
+	modTime := time.Now().Add(-time.Minute)
+	writeFile(t, certFile, first.certPEM, modTime)
+	writeFile(t, keyFile, first.keyPEM, modTime)
+
+	reloader, err := NewCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+	reloader.checkInterval = 0
+	cert, err := reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, first.cert.Raw, cert.Certificate[0])
+
+	//replace the key pair
+	second := newTestCert(t, "second", nil)
+	writeFile(t, certFile, second.certPEM, time.Now())
+	writeFile(t, keyFile, second.keyPEM, time.Now())
+	cert, err = reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, second.cert.Raw, cert.Certificate[0], "certificate should be reloaded")
+
+	//invalid files keep the previous certificate
+	writeFile(t, keyFile, []byte("invalid"), time.Now().Add(time.Minute))
+	cert, err = reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, second.cert.Raw, cert.Certificate[0])
+
+	_, err = NewCertReloader(filepath.Join(dir, "missing.crt"), keyFile)
+	assert.Error(t, err)
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "test-ca", nil)
+	server := newTestCert(t, "127.0.0.1", ca)
+	client := newTestCert(t, "internal-service", ca)
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+	writeFile(t, certFile, server.certPEM, time.Now())
+	writeFile(t, keyFile, server.keyPEM, time.Now())
+	writeFile(t, caFile, ca.certPEM, time.Now())
+
+	tlsConfig, err := New(utils.TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile, RequireClientCert: true})
+	require.NoError(t, err)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	require.NoError(t, err)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.VerifiedChains[0][0].Subject.CommonName))
+	}), ErrorLog: log.New(io.Discard, "", 0)}
+	go func() { _ = srv.Serve(listener) }()
+	defer srv.Close()
+	url := "https://" + listener.Addr().String()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientTLS := func(withCert bool) *http.Client {
+		conf := &tls.Config{RootCAs: roots}
+		if withCert {
+			keyPair, err := tls.X509KeyPair(client.certPEM, client.keyPEM)
+			require.NoError(t, err)
+			conf.Certificates = []tls.Certificate{keyPair}
+		}
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: conf}}
+	}
+	resp, err := clientTLS(true).Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "internal-service", string(body))
+
+	_, err = clientTLS(false).Get(url)
+	assert.Error(t, err, "connection without client certificate should be rejected")
+}
@@ -0,0 +1,315 @@
+package webhooks
+
+import (
+	"bytes"
+	"config-service/db/mongo"
+	"config-service/types"
+	"config-service/utils"
+	"config-service/utils/consts"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	rndStr "github.com/dchest/uniuri"
+	"go.mongodb.org/mongo-driver/bson"
+	mongoDB "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPollInterval   = 5 * time.Second
+	defaultTimeout        = 10 * time.Second
+	defaultMaxAttempts    = 8
+	defaultInitialBackoff = 10 * time.Second
+	defaultMaxBackoff     = time.Hour
+	defaultRetention      = 7 * 24 * time.Hour
+	//max response body that is read to keep the connection for the next deliveries
+	maxResponseBody = 64 * 1024
+)
+
+var errPrivateTarget = errors.New("delivery to loopback and private network addresses is not allowed")
+
+// wakeup channel to let the local dispatcher deliver new deliveries without waiting for the next poll
+var wakeup = make(chan struct{}, 1)
+
+func notifyDispatcher() {
+	select {
+	case wakeup <- struct{}{}:
+	default:
+	}
+}
+
+type dispatcher struct {
+	id             string
+	client         *http.Client
+	leaseDuration  time.Duration
+	pollInterval   time.Duration
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+// StartDispatcher starts the replica deliveries dispatcher and returns a function that stops it and waits for the running delivery to end
+func StartDispatcher(config utils.WebhooksConfig) (stop func()) {
+	d := newDispatcher(config)
+	retention := defaultRetention
+	if config.RetentionHours > 0 {
+		retention = time.Duration(config.RetentionHours) * time.Hour
+	}
+	index := mongoDB.IndexModel{
+		Keys:    bson.D{{Key: "deliveredTime", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(retention.Seconds())),
+	}
+	if _, err := mongo.GetWriteCollection(consts.WebhookDeliveriesCollection).Indexes().CreateOne(context.Background(), index); err != nil {
+		//delivered deliveries are only not removed from the delivery log
+		zap.L().Error("failed to create webhook deliveries expiration index", zap.Error(err))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.run(ctx)
+	}()
+	zap.L().Info("webhooks dispatcher started", zap.String("dispatcher", d.id))
+	return func() {
+		cancel()
+		wg.Wait()
+		zap.L().Info("webhooks dispatcher stopped", zap.String("dispatcher", d.id))
+	}
+}
+
+func newDispatcher(config utils.WebhooksConfig) *dispatcher {
+	hostName, _ := os.Hostname()
+	d := &dispatcher{
+		id:             fmt.Sprintf("%s-%s", hostName, rndStr.NewLen(6)),
+		pollInterval:   defaultPollInterval,
+		maxAttempts:    defaultMaxAttempts,
+		initialBackoff: defaultInitialBackoff,
+		maxBackoff:     defaultMaxBackoff,
+	}
+	timeout := defaultTimeout
+	if config.TimeoutSeconds > 0 {
+		timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	}
+	if config.PollIntervalSeconds > 0 {
+		d.pollInterval = time.Duration(config.PollIntervalSeconds) * time.Second
+	}
+	if config.MaxAttempts > 0 {
+		d.maxAttempts = config.MaxAttempts
+	}
+	if config.InitialBackoffSeconds > 0 {
+		d.initialBackoff = time.Duration(config.InitialBackoffSeconds) * time.Second
+	}
+	if config.MaxBackoffSeconds > 0 {
+		d.maxBackoff = time.Duration(config.MaxBackoffSeconds) * time.Second
+	}
+	//a delivery that is not completed in its lease (e.g. the replica crashed) is claimed again
+	d.leaseDuration = timeout + time.Minute
+	d.client = newClient(timeout, config.AllowPrivateTargets)
+	return d
+}
+
+// newClient returns the client of the deliveries, redirects are not followed and the connections to private addresses are
+// refused after the host name is resolved so subscriptions cannot reach the internal services
+func newClient(timeout time.Duration, allowPrivateTargets bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivateTargets {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isPrivateIP(ip) {
+				return errPrivateTarget
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast()
+}
+
+func (d *dispatcher) run(ctx context.Context) {
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+	for {
+		//deliver until there is nothing to claim
+		for ctx.Err() == nil {
+			delivery, err := d.claim(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					zap.L().Error("failed to claim webhook delivery", zap.Error(err))
+				}
+				break
+			}
+			if delivery == nil {
+				break
+			}
+			d.deliver(ctx, delivery)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wakeup:
+		}
+	}
+}
+
+// claim takes the lease of the earliest due pending delivery or of a delivery with an expired lease
+func (d *dispatcher) claim(ctx context.Context) (*Delivery, error) {
+	now := time.Now().UTC()
+	filter := bson.D{
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "status", Value: StatusPending}, {Key: "nextAttemptTime", Value: bson.D{{Key: "$lte", Value: now}}}},
+			bson.D{{Key: "status", Value: StatusDelivering}, {Key: "leaseExpiration", Value: bson.D{{Key: "$lt", Value: now}}}},
+		}},
+	}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "status", Value: StatusDelivering},
+			{Key: "owner", Value: d.id},
+			{Key: "leaseExpiration", Value: now.Add(d.leaseDuration)},
+			{Key: "updatedTime", Value: now},
+		}},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "nextAttemptTime", Value: 1}}).
+		SetReturnDocument(options.After)
+	var delivery Delivery
+	if err := mongo.GetWriteCollection(consts.WebhookDeliveriesCollection).FindOneAndUpdate(ctx, filter, update, opts).Decode(&delivery); err != nil {
+		if err == mongoDB.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// deliver posts the delivery to the current URL of its subscription and records the attempt
+func (d *dispatcher) deliver(ctx context.Context, delivery *Delivery) {
+	logger := zap.L().With(zap.String("deliveryId", delivery.ID), zap.String("subscription", delivery.SubscriptionGUID))
+	var subscription types.WebhookSubscription
+	err := mongo.GetReadCollection(consts.WebhookCollection).FindOne(ctx,
+		bson.D{{Key: consts.IdField, Value: delivery.SubscriptionGUID}, {Key: consts.CustomersField, Value: delivery.CustomerGUID}}).
+		Decode(&subscription)
+	if err == mongoDB.ErrNoDocuments {
+		//deliveries of a deleted subscription are not retried
+		d.finish(ctx, delivery, Attempt{Time: time.Now().UTC(), Error: "subscription not found"}, true)
+		return
+	} else if err != nil {
+		if ctx.Err() == nil {
+			logger.Error("failed to read webhook subscription", zap.Error(err))
+		}
+		//the delivery is claimed again when the lease expires
+		return
+	}
+	attempt := d.send(ctx, subscription.URL, subscription.Secret, delivery)
+	if ctx.Err() != nil {
+		//this replica is shutting down - the delivery is claimed again when the lease expires
+		return
+	}
+	if attempt.Error != "" {
+		logger.Warn("webhook delivery failed", zap.String("error", attempt.Error), zap.Int("statusCode", attempt.StatusCode))
+	}
+	d.finish(ctx, delivery, attempt, false)
+}
+
+// send posts the payload of the delivery to the url with the signature of the secret
+func (d *dispatcher) send(ctx context.Context, url string, secret types.Secret, delivery *Delivery) Attempt {
+	start := time.Now().UTC()
+	attempt := Attempt{Time: start, URL: url}
+	defer func() {
+		attempt.DurationMs = time.Since(start).Milliseconds()
+	}()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	timestamp := strconv.FormatInt(start.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "config-service-webhooks")
+	req.Header.Set(consts.WebhookEventHeader, string(delivery.EventType))
+	req.Header.Set(consts.WebhookDeliveryHeader, delivery.ID)
+	req.Header.Set(consts.WebhookTimestampHeader, timestamp)
+	req.Header.Set(consts.WebhookSignatureHeader, Sign(secret, timestamp, delivery.Payload))
+	resp, err := d.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("unexpected response status %d", resp.StatusCode)
+	}
+	return attempt
+}
+
+// finish records the attempt and releases the lease, a failed delivery is scheduled for a retry or moved to the dead letters
+func (d *dispatcher) finish(ctx context.Context, delivery *Delivery, attempt Attempt, dead bool) {
+	now := time.Now().UTC()
+	set := bson.D{{Key: "updatedTime", Value: now}}
+	inc := bson.D{}
+	if attempt.Error == "" {
+		set = append(set, bson.E{Key: "status", Value: StatusDelivered}, bson.E{Key: "deliveredTime", Value: now})
+	} else {
+		failedAttempts := delivery.FailedAttempts + 1
+		inc = append(inc, bson.E{Key: "failedAttempts", Value: 1})
+		if dead || failedAttempts >= d.maxAttempts {
+			set = append(set, bson.E{Key: "status", Value: StatusDead})
+		} else {
+			set = append(set, bson.E{Key: "status", Value: StatusPending}, bson.E{Key: "nextAttemptTime", Value: now.Add(d.backoff(failedAttempts))})
+		}
+	}
+	update := bson.D{
+		{Key: "$set", Value: set},
+		{Key: "$push", Value: bson.D{{Key: "attempts", Value: attempt}}},
+		{Key: "$unset", Value: bson.D{{Key: "owner", Value: ""}, {Key: "leaseExpiration", Value: ""}}},
+	}
+	if len(inc) > 0 {
+		update = append(update, bson.E{Key: "$inc", Value: inc})
+	}
+	if _, err := mongo.GetWriteCollection(consts.WebhookDeliveriesCollection).UpdateOne(ctx,
+		bson.D{{Key: consts.IdField, Value: delivery.ID}, {Key: "owner", Value: d.id}, {Key: "status", Value: StatusDelivering}},
+		update); err != nil {
+		zap.L().Error("failed to update webhook delivery status", zap.String("deliveryId", delivery.ID), zap.Error(err))
+	}
+}
+
+// backoff returns the delay before the retry of a delivery after its failed attempts, doubled in each retry up to the max backoff
+func (d *dispatcher) backoff(failedAttempts int) time.Duration {
+	delay := d.initialBackoff
+	for i := 1; i < failedAttempts && delay < d.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.maxBackoff {
+		return d.maxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,226 @@
+package webhooks
+
+import (
+	"config-service/db"
+	"config-service/db/mongo"
+	"config-service/types"
+	"config-service/utils/consts"
+	"config-service/utils/log"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	mongoDB "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/exp/slices"
+)
+
+// outbound webhooks of documents changes
+// the changes are added as deliveries to the webhook_deliveries collection (the outbox) with the context of the write,
+// so in atomic batches they are added in the batch transaction, and are posted to the subscriptions URLs by the dispatcher of one of the replicas.
+// failed deliveries are retried with exponential backoff and are moved to the dead letters after max attempts
+
+type EventType string
+
+const (
+	EventCreated EventType = "created"
+	EventUpdated EventType = "updated"
+	EventDeleted EventType = "deleted"
+)
+
+// EventTypes - the event types that can be subscribed
+var EventTypes = []EventType{EventCreated, EventUpdated, EventDeleted}
+
+type Status string
+
+const (
+	StatusPending    Status = "pending"
+	StatusDelivering Status = "delivering"
+	StatusDelivered  Status = "delivered"
+	StatusDead       Status = "dead"
+)
+
+// Event - body of a delivery
+type Event struct {
+	ID           string      `json:"id"`
+	Type         EventType   `json:"type"`
+	Collection   string      `json:"collection"`
+	CustomerGUID string      `json:"customerGUID"`
+	Time         time.Time   `json:"time"`
+	Documents    interface{} `json:"documents"`
+}
+
+// Attempt - result of a delivery attempt
+type Attempt struct {
+	Time       time.Time `json:"time" bson:"time"`
+	URL        string    `json:"url" bson:"url"`
+	StatusCode int       `json:"statusCode,omitempty" bson:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty" bson:"error,omitempty"`
+	DurationMs int64     `json:"durationMs" bson:"durationMs"`
+}
+
+// Delivery - delivery of an event to a subscription in db
+type Delivery struct {
+	ID               string          `json:"id" bson:"_id"`
+	CustomerGUID     string          `json:"customerGUID" bson:"customerGUID"`
+	SubscriptionGUID string          `json:"subscriptionGUID" bson:"subscriptionGUID"`
+	EventID          string          `json:"eventId" bson:"eventId"`
+	EventType        EventType       `json:"eventType" bson:"eventType"`
+	Collection       string          `json:"collection" bson:"collection"`
+	Payload          json.RawMessage `json:"payload" bson:"payload"`
+	Status           Status          `json:"status" bson:"status"`
+	Attempts         []Attempt       `json:"attempts" bson:"attempts"`
+	FailedAttempts   int             `json:"failedAttempts" bson:"failedAttempts"` //failed attempts since the delivery was added or redelivered
+	NextAttemptTime  time.Time       `json:"nextAttemptTime" bson:"nextAttemptTime"`
+	Owner            string          `json:"-" bson:"owner,omitempty"`
+	LeaseExpiration  *time.Time      `json:"-" bson:"leaseExpiration,omitempty"`
+	CreationTime     time.Time       `json:"creationTime" bson:"creationTime"`
+	UpdatedTime      time.Time       `json:"updatedTime" bson:"updatedTime"`
+	DeliveredTime    *time.Time      `json:"deliveredTime,omitempty" bson:"deliveredTime,omitempty"`
+}
+
+// Enqueue adds deliveries of the event of the documents to the subscriptions of the customer,
+// the collection and the customer are read from the context of the write
+func Enqueue[T any](c context.Context, eventType EventType, docs []T) error {
+	defer log.LogNTraceEnterExit("webhooks.Enqueue", c)()
+	collection, _ := c.Value(consts.Collection).(string)
+	customerGUID, _ := c.Value(consts.CustomerGUID).(string)
+	//changes of subscriptions are not delivered
+	if len(docs) == 0 || collection == "" || customerGUID == "" || collection == consts.WebhookCollection {
+		return nil
+	}
+	customerSubscriptions, err := findSubscriptions(c)
+	if err != nil {
+		return err
+	}
+	subscriptions := []*types.WebhookSubscription{}
+	for _, subscription := range customerSubscriptions {
+		if Subscribed(subscription, collection, eventType) {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	event := Event{
+		ID:           uuid.NewV4().String(),
+		Type:         eventType,
+		Collection:   collection,
+		CustomerGUID: customerGUID,
+		Time:         now,
+		Documents:    docs,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	deliveries := make([]interface{}, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		deliveries = append(deliveries, Delivery{
+			ID:               uuid.NewV4().String(),
+			CustomerGUID:     customerGUID,
+			SubscriptionGUID: subscription.GUID,
+			EventID:          event.ID,
+			EventType:        eventType,
+			Collection:       collection,
+			Payload:          payload,
+			Status:           StatusPending,
+			Attempts:         []Attempt{},
+			NextAttemptTime:  now,
+			CreationTime:     now,
+			UpdatedTime:      now,
+		})
+	}
+	if _, err := mongo.GetWriteCollection(consts.WebhookDeliveriesCollection).InsertMany(c, deliveries); err != nil {
+		return err
+	}
+	notifyDispatcher()
+	return nil
+}
+
+// Subscribed returns true if the subscription is for the event of documents in the collection
+func Subscribed(subscription *types.WebhookSubscription, collection string, eventType EventType) bool {
+	if !slices.Contains(subscription.Collections, collection) {
+		return false
+	}
+	return len(subscription.EventTypes) == 0 || slices.Contains(subscription.EventTypes, string(eventType))
+}
+
+// Sign returns the signature header value of a delivery body that is sent at the timestamp
+func Sign(secret types.Secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ListDeliveries returns the deliveries of the customer, latest first, filtered by the not empty subscription and status
+func ListDeliveries(c context.Context, customerGUID, subscriptionGUID string, status Status, limit, skip int64) ([]Delivery, error) {
+	defer log.LogNTraceEnterExit("webhooks.ListDeliveries", c)()
+	filter := bson.D{{Key: "customerGUID", Value: customerGUID}}
+	if subscriptionGUID != "" {
+		filter = append(filter, bson.E{Key: "subscriptionGUID", Value: subscriptionGUID})
+	}
+	if status != "" {
+		filter = append(filter, bson.E{Key: "status", Value: status})
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "creationTime", Value: -1}}).
+		SetLimit(limit).
+		SetSkip(skip)
+	cur, err := mongo.GetReadCollection(consts.WebhookDeliveriesCollection).Find(c, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	deliveries := []Delivery{}
+	if err := cur.All(c, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// Redeliver moves a dead delivery of the customer back to the outbox for another round of attempts,
+// it returns nil if there is no such dead delivery
+func Redeliver(c context.Context, customerGUID, id string) (*Delivery, error) {
+	defer log.LogNTraceEnterExit("webhooks.Redeliver", c)()
+	now := time.Now().UTC()
+	var delivery Delivery
+	if err := mongo.GetWriteCollection(consts.WebhookDeliveriesCollection).FindOneAndUpdate(c,
+		bson.D{{Key: consts.IdField, Value: id}, {Key: "customerGUID", Value: customerGUID}, {Key: "status", Value: StatusDead}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: StatusPending},
+			{Key: "failedAttempts", Value: 0},
+			{Key: "nextAttemptTime", Value: now},
+			{Key: "updatedTime", Value: now},
+		}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&delivery); err != nil {
+		if err == mongoDB.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	notifyDispatcher()
+	return &delivery, nil
+}
+
+// findSubscriptions returns the subscriptions of the customer in the context
+func findSubscriptions(c context.Context) ([]*types.WebhookSubscription, error) {
+	filter := db.NewFilterBuilder().WithNotDeleteForCustomer(c).Get()
+	cur, err := mongo.GetReadCollection(consts.WebhookCollection).Find(c, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhook subscriptions: %w", err)
+	}
+	subscriptions := []*types.WebhookSubscription{}
+	if err := cur.All(c, &subscriptions); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
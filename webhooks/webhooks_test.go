@@ -0,0 +1,120 @@
+package webhooks
+
+import (
+	"config-service/types"
+	"config-service/utils"
+	"config-service/utils/consts"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribed(t *testing.T) {
+	subscription := &types.WebhookSubscription{Collections: []string{consts.ClustersCollection}}
+	assert.True(t, Subscribed(subscription, consts.ClustersCollection, EventCreated), "all the events when no event types")
+	assert.True(t, Subscribed(subscription, consts.ClustersCollection, EventDeleted))
+	assert.False(t, Subscribed(subscription, consts.FrameworkCollection, EventCreated))
+	subscription.EventTypes = []string{string(EventDeleted)}
+	assert.False(t, Subscribed(subscription, consts.ClustersCollection, EventCreated))
+	assert.True(t, Subscribed(subscription, consts.ClustersCollection, EventDeleted))
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	mac := hmac.New(sha256.New, []byte("secret-of-the-subscription"))
+	mac.Write([]byte("1673344800." + string(body)))
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), Sign("secret-of-the-subscription", "1673344800", body))
+	assert.NotEqual(t, Sign("secret-of-the-subscription", "1673344800", body), Sign("secret-of-the-subscription", "1673344801", body), "timestamp is signed")
+}
+
+func TestBackoff(t *testing.T) {
+	d := newDispatcher(utils.WebhooksConfig{InitialBackoffSeconds: 10, MaxBackoffSeconds: 60})
+	assert.Equal(t, 10*time.Second, d.backoff(1))
+	assert.Equal(t, 20*time.Second, d.backoff(2))
+	assert.Equal(t, 40*time.Second, d.backoff(3))
+	assert.Equal(t, time.Minute, d.backoff(4))
+	assert.Equal(t, time.Minute, d.backoff(100))
+}
+
+func TestSend(t *testing.T) {
+	var received *http.Request
+	var receivedBody []byte
+	status := http.StatusOK
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer receiver.Close()
+
+	d := newDispatcher(utils.WebhooksConfig{AllowPrivateTargets: true})
+	delivery := &Delivery{ID: "delivery1", EventType: EventUpdated, Payload: json.RawMessage(`{"id":"event1"}`)}
+	attempt := d.send(context.Background(), receiver.URL, "secret-of-the-subscription", delivery)
+	assert.Empty(t, attempt.Error)
+	assert.Equal(t, http.StatusOK, attempt.StatusCode)
+	assert.Equal(t, receiver.URL, attempt.URL)
+	require.NotNil(t, received)
+	assert.Equal(t, http.MethodPost, received.Method)
+	assert.JSONEq(t, `{"id":"event1"}`, string(receivedBody))
+	assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+	assert.Equal(t, "updated", received.Header.Get(consts.WebhookEventHeader))
+	assert.Equal(t, "delivery1", received.Header.Get(consts.WebhookDeliveryHeader))
+	timestamp := received.Header.Get(consts.WebhookTimestampHeader)
+	assert.Equal(t, Sign("secret-of-the-subscription", timestamp, receivedBody), received.Header.Get(consts.WebhookSignatureHeader))
+
+	status = http.StatusServiceUnavailable
+	attempt = d.send(context.Background(), receiver.URL, "secret-of-the-subscription", delivery)
+	assert.Equal(t, http.StatusServiceUnavailable, attempt.StatusCode)
+	assert.Equal(t, "unexpected response status 503", attempt.Error)
+
+	//redirects are failed deliveries
+	status = http.StatusFound
+	attempt = d.send(context.Background(), receiver.URL, "secret-of-the-subscription", delivery)
+	assert.Equal(t, "unexpected response status 302", attempt.Error)
+}
+
+func TestSendPrivateTarget(t *testing.T) {
+	called := false
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer receiver.Close()
+	d := newDispatcher(utils.WebhooksConfig{})
+	attempt := d.send(context.Background(), receiver.URL, "secret-of-the-subscription", &Delivery{ID: "delivery1", Payload: json.RawMessage(`{}`)})
+	assert.Contains(t, attempt.Error, errPrivateTarget.Error())
+	assert.False(t, called)
+
+	assert.True(t, isPrivateIP(net.ParseIP("10.0.0.1")))
+	assert.True(t, isPrivateIP(net.ParseIP("169.254.169.254")), "cloud metadata address")
+	assert.True(t, isPrivateIP(net.ParseIP("::1")))
+	assert.False(t, isPrivateIP(net.ParseIP("8.8.8.8")))
+}
+
+func TestSecretEncoding(t *testing.T) {
+	subscription := &types.WebhookSubscription{URL: "https://hooks.example.com", Secret: "secret-of-the-subscription"}
+	data, err := json.Marshal(subscription)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret-of-the-subscription")
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Contains(t, decoded, "secret")
+	assert.Nil(t, decoded["secret"])
+
+	//the secret is set by requests
+	require.NoError(t, json.Unmarshal([]byte(`{"secret":"new-secret-of-the-subscription"}`), subscription))
+	assert.Equal(t, types.Secret("new-secret-of-the-subscription"), subscription.Secret)
+	//null keeps the secret
+	require.NoError(t, json.Unmarshal(data, subscription))
+	assert.Equal(t, types.Secret("new-secret-of-the-subscription"), subscription.Secret)
+}